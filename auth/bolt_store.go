@@ -0,0 +1,247 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hrygo/log"
+	bolt "go.etcd.io/bbolt"
+	"gopkg.in/yaml.v3"
+)
+
+// BoltStore 本地嵌入式数据库存储，适合单节点部署；每次读写时打开数据库文件，
+// 以便 gosmsctl 等其他进程在服务运行期间修改，修改后通过监听文件变更重新加载
+type BoltStore storage
+
+var boltBucket = []byte("clients")
+
+// path 数据库文件路径
+func (b *BoltStore) path() string {
+	name := b.Config.GetString("AuthClient.BoltPath")
+	if "" == name {
+		name = "data/auth.db"
+	}
+	if filepath.IsAbs(name) {
+		return name
+	}
+	return b.Config.BasePath() + name
+}
+
+// open 打开数据库，其他进程占用时最多等待 5 秒
+func (b *BoltStore) open(readOnly bool) (*bolt.DB, error) {
+	if !readOnly {
+		if err := os.MkdirAll(filepath.Dir(b.path()), 0755); err != nil {
+			return nil, err
+		}
+	}
+	return bolt.Open(b.path(), 0600, &bolt.Options{Timeout: 5 * time.Second, ReadOnly: readOnly})
+}
+
+// view 在只读事务中执行 fn，数据库文件不存在时视为空库
+func (b *BoltStore) view(fn func(bk *bolt.Bucket) error) error {
+	if _, err := os.Stat(b.path()); os.IsNotExist(err) {
+		return nil
+	}
+	db, err := b.open(true)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = db.Close() }()
+	return db.View(func(tx *bolt.Tx) error {
+		bk := tx.Bucket(boltBucket)
+		if bk == nil {
+			return nil
+		}
+		return fn(bk)
+	})
+}
+
+// update 在读写事务中执行 fn，fn 返回错误时事务回滚，成功后重新加载缓存
+func (b *BoltStore) update(fn func(bk *bolt.Bucket) error) error {
+	db, err := b.open(false)
+	if err != nil {
+		return err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		bk, err := tx.CreateBucketIfNotExists(boltBucket)
+		if err != nil {
+			return err
+		}
+		return fn(bk)
+	})
+	if cerr := db.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if err = b.Load(); err != nil {
+		log.Errorf("[Auth] Load clients error: %v", err)
+	}
+	return nil
+}
+
+// Load 加载数据库中的全部客户端，数据库无法打开时保留已加载的配置；
+// 单个客户端无法解析时沿用其已加载的配置，错误信息中注明其存储键
+func (b *BoltStore) Load() error {
+	var errs LoadError
+	fresh := make(map[string]*Client)
+	err := b.view(func(bk *bolt.Bucket) error {
+		return bk.ForEach(func(k, v []byte) error {
+			c := &Client{}
+			err := json.Unmarshal(v, c)
+			if err == nil {
+				err = c.Resolve()
+			}
+			if err != nil {
+				errs = append(errs, &FileError{File: b.path(), Err: fmt.Errorf("%s: %w", k, err)})
+				if old := (*storage)(b).get(string(k)); old != nil {
+					fresh[old.Key()] = old
+				}
+				return nil
+			}
+			if err = c.Validate(); err != nil {
+				log.Warnf("Invalid client %s in %s: %v", k, b.path(), err)
+			}
+			fresh[c.Key()] = c
+			return nil
+		})
+	})
+	if err != nil {
+		return LoadError{{File: b.path(), Err: err}}
+	}
+	(*storage)(b).replace(fresh)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (b *BoltStore) FindByCid(isp string, cid string) *Client {
+	return (*storage)(b).get(Key(isp, cid))
+}
+
+// watch 监听数据库文件，其他进程修改后重新加载
+func (b *BoltStore) watch() error {
+	if err := os.MkdirAll(filepath.Dir(b.path()), 0755); err != nil {
+		return err
+	}
+	base := filepath.Base(b.path())
+	return watchDir(filepath.Dir(b.path()), func(name string) bool { return name == base }, func() {
+		if err := b.Load(); err != nil {
+			log.Errorf("[Auth] Load clients error: %v", err)
+		}
+	})
+}
+
+func (b *BoltStore) Create(c *Client) error {
+	if err := prepare(c); err != nil {
+		return err
+	}
+	return b.update(func(bk *bolt.Bucket) error {
+		if bk.Get([]byte(c.Key())) != nil {
+			return ErrExists
+		}
+		return boltPut(bk, c)
+	})
+}
+
+func (b *BoltStore) Update(c *Client) error {
+	if err := prepare(c); err != nil {
+		return err
+	}
+	return b.update(func(bk *bolt.Bucket) error {
+		if bk.Get([]byte(c.Key())) == nil {
+			return ErrNotFound
+		}
+		return boltPut(bk, c)
+	})
+}
+
+func (b *BoltStore) Delete(isp string, cid string) error {
+	key := []byte(Key(isp, cid))
+	return b.update(func(bk *bolt.Bucket) error {
+		if bk.Get(key) == nil {
+			return ErrNotFound
+		}
+		return bk.Delete(key)
+	})
+}
+
+func (b *BoltStore) List() []*Client {
+	return (*storage)(b).list()
+}
+
+// Export 以 file 存储的 yaml 格式导出全部客户端，密钥保留原引用
+func (b *BoltStore) Export(w io.Writer) error {
+	doc := &fileDoc{}
+	err := b.view(func(bk *bolt.Bucket) error {
+		return bk.ForEach(func(k, v []byte) error {
+			c := &Client{}
+			if err := json.Unmarshal(v, c); err != nil {
+				return fmt.Errorf("%s: %w", k, err)
+			}
+			doc.Clients = append(doc.Clients, c)
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+	sortClients(doc.Clients)
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err = enc.Encode(doc); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// Import 导入 file 存储格式的 yaml 内容，新增或覆盖其中的客户端；
+// 全部客户端校验通过后在一个事务中写入，任一客户端有误时不做修改
+func (b *BoltStore) Import(r io.Reader) (int, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return 0, err
+	}
+	doc := &fileDoc{}
+	if err = yaml.Unmarshal(data, doc); err != nil {
+		return 0, err
+	}
+	for i, c := range doc.Clients {
+		if c == nil {
+			return 0, fmt.Errorf("clients[%d]: empty", i)
+		}
+		if c.ISP == "" {
+			return 0, fmt.Errorf("clients[%d].isp: required", i)
+		}
+		if err = prepare(c); err != nil {
+			return 0, fmt.Errorf("clients[%d].%w", i, err)
+		}
+	}
+	err = b.update(func(bk *bolt.Bucket) error {
+		for _, c := range doc.Clients {
+			if err := boltPut(bk, c); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(doc.Clients), nil
+}
+
+// boltPut 写入客户端配置，密钥保留原引用
+func boltPut(bk *bolt.Bucket, c *Client) error {
+	v, err := json.Marshal(c.stored())
+	if err != nil {
+		return err
+	}
+	return bk.Put([]byte(c.Key()), v)
+}
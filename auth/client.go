@@ -1,6 +1,12 @@
 package auth
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/netip"
+	"regexp"
+	"strings"
 	"time"
 )
 
@@ -25,4 +31,214 @@ type Client struct {
 	MaxConns        int           `yaml:"max-conns"         json:"maxConns"`        // 最大连接数
 	MtWindowSize    int           `yaml:"mt-window-size"    json:"mtWindowSize"`    // 接收窗口大小,服务端分配
 	Throughput      int           `yaml:"throughput"        json:"throughput"`      // 系统最大吞吐,单位tps
+	Disabled        bool          `yaml:"disabled"          json:"disabled"`        // 停用后不允许登录，已登录的会话将被断开
+	AllowedIPs      []string      `yaml:"allowed-ips"       json:"allowedIps"`      // 允许登录的来源IP或网段（CIDR），为空时不限制
+	Quotas          []Quota       `yaml:"quotas"            json:"quotas"`          // 发送量配额，为空时不限制
+	SrcIdPrefixes   []string      `yaml:"src-id-prefixes"   json:"srcIdPrefixes"`   // 允许的发送号码前缀，为空时为 sms-display-no
+	SubNumberLen    Range         `yaml:"sub-number-len"    json:"subNumberLen"`    // 发送号码前缀后的子码长度范围，如 0-6，为空时不限制
+	ServiceIds      []string      `yaml:"service-ids"       json:"serviceIds"`      // 允许的业务代码，为空时不限制
+	FeeTypes        []string      `yaml:"fee-types"         json:"feeTypes"`        // 允许的资费类型，如 01、02，为空时不限制
+	MsgLevels       Range         `yaml:"msg-levels"        json:"msgLevels"`       // 允许的优先级范围，如 0-3，为空时按协议范围
+
+	secretRef string // 解析前的密钥引用（如 env:VAR），写回存储时使用
+}
+
+// 配额统计周期
+const (
+	PeriodDay   = "day"
+	PeriodMonth = "month"
+)
+
+// Quota 发送量配额，按接收号码计数，每个周期内超过 Limit 后拒绝提交
+type Quota struct {
+	Period string `yaml:"period" json:"period"` // 统计周期 day 或 month
+	Limit  int64  `yaml:"limit"  json:"limit"`  // 周期内允许提交的号码数
+	Prefix string `yaml:"prefix" json:"prefix"` // 接收号码前缀（不含国家码86），为空时统计全部号码
+}
+
+// Key 客户端在存储中的键，如 cmpp_123456
+func (c *Client) Key() string {
+	return Key(c.ISP, c.ClientId)
+}
+
+// Key 按运营商标识及客户端ID生成存储键
+func Key(isp, clientId string) string {
+	return strings.ToLower(isp) + "_" + clientId
+}
+
+// Resolve 解析 SharedSecret 中的密钥引用（见 ResolveSecret），保留原引用以便写回存储
+func (c *Client) Resolve() error {
+	if c.secretRef != "" {
+		return nil
+	}
+	s, err := ResolveSecret(c.SharedSecret)
+	if err != nil {
+		return fmt.Errorf("shared-secret: %w", err)
+	}
+	if s != c.SharedSecret {
+		c.secretRef, c.SharedSecret = c.SharedSecret, s
+	}
+	return nil
+}
+
+// SecretRef 解析前的密钥引用，SharedSecret 为明文时返回空字符串
+func (c *Client) SecretRef() string {
+	return c.secretRef
+}
+
+// stored 写入存储的客户端配置，密钥已解析时写回原引用
+func (c *Client) stored() *Client {
+	if c.secretRef == "" {
+		return c
+	}
+	s := *c
+	s.SharedSecret, s.secretRef = c.secretRef, ""
+	return &s
+}
+
+// 各协议允许的版本号，高4位为主版本号，低4位为次版本号
+var versions = map[string][]byte{
+	"cmpp": {0x20, 0x21, 0x30},
+	"smgp": {0x13, 0x20, 0x30},
+	"sgip": {0x12, 0x30},
+}
+
+// 各协议客户端ID的最大长度：CMPP Source_Addr 6 字节，SMGP ClientID 8 字节，SGIP 节点编号为 uint32
+var clientIdLen = map[string]int{"cmpp": 6, "smgp": 8, "sgip": 10}
+
+var digits = regexp.MustCompile(`^[0-9]+$`)
+
+// Validate 校验客户端配置，ISP 统一转换为小写
+func (c *Client) Validate() error {
+	c.ISP = strings.ToLower(strings.TrimSpace(c.ISP))
+	// 空列表与未配置等同，避免重新加载时误判为配置变更
+	if len(c.AllowedIPs) == 0 {
+		c.AllowedIPs = nil
+	}
+	if len(c.Quotas) == 0 {
+		c.Quotas = nil
+	}
+	if len(c.SrcIdPrefixes) == 0 {
+		c.SrcIdPrefixes = nil
+	}
+	if len(c.ServiceIds) == 0 {
+		c.ServiceIds = nil
+	}
+	if len(c.FeeTypes) == 0 {
+		c.FeeTypes = nil
+	}
+	vs, ok := versions[c.ISP]
+	if !ok {
+		return fmt.Errorf("isp: must be one of cmpp, sgip, smgp, got %q", c.ISP)
+	}
+	switch {
+	case c.ClientId == "":
+		return errors.New("client-id: required")
+	case len(c.ClientId) > clientIdLen[c.ISP]:
+		return fmt.Errorf("client-id: at most %d characters for %s", clientIdLen[c.ISP], c.ISP)
+	case c.ISP == "sgip" && !digits.MatchString(c.ClientId):
+		return errors.New("client-id: sgip node id must be digits")
+	case c.ISP == "sgip" && c.LoginName == "":
+		return errors.New("login-name: required for sgip")
+	case c.SharedSecret == "":
+		return errors.New("shared-secret: required")
+	case bytes.IndexByte(vs, c.Version) < 0:
+		return fmt.Errorf("version: %#x is not supported by %s, want one of %#x", c.Version, c.ISP, vs)
+	case c.NeedReport > 1:
+		return errors.New("need-report: must be 0 or 1")
+	case c.SmsDisplayNo == "" || !digits.MatchString(c.SmsDisplayNo) || len(c.SmsDisplayNo) > 21:
+		return errors.New("sms-display-no: must be 1-21 digits")
+	case c.DefaultMsgLevel > 9:
+		return errors.New("default-msg-level: must be 0-9")
+	case c.MaxConns <= 0:
+		return errors.New("max-conns: must be greater than 0")
+	case c.MtWindowSize <= 0:
+		return errors.New("mt-window-size: must be greater than 0")
+	case c.Throughput <= 0:
+		return errors.New("throughput: must be greater than 0")
+	case c.MtValidDuration < 0:
+		return errors.New("mt-valid-duration: must not be negative")
+	}
+	for _, s := range c.AllowedIPs {
+		if _, err := parsePrefix(s); err != nil {
+			return fmt.Errorf("allowed-ips: invalid ip or cidr %q", s)
+		}
+	}
+	for _, p := range c.SrcIdPrefixes {
+		if !digits.MatchString(p) || len(p) > 21 {
+			return fmt.Errorf("src-id-prefixes: %q must be 1-21 digits", p)
+		}
+	}
+	if _, _, err := c.SubNumberLen.Bounds(); err != nil {
+		return fmt.Errorf("sub-number-len: %w", err)
+	}
+	for _, f := range c.FeeTypes {
+		if !digits.MatchString(f) {
+			return fmt.Errorf("fee-types: %q must be digits", f)
+		}
+	}
+	if min, max, err := c.MsgLevels.Bounds(); err != nil {
+		return fmt.Errorf("msg-levels: %w", err)
+	} else if c.MsgLevels != "" && (min < 0 || max > 9) {
+		return errors.New("msg-levels: must be within 0-9")
+	}
+	// 相同周期及号码前缀的配额共用计数，不允许重复配置
+	seen := make(map[Quota]int, len(c.Quotas))
+	for i, q := range c.Quotas {
+		switch {
+		case q.Period != PeriodDay && q.Period != PeriodMonth:
+			return fmt.Errorf("quotas[%d].period: must be day or month, got %q", i, q.Period)
+		case q.Limit <= 0:
+			return fmt.Errorf("quotas[%d].limit: must be greater than 0", i)
+		case q.Prefix != "" && !digits.MatchString(q.Prefix):
+			return fmt.Errorf("quotas[%d].prefix: must be digits", i)
+		}
+		k := Quota{Period: q.Period, Prefix: q.Prefix}
+		if j, ok := seen[k]; ok {
+			return fmt.Errorf("quotas[%d]: duplicate period and prefix with quotas[%d]", i, j)
+		}
+		seen[k] = i
+	}
+	return nil
+}
+
+// AllowIP 来源地址是否在客户端的IP白名单内，未配置白名单时不限制
+func (c *Client) AllowIP(ip netip.Addr) bool {
+	if len(c.AllowedIPs) == 0 {
+		return true
+	}
+	ip = ip.Unmap()
+	for _, s := range c.AllowedIPs {
+		if p, err := parsePrefix(s); err == nil && p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowIP 来源地址是否被运营商 isp 下任一启用的客户端允许，用于在登录前丢弃未知来源的连接
+func AllowIP(isp string, ip netip.Addr) bool {
+	if Cache == nil {
+		return true
+	}
+	isp = strings.ToLower(isp)
+	for _, c := range Cache.List() {
+		if c.ISP == isp && !c.Disabled && c.AllowIP(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parsePrefix 解析网段，单个IP视为掩码为全长的网段
+func parsePrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		p, err := netip.ParsePrefix(s)
+		return p.Masked(), err
+	}
+	a, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	return netip.PrefixFrom(a, a.BitLen()), nil
 }
@@ -1,6 +1,8 @@
 package auth
 
 import (
+	"errors"
+	"sort"
 	"sync"
 	"time"
 
@@ -10,13 +12,27 @@ import (
 	db "github.com/hrygo/gosms/database/mongodb"
 )
 
+var (
+	ErrNotFound = errors.New("auth: client not found")
+	ErrExists   = errors.New("auth: client already exists")
+)
+
 type Store interface {
-	// Load 从存储加载客户端配置信息
-	Load()
+	// Load 从存储加载客户端配置信息，出错时保留已加载的配置
+	Load() error
 	// FindByCid 根据客户端ID获取指定客户端配置信息:
 	// isp 运营商，用协议名称表示 CMPP、SGIP、SMGP
 	FindByCid(isp string, cid string) *Client
 	// 采用定时器，定时刷新配置
+
+	// Create 校验并新增客户端，已存在时返回 ErrExists
+	Create(c *Client) error
+	// Update 校验并修改客户端，不存在时返回 ErrNotFound
+	Update(c *Client) error
+	// Delete 删除客户端，不存在时返回 ErrNotFound
+	Delete(isp string, cid string) error
+	// List 按运营商及客户端ID排序返回全部客户端
+	List() []*Client
 }
 
 // Cache 从存储加载的客户端缓存数据
@@ -26,15 +42,30 @@ type storage struct {
 	sync.Mutex
 	Cache  map[string]*Client
 	Config yaml_config.YmlConfig
+
+	files map[string]string // 目录存储中客户端对应的配置文件名
 }
 
 func New(c yaml_config.YmlConfig) (cache Store) {
+	if err := LoadMasterKey(c); err != nil {
+		log.Errorf("[Auth] Load master key error: %v", err)
+	}
 	st := c.GetString("AuthClient.StoreType")
 	if "" == st || "yaml" == st || "yml" == st {
 		cache = &YamlStore{
 			Config: c,
 			Cache:  make(map[string]*Client),
 		}
+	} else if "file" == st {
+		cache = &FileStore{
+			Config: c,
+			Cache:  make(map[string]*Client),
+		}
+	} else if "bolt" == st {
+		cache = &BoltStore{
+			Config: c,
+			Cache:  make(map[string]*Client),
+		}
 	} else if "mongo" == st {
 		db.InitDB(c, "Mongo")
 		cache = &MongoStore{
@@ -43,12 +74,21 @@ func New(c yaml_config.YmlConfig) (cache Store) {
 		}
 	}
 	// 初次加载存储
-	cache.Load()
-	// 启动定时器，定时加载存储
-	startTicker(c, cache)
+	if err := cache.Load(); err != nil {
+		log.Errorf("[Auth] Load clients error: %v", err)
+	}
+	// 监听存储变更，不支持时启动定时器，定时加载存储
+	if w, ok := cache.(watcher); !ok || w.watch() != nil {
+		startTicker(c, cache)
+	}
 	return
 }
 
+// watcher 支持监听变更的存储，变更后立即重新加载
+type watcher interface {
+	watch() error
+}
+
 func startTicker(c yaml_config.YmlConfig, s Store) {
 	go func() {
 		d := c.GetDuration("AuthClient.ReloadTicker")
@@ -62,7 +102,78 @@ func startTicker(c yaml_config.YmlConfig, s Store) {
 		for {
 			<-ticker.C
 			log.Warn("Auth cache reload!")
-			s.Load()
+			if err := s.Load(); err != nil {
+				log.Errorf("[Auth] Load clients error: %v", err)
+			}
 		}
 	}()
 }
+
+func (s *storage) get(key string) *Client {
+	s.Lock()
+	defer s.Unlock()
+	return s.Cache[key]
+}
+
+func (s *storage) put(c *Client) {
+	s.Lock()
+	old := s.Cache[c.Key()]
+	s.Cache[c.Key()] = c
+	s.Unlock()
+	if old == nil {
+		notify([]*Event{{Type: EventCreate, Client: c}})
+	} else {
+		notify([]*Event{{Type: EventUpdate, Client: c, Old: old}})
+	}
+}
+
+func (s *storage) remove(key string) {
+	s.Lock()
+	old, ok := s.Cache[key]
+	delete(s.Cache, key)
+	s.Unlock()
+	if ok {
+		notify([]*Event{{Type: EventDelete, Client: old, Old: old}})
+	}
+}
+
+// replace 以重新加载的数据替换缓存，存储中已删除的客户端同时从缓存中删除
+func (s *storage) replace(fresh map[string]*Client) {
+	s.Lock()
+	events := diff(s.Cache, fresh)
+	s.Cache = fresh
+	s.Unlock()
+	for _, e := range events {
+		log.Infof("[Auth] Client %s %s.", e.Client.Key(), e.Type)
+	}
+	notify(events)
+}
+
+func (s *storage) list() []*Client {
+	s.Lock()
+	ret := make([]*Client, 0, len(s.Cache))
+	for _, c := range s.Cache {
+		ret = append(ret, c)
+	}
+	s.Unlock()
+	sortClients(ret)
+	return ret
+}
+
+// prepare 写入存储前校验客户端配置并解析密钥引用
+func prepare(c *Client) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	return c.Resolve()
+}
+
+// sortClients 按运营商及客户端ID排序
+func sortClients(cs []*Client) {
+	sort.Slice(cs, func(i, j int) bool {
+		if cs[i].ISP != cs[j].ISP {
+			return cs[i].ISP < cs[j].ISP
+		}
+		return cs[i].ClientId < cs[j].ClientId
+	})
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"reflect"
+	"sync"
+)
+
+// EventType 客户端配置变更类型
+type EventType byte
+
+const (
+	EventCreate EventType = iota + 1
+	EventUpdate
+	EventDelete
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventCreate:
+		return "create"
+	case EventUpdate:
+		return "update"
+	case EventDelete:
+		return "delete"
+	}
+	return "unknown"
+}
+
+// Event 客户端配置变更事件
+type Event struct {
+	Type   EventType
+	Client *Client // 变更后的配置，删除时为删除前的配置
+	Old    *Client // 变更前的配置，新增时为nil
+}
+
+var (
+	listenersLock sync.RWMutex
+	listeners     []func(e *Event)
+)
+
+// OnChange 注册客户端配置变更监听函数，存储加载或写入后，对新增、修改及删除的客户端依次回调
+func OnChange(fn func(e *Event)) {
+	listenersLock.Lock()
+	defer listenersLock.Unlock()
+	listeners = append(listeners, fn)
+}
+
+func notify(events []*Event) {
+	if len(events) == 0 {
+		return
+	}
+	listenersLock.RLock()
+	defer listenersLock.RUnlock()
+	for _, e := range events {
+		for _, fn := range listeners {
+			fn(e)
+		}
+	}
+}
+
+// diff 对比新旧缓存，返回变更事件
+func diff(old, fresh map[string]*Client) (events []*Event) {
+	for k, c := range fresh {
+		o, ok := old[k]
+		if !ok {
+			events = append(events, &Event{Type: EventCreate, Client: c})
+		} else if !reflect.DeepEqual(o, c) {
+			events = append(events, &Event{Type: EventUpdate, Client: c, Old: o})
+		}
+	}
+	for k, o := range old {
+		if _, ok := fresh[k]; !ok {
+			events = append(events, &Event{Type: EventDelete, Client: o, Old: o})
+		}
+	}
+	return
+}
@@ -0,0 +1,168 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hrygo/log"
+)
+
+// FileStore 单文件存储，全部客户端保存在一个 yaml、json 或 toml 文件的 clients 列表中，
+// 每个客户端须配置 isp 字段
+type FileStore storage
+
+// fileDoc 单文件存储的文件内容
+type fileDoc struct {
+	Clients []*Client `yaml:"clients" json:"clients"`
+}
+
+// path 客户端配置文件路径
+func (f *FileStore) path() string {
+	name := f.Config.GetString("AuthClient.FilePath")
+	if "" == name {
+		name = "config/clients.yaml"
+	}
+	return f.Config.BasePath() + name
+}
+
+// Load 加载配置文件，文件读取或解析失败时保留已加载的配置；
+// 单个客户端配置有误时沿用该客户端已加载的配置，错误信息中注明其在列表中的位置
+func (f *FileStore) Load() error {
+	name := f.path()
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return LoadError{{File: name, Err: err}}
+	}
+	doc := &fileDoc{}
+	if err = Decode(FormatOf(name), data, doc); err != nil {
+		return LoadError{{File: name, Err: err}}
+	}
+
+	var errs LoadError
+	fresh := make(map[string]*Client, len(doc.Clients))
+	for i, c := range doc.Clients {
+		switch {
+		case c == nil:
+			err = fmt.Errorf("clients[%d]: empty", i)
+		case c.ISP == "":
+			err = fmt.Errorf("clients[%d].isp: required", i)
+		case fresh[Key(c.ISP, c.ClientId)] != nil:
+			err = fmt.Errorf("clients[%d]: duplicate client %s", i, Key(c.ISP, c.ClientId))
+		default:
+			if err = c.Resolve(); err != nil {
+				err = fmt.Errorf("clients[%d].%w", i, err)
+			}
+		}
+		if err != nil {
+			errs = append(errs, &FileError{File: name, Err: err})
+			if c != nil && c.ISP != "" {
+				if old := (*storage)(f).get(Key(c.ISP, c.ClientId)); old != nil && fresh[old.Key()] == nil {
+					fresh[old.Key()] = old
+				}
+			}
+			continue
+		}
+		if err = c.Validate(); err != nil {
+			log.Warnf("Invalid client %s in %s: clients[%d].%v", c.Key(), name, i, err)
+		}
+		fresh[c.Key()] = c
+	}
+	(*storage)(f).replace(fresh)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (f *FileStore) FindByCid(isp string, cid string) *Client {
+	return (*storage)(f).get(Key(isp, cid))
+}
+
+// watch 监听配置文件，变更后重新加载
+func (f *FileStore) watch() error {
+	base := filepath.Base(f.path())
+	return watchDir(filepath.Dir(f.path()), func(name string) bool { return name == base }, func() {
+		if err := f.Load(); err != nil {
+			log.Errorf("[Auth] Load clients error: %v", err)
+		}
+	})
+}
+
+func (f *FileStore) Create(c *Client) error {
+	if err := prepare(c); err != nil {
+		return err
+	}
+	return f.modify(func(cs map[string]*Client) error {
+		if cs[c.Key()] != nil {
+			return ErrExists
+		}
+		cs[c.Key()] = c
+		return nil
+	})
+}
+
+func (f *FileStore) Update(c *Client) error {
+	if err := prepare(c); err != nil {
+		return err
+	}
+	return f.modify(func(cs map[string]*Client) error {
+		if cs[c.Key()] == nil {
+			return ErrNotFound
+		}
+		cs[c.Key()] = c
+		return nil
+	})
+}
+
+func (f *FileStore) Delete(isp string, cid string) error {
+	key := Key(isp, cid)
+	return f.modify(func(cs map[string]*Client) error {
+		if cs[key] == nil {
+			return ErrNotFound
+		}
+		delete(cs, key)
+		return nil
+	})
+}
+
+func (f *FileStore) List() []*Client {
+	return (*storage)(f).list()
+}
+
+// modify 修改已加载的客户端后重写整个配置文件，写入成功后更新缓存
+func (f *FileStore) modify(fn func(cs map[string]*Client) error) error {
+	name := f.path()
+	format := FormatOf(name)
+	if format == "" {
+		return errors.New("unsupported file type: " + filepath.Ext(name))
+	}
+
+	f.Lock()
+	cs := make(map[string]*Client, len(f.Cache))
+	for k, c := range f.Cache {
+		cs[k] = c
+	}
+	err := fn(cs)
+	if err == nil {
+		doc := &fileDoc{Clients: make([]*Client, 0, len(cs))}
+		for _, c := range cs {
+			doc.Clients = append(doc.Clients, c.stored())
+		}
+		sortClients(doc.Clients)
+		var data []byte
+		if data, err = Encode(format, doc); err == nil {
+			err = writeFile(name, data)
+		}
+	}
+	if err != nil {
+		f.Unlock()
+		return err
+	}
+	events := diff(f.Cache, cs)
+	f.Cache = cs
+	f.Unlock()
+	notify(events)
+	return nil
+}
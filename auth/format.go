@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// 客户端配置文件格式，按扩展名区分
+const (
+	FormatYaml = "yaml"
+	FormatJson = "json"
+	FormatToml = "toml"
+)
+
+// FormatOf 按文件扩展名返回配置文件格式，不支持的扩展名返回空字符串
+func FormatOf(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yaml", ".yml":
+		return FormatYaml
+	case ".json":
+		return FormatJson
+	case ".toml":
+		return FormatToml
+	}
+	return ""
+}
+
+// Decode 按格式解析配置内容，yaml 及 toml 使用 yaml 标签的字段名（如 client-id），json 使用 json 标签的字段名（如 clientId）
+func Decode(format string, data []byte, v any) error {
+	switch format {
+	case FormatYaml:
+		return yaml.Unmarshal(data, v)
+	case FormatJson:
+		return json.Unmarshal(data, v)
+	case FormatToml:
+		m := make(map[string]any)
+		if err := toml.Unmarshal(data, &m); err != nil {
+			return err
+		}
+		d, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+			DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
+			WeaklyTypedInput: true,
+			ErrorUnused:      true,
+			TagName:          "yaml",
+			Result:           v,
+		})
+		if err != nil {
+			return err
+		}
+		return d.Decode(m)
+	}
+	return fmt.Errorf("unsupported format %q", format)
+}
+
+// Encode 按格式生成配置内容，字段名规则同 Decode
+func Encode(format string, v any) ([]byte, error) {
+	switch format {
+	case FormatYaml:
+		return yaml.Marshal(v)
+	case FormatJson:
+		data, err := json.MarshalIndent(v, "", "  ")
+		return append(data, '\n'), err
+	case FormatToml:
+		// 先转换为 yaml 再读取为 map，使字段名与 yaml 一致
+		data, err := yaml.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		m := make(map[string]any)
+		if err = yaml.Unmarshal(data, &m); err != nil {
+			return nil, err
+		}
+		return toml.Marshal(m)
+	}
+	return nil, fmt.Errorf("unsupported format %q", format)
+}
+
+// FileError 配置文件读取或解析错误
+type FileError struct {
+	File string
+	Err  error
+}
+
+func (e *FileError) Error() string {
+	return e.File + ": " + e.Err.Error()
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// LoadError 加载存储时出错的全部文件，其余文件及已加载的配置不受影响
+type LoadError []*FileError
+
+func (e LoadError) Error() string {
+	s := make([]string, len(e))
+	for i, fe := range e {
+		s[i] = fe.Error()
+	}
+	return strings.Join(s, "; ")
+}
@@ -3,19 +3,20 @@ module github.com/hrygo/gosms/auth
 go 1.18
 
 require (
+	github.com/fsnotify/fsnotify v1.5.4
 	github.com/hrygo/log v1.2.4
 	github.com/hrygo/yaml_config v1.2.5
+	github.com/mitchellh/mapstructure v1.5.0
+	github.com/pelletier/go-toml/v2 v2.0.2
+	go.etcd.io/bbolt v1.3.6
 	go.mongodb.org/mongo-driver v1.10.1
 	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
-	github.com/fsnotify/fsnotify v1.5.4 // indirect
 	github.com/hashicorp/hcl v1.0.0 // indirect
 	github.com/magiconair/properties v1.8.6 // indirect
-	github.com/mitchellh/mapstructure v1.5.0 // indirect
 	github.com/pelletier/go-toml v1.9.5 // indirect
-	github.com/pelletier/go-toml/v2 v2.0.2 // indirect
 	github.com/spf13/afero v1.9.2 // indirect
 	github.com/spf13/cast v1.5.0 // indirect
 	github.com/spf13/jwalterweatherman v1.1.0 // indirect
@@ -2,10 +2,12 @@ package auth
 
 import (
 	"context"
+	"fmt"
 	"strings"
 
 	"github.com/hrygo/log"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
 
 	db "github.com/hrygo/gosms/database/mongodb"
 )
@@ -17,7 +19,7 @@ const (
 
 type MongoStore storage
 
-func (m *MongoStore) Load() {
+func (m *MongoStore) Load() error {
 	coll := db.Mongo.Client.Database(DBN).Collection(Collection)
 	rod := db.Mongo.Config.GetDuration(db.Mongo.Prefix + ".ReadTimeout")
 	ctx, cancel := context.WithTimeout(context.Background(), rod)
@@ -25,20 +27,60 @@ func (m *MongoStore) Load() {
 
 	cursor, err := coll.Find(ctx, bson.D{})
 	if err != nil {
-		log.Error(err.Error())
-		return
+		return err
 	}
+	defer func() { _ = cursor.Close(ctx) }()
 
-	m.Lock()
-	defer m.Unlock()
+	fresh := make(map[string]*Client)
 	for cursor.Next(ctx) {
 		c := &Client{}
 		err := cursor.Decode(c)
 		if err != nil {
-			return
+			return fmt.Errorf("decode client: %w", err)
+		}
+		if err = c.Resolve(); err != nil {
+			// 密钥引用无法解析时沿用已加载的配置
+			log.Errorf("[Auth] Client %s_%s: %v", c.ISP, c.ClientId, err)
+			if old := (*storage)(m).get(c.Key()); old != nil {
+				fresh[old.Key()] = old
+			}
+			continue
 		}
-		m.Cache[c.ISP+"_"+c.ClientId] = c
+		if err = c.Validate(); err != nil {
+			log.Warnf("Invalid client %s_%s: %v", c.ISP, c.ClientId, err)
+		}
+		fresh[c.ISP+"_"+c.ClientId] = c
+	}
+	if err = cursor.Err(); err != nil {
+		return err
 	}
+	(*storage)(m).replace(fresh)
+	return nil
+}
+
+// watch 通过 change stream 监听集合变更（需副本集或分片集群），变更后重新加载；
+// change stream 中断时改为定时加载
+func (m *MongoStore) watch() error {
+	coll := db.Mongo.Client.Database(DBN).Collection(Collection)
+	cs, err := coll.Watch(context.Background(), mongo.Pipeline{})
+	if err != nil {
+		log.Warnf("[Auth] Watch %s error: %v, fall back to polling.", Collection, err)
+		return err
+	}
+	go func() {
+		ctx := context.Background()
+		defer func() { _ = cs.Close(ctx) }()
+		for cs.Next(ctx) {
+			// 删除事件不含文档内容，统一重新加载整个集合
+			if err := m.Load(); err != nil {
+				log.Errorf("[Auth] Load clients error: %v", err)
+			}
+		}
+		log.Errorf("[Auth] Watch %s stopped: %v, fall back to polling.", Collection, cs.Err())
+		startTicker(m.Config, m)
+	}()
+	log.Infof("[Auth] Watching %s.%s.", DBN, Collection)
+	return nil
 }
 
 func (m *MongoStore) FindByCid(isp string, cid string) (c *Client) {
@@ -47,3 +89,70 @@ func (m *MongoStore) FindByCid(isp string, cid string) (c *Client) {
 	client := m.Cache[strings.ToLower(isp)+"_"+cid]
 	return client
 }
+
+func (m *MongoStore) Create(c *Client) error {
+	if err := prepare(c); err != nil {
+		return err
+	}
+	ctx, cancel := writeContext()
+	defer cancel()
+	coll := db.Mongo.Client.Database(DBN).Collection(Collection)
+	n, err := coll.CountDocuments(ctx, filter(c.ISP, c.ClientId))
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return ErrExists
+	}
+	if _, err = coll.InsertOne(ctx, c.stored()); err != nil {
+		return err
+	}
+	(*storage)(m).put(c)
+	return nil
+}
+
+func (m *MongoStore) Update(c *Client) error {
+	if err := prepare(c); err != nil {
+		return err
+	}
+	ctx, cancel := writeContext()
+	defer cancel()
+	coll := db.Mongo.Client.Database(DBN).Collection(Collection)
+	ret, err := coll.ReplaceOne(ctx, filter(c.ISP, c.ClientId), c.stored())
+	if err != nil {
+		return err
+	}
+	if ret.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	(*storage)(m).put(c)
+	return nil
+}
+
+func (m *MongoStore) Delete(isp string, cid string) error {
+	ctx, cancel := writeContext()
+	defer cancel()
+	coll := db.Mongo.Client.Database(DBN).Collection(Collection)
+	ret, err := coll.DeleteOne(ctx, filter(isp, cid))
+	if err != nil {
+		return err
+	}
+	(*storage)(m).remove(Key(isp, cid))
+	if ret.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (m *MongoStore) List() []*Client {
+	return (*storage)(m).list()
+}
+
+func filter(isp string, cid string) bson.M {
+	return bson.M{"isp": strings.ToLower(isp), "clientid": cid}
+}
+
+func writeContext() (context.Context, context.CancelFunc) {
+	wtd := db.Mongo.Config.GetDuration(db.Mongo.Prefix + ".WriteTimeout")
+	return context.WithTimeout(context.Background(), wtd)
+}
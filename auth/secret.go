@@ -0,0 +1,156 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/hrygo/yaml_config"
+)
+
+// 密钥引用前缀，shared-secret 以此开头时在加载时解析，否则为明文
+const (
+	SecretEnv  = "env:"  // env:VAR 读取环境变量
+	SecretFile = "file:" // file:/path 读取文件内容（去掉首尾空白）
+	SecretEnc  = "enc:"  // enc:<base64> 使用主密钥以 AES-256-GCM 加密的密文
+)
+
+// MasterKeyEnv 主密钥环境变量，值为 base64 编码的 32 字节密钥，优先于 AuthClient.MasterKeyFile
+const MasterKeyEnv = "GOSMS_MASTER_KEY"
+
+var ErrNoMasterKey = errors.New("master key is not configured")
+
+var (
+	keyMu     sync.RWMutex
+	masterKey []byte
+)
+
+// SetMasterKey 设置解密 enc: 密钥所用的主密钥，须为 32 字节
+func SetMasterKey(key []byte) error {
+	if len(key) != 32 {
+		return fmt.Errorf("master key must be 32 bytes, got %d", len(key))
+	}
+	keyMu.Lock()
+	masterKey = append([]byte(nil), key...)
+	keyMu.Unlock()
+	return nil
+}
+
+// ParseMasterKey 解析 base64 编码的主密钥
+func ParseMasterKey(s string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("master key: %w", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("master key must be 32 bytes, got %d", len(key))
+	}
+	return key, nil
+}
+
+// GenerateMasterKey 生成 base64 编码的随机主密钥
+func GenerateMasterKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// LoadMasterKey 按环境变量 GOSMS_MASTER_KEY 或 AuthClient.MasterKeyFile 文件加载主密钥，均未配置时不加载
+func LoadMasterKey(c yaml_config.YmlConfig) error {
+	s := os.Getenv(MasterKeyEnv)
+	if s == "" {
+		name := c.GetString("AuthClient.MasterKeyFile")
+		if name == "" {
+			return nil
+		}
+		if !filepath.IsAbs(name) {
+			name = c.BasePath() + name
+		}
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return fmt.Errorf("master key: %w", err)
+		}
+		s = string(data)
+	}
+	key, err := ParseMasterKey(s)
+	if err != nil {
+		return err
+	}
+	return SetMasterKey(key)
+}
+
+func gcm() (cipher.AEAD, error) {
+	keyMu.RLock()
+	key := masterKey
+	keyMu.RUnlock()
+	if key == nil {
+		return nil, ErrNoMasterKey
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// EncryptSecret 使用主密钥加密明文，返回 enc: 开头的密钥引用
+func EncryptSecret(plain string) (string, error) {
+	aead, err := gcm()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return SecretEnc + base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, []byte(plain), nil)), nil
+}
+
+// ResolveSecret 解析密钥引用，不是引用时原样返回；错误信息中不含密钥内容
+func ResolveSecret(ref string) (string, error) {
+	var s string
+	switch {
+	case strings.HasPrefix(ref, SecretEnv):
+		name := ref[len(SecretEnv):]
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("env %s is not set", name)
+		}
+		s = v
+	case strings.HasPrefix(ref, SecretFile):
+		data, err := os.ReadFile(ref[len(SecretFile):])
+		if err != nil {
+			return "", err
+		}
+		s = strings.TrimSpace(string(data))
+	case strings.HasPrefix(ref, SecretEnc):
+		aead, err := gcm()
+		if err != nil {
+			return "", err
+		}
+		data, err := base64.StdEncoding.DecodeString(ref[len(SecretEnc):])
+		if err != nil || len(data) < aead.NonceSize() {
+			return "", errors.New("malformed encrypted secret")
+		}
+		plain, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+		if err != nil {
+			return "", errors.New("decrypt secret failed, wrong master key?")
+		}
+		s = string(plain)
+	default:
+		return ref, nil
+	}
+	if s == "" {
+		return "", errors.New("resolved secret is empty")
+	}
+	return s, nil
+}
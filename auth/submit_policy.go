@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Range 数值范围，如 "0-6"，单个数值如 "4" 表示固定值，为空时不限制
+type Range string
+
+// Bounds 范围的上下限（含），为空时为 0 至 math.MaxInt
+func (r Range) Bounds() (min, max int, err error) {
+	s := strings.TrimSpace(string(r))
+	if s == "" {
+		return 0, math.MaxInt, nil
+	}
+	lo, hi, ok := strings.Cut(s, "-")
+	if !ok {
+		hi = lo
+	}
+	if min, err = strconv.Atoi(strings.TrimSpace(lo)); err == nil {
+		max, err = strconv.Atoi(strings.TrimSpace(hi))
+	}
+	if err != nil || min < 0 || min > max {
+		return 0, 0, fmt.Errorf("invalid range %q, want e.g. 0-6", s)
+	}
+	return min, max, nil
+}
+
+// Contains 数值是否在范围内，范围无效时返回 false
+func (r Range) Contains(n int) bool {
+	min, max, err := r.Bounds()
+	return err == nil && n >= min && n <= max
+}
+
+// 提交请求中可能不符合客户端配置的字段
+var (
+	ErrSrcId     = errors.New("src id is not allowed")
+	ErrSubNumber = errors.New("sub number length is not allowed")
+	ErrServiceId = errors.New("service id is not allowed")
+	ErrFeeType   = errors.New("fee type is not allowed")
+	ErrMsgLevel  = errors.New("msg level is not allowed")
+)
+
+// CheckSrcId 检查发送号码：须以允许的前缀（未配置时为 sms-display-no）开头，
+// 前缀后的子码长度在 sub-number-len 范围内；匹配多个前缀时按最长的前缀计算子码长度
+func (c *Client) CheckSrcId(srcId string) error {
+	prefixes := c.SrcIdPrefixes
+	if len(prefixes) == 0 {
+		prefixes = []string{c.SmsDisplayNo}
+	}
+	matched := -1
+	for _, p := range prefixes {
+		if strings.HasPrefix(srcId, p) && len(p) > matched {
+			matched = len(p)
+		}
+	}
+	if matched < 0 {
+		return ErrSrcId
+	}
+	if !c.SubNumberLen.Contains(len(srcId) - matched) {
+		return ErrSubNumber
+	}
+	return nil
+}
+
+// CheckServiceId 检查业务代码，未配置 service-ids 时不限制
+func (c *Client) CheckServiceId(serviceId string) error {
+	if len(c.ServiceIds) == 0 {
+		return nil
+	}
+	for _, s := range c.ServiceIds {
+		if s == serviceId {
+			return nil
+		}
+	}
+	return ErrServiceId
+}
+
+// CheckFeeType 检查资费类型，按数值比较（如 01 与 1 相同），未配置 fee-types 时不限制
+func (c *Client) CheckFeeType(feeType string) error {
+	if len(c.FeeTypes) == 0 {
+		return nil
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(feeType))
+	if err != nil {
+		return ErrFeeType
+	}
+	for _, f := range c.FeeTypes {
+		if v, err := strconv.Atoi(f); err == nil && v == n {
+			return nil
+		}
+	}
+	return ErrFeeType
+}
+
+// CheckMsgLevel 检查优先级，未配置 msg-levels 时使用协议允许的范围 def
+func (c *Client) CheckMsgLevel(level int, def Range) error {
+	r := c.MsgLevels
+	if r == "" {
+		r = def
+	}
+	if !r.Contains(level) {
+		return ErrMsgLevel
+	}
+	return nil
+}
@@ -1,16 +1,23 @@
 package auth
 
 import (
-	"io/ioutil"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/fsnotify/fsnotify"
 	"github.com/hrygo/log"
-	"gopkg.in/yaml.v3"
 )
 
+// YamlStore 目录存储，每个客户端一个文件，文件名为 <isp>_<client-id>.yaml，
+// 也支持 .yml、.json 及 .toml 格式的文件
 type YamlStore storage
 
-func (y *YamlStore) Load() {
+// dir 客户端配置文件所在目录
+func (y *YamlStore) dir() string {
 	dir := y.Config.GetString("AuthClient.YamlFilePath")
 	if "" == dir {
 		dir = "Config/yml_store/"
@@ -18,35 +25,54 @@ func (y *YamlStore) Load() {
 	if !strings.HasSuffix(dir, "/") {
 		dir += "/"
 	}
-	dir = y.Config.BasePath() + dir
-	fs, err := ioutil.ReadDir(dir)
+	return y.Config.BasePath() + dir
+}
+
+// Load 加载目录下的全部客户端配置文件，读取或解析失败的文件沿用已加载的配置，
+// 并在返回的 LoadError 中列出出错的文件及原因
+func (y *YamlStore) Load() error {
+	dir := y.dir()
+	fs, err := os.ReadDir(dir)
 	if err != nil {
-		log.Fatalf("Config file init error: %v", err)
+		return LoadError{{File: dir, Err: err}}
 	}
 
+	var errs LoadError
+	fresh := make(map[string]*Client, len(fs))
+	files := make(map[string]string, len(fs))
 	for _, f := range fs {
-		f := f
-		if f.IsDir() {
+		if f.IsDir() || FormatOf(f.Name()) == "" {
 			continue
 		}
-		// e.g. cmpp_123456
-		var key string
-		if strings.HasSuffix(f.Name(), ".yaml") {
-			key = f.Name()[:len(f.Name())-5]
+		c, err := readClientFile(dir + f.Name())
+		if err == nil {
+			if other, ok := files[c.Key()]; ok {
+				err = fmt.Errorf("duplicate client %s, also defined in %s", c.Key(), other)
+			}
 		}
-		if strings.HasSuffix(f.Name(), ".yml") {
-			key = f.Name()[:len(f.Name())-4]
-		}
-		if "" == key {
+		if err != nil {
+			errs = append(errs, &FileError{File: f.Name(), Err: err})
+			// 文件读取或解析失败时沿用已加载的配置
+			if old := y.loaded(f.Name()); old != nil {
+				fresh[old.Key()] = old
+				files[old.Key()] = f.Name()
+			}
 			continue
 		}
-		data, err := ioutil.ReadFile(dir + f.Name())
-		if err != nil {
-			log.Fatalf("Config file init error: %v", err)
+		if err = c.Validate(); err != nil {
+			log.Warnf("Invalid client %s in %s: %v", c.Key(), f.Name(), err)
 		}
-
-		unmarshal(data, strings.ToLower(key), y)
+		fresh[c.Key()] = c
+		files[c.Key()] = f.Name()
+	}
+	(*storage)(y).replace(fresh)
+	y.Lock()
+	y.files = files
+	y.Unlock()
+	if len(errs) > 0 {
+		return errs
 	}
+	return nil
 }
 
 func (y *YamlStore) FindByCid(isp string, cid string) *Client {
@@ -56,16 +82,190 @@ func (y *YamlStore) FindByCid(isp string, cid string) *Client {
 	return client
 }
 
-func unmarshal(data []byte, id string, y *YamlStore) {
+// loaded 上次从指定文件加载的客户端
+func (y *YamlStore) loaded(name string) *Client {
 	y.Lock()
 	defer y.Unlock()
+	for key, f := range y.files {
+		if f == name {
+			return y.Cache[key]
+		}
+	}
+	// 首次加载或文件为新增
+	return y.Cache[fileKey(name)]
+}
 
+// fileKey 由文件名得到客户端的存储键，如 cmpp_123456.yaml 为 cmpp_123456
+func fileKey(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, filepath.Ext(name)))
+}
+
+// readClientFile 读取单个客户端的配置文件，未配置 isp 及 client-id 时由文件名 <isp>_<client-id> 得到
+func readClientFile(name string) (*Client, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
 	cli := &Client{}
-	err := yaml.Unmarshal(data, cli)
+	if err = Decode(FormatOf(name), data, cli); err != nil {
+		return nil, err
+	}
+	if err = cli.Resolve(); err != nil {
+		return nil, err
+	}
+	isp, cid, ok := strings.Cut(fileKey(filepath.Base(name)), "_")
+	if cli.ISP == "" {
+		if !ok {
+			return nil, errors.New("isp: required when the file name is not <isp>_<client-id>")
+		}
+		cli.ISP = isp
+	}
+	if cli.ClientId == "" && ok {
+		cli.ClientId = cid
+	}
+	cli.ISP = strings.ToLower(cli.ISP)
+	return cli, nil
+}
+
+// watch 监听配置文件目录，文件变更后重新加载
+func (y *YamlStore) watch() error {
+	return watchDir(y.dir(), func(name string) bool { return FormatOf(name) != "" }, func() {
+		if err := y.Load(); err != nil {
+			log.Errorf("[Auth] Load clients error: %v", err)
+		}
+	})
+}
+
+// watchDir 监听目录下 match 的文件，变更后（合并短时间内的多次变更）调用 load
+func watchDir(dir string, match func(name string) bool, load func()) error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err = w.Add(dir); err != nil {
+		_ = w.Close()
+		log.Errorf("[Auth] Watch %s error: %v", dir, err)
+		return err
+	}
+	go func() {
+		defer func() { _ = w.Close() }()
+		var reload <-chan time.Time
+		for {
+			select {
+			case e, ok := <-w.Events:
+				if !ok {
+					return
+				}
+				if !match(filepath.Base(e.Name)) {
+					continue
+				}
+				if e.Op&(fsnotify.Create|fsnotify.Write|fsnotify.Remove|fsnotify.Rename) != 0 {
+					reload = time.After(100 * time.Millisecond)
+				}
+			case err, ok := <-w.Errors:
+				if !ok {
+					return
+				}
+				log.Errorf("[Auth] Watch %s error: %v", dir, err)
+			case <-reload:
+				reload = nil
+				load()
+			}
+		}
+	}()
+	log.Infof("[Auth] Watching %s.", dir)
+	return nil
+}
+
+func (y *YamlStore) Create(c *Client) error {
+	if err := prepare(c); err != nil {
+		return err
+	}
+	if (*storage)(y).get(c.Key()) != nil {
+		return ErrExists
+	}
+	if _, err := os.Stat(y.file(c.Key())); err == nil {
+		return ErrExists
+	}
+	return y.write(c)
+}
+
+func (y *YamlStore) Update(c *Client) error {
+	if err := prepare(c); err != nil {
+		return err
+	}
+	if (*storage)(y).get(c.Key()) == nil {
+		return ErrNotFound
+	}
+	return y.write(c)
+}
+
+func (y *YamlStore) Delete(isp string, cid string) error {
+	key := Key(isp, cid)
+	if (*storage)(y).get(key) == nil {
+		return ErrNotFound
+	}
+	if err := os.Remove(y.file(key)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	(*storage)(y).remove(key)
+	y.Lock()
+	delete(y.files, key)
+	y.Unlock()
+	return nil
+}
+
+func (y *YamlStore) List() []*Client {
+	return (*storage)(y).list()
+}
+
+// file 客户端配置文件路径，沿用加载时的文件（文件名可以不是 <isp>_<client-id>），
+// 新建时已存在 .yml、.json 或 .toml 文件的沿用
+func (y *YamlStore) file(key string) string {
+	y.Lock()
+	f, ok := y.files[key]
+	y.Unlock()
+	if ok {
+		return y.dir() + f
+	}
+	name := y.dir() + key
+	for _, ext := range []string{".yml", ".json", ".toml"} {
+		if _, err := os.Stat(name + ext); err == nil {
+			return name + ext
+		}
+	}
+	return name + ".yaml"
+}
+
+// write 按文件格式写入客户端配置
+func (y *YamlStore) write(c *Client) error {
+	name := y.file(c.Key())
+	data, err := Encode(FormatOf(name), c.stored())
 	if err != nil {
-		log.Errorf("Config file init error: %v", err)
-	} else {
-		cli.ISP = id[0:4]
-		y.Cache[id] = cli
+		return err
+	}
+	if err = writeFile(name, data); err != nil {
+		return err
+	}
+	(*storage)(y).put(c)
+	y.Lock()
+	if y.files == nil {
+		y.files = make(map[string]string)
+	}
+	y.files[c.Key()] = filepath.Base(name)
+	y.Unlock()
+	return nil
+}
+
+// writeFile 先写临时文件再改名，避免重新加载时读到不完整的文件
+func writeFile(name string, data []byte) error {
+	tmp := name + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, name); err != nil {
+		_ = os.Remove(tmp)
+		return err
 	}
+	return nil
 }
@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hrygo/gosms/auth"
+)
+
+func TestBoltStore_CRUD(t *testing.T) {
+	t.Setenv("AUTH_TEST_SECRET", "from env")
+	file := t.TempDir() + "/auth.db"
+	s := &auth.BoltStore{Config: filePath(file), Cache: make(map[string]*auth.Client)}
+	assert.Nil(t, s.Load())
+	assert.Empty(t, s.List())
+
+	c := validClient()
+	c.SharedSecret = "env:AUTH_TEST_SECRET"
+	assert.Nil(t, s.Create(c))
+	assert.ErrorIs(t, s.Create(validClient()), auth.ErrExists)
+	assert.FileExists(t, file)
+	assert.Equal(t, "from env", s.FindByCid("CMPP", "654321").SharedSecret)
+
+	// 另一个实例加载后与写入的配置一致
+	r := &auth.BoltStore{Config: filePath(file), Cache: make(map[string]*auth.Client)}
+	assert.Nil(t, r.Load())
+	assert.Equal(t, s.List(), r.List())
+
+	c = validClient()
+	c.Throughput = 200
+	assert.Nil(t, s.Update(c))
+	assert.Equal(t, 200, s.FindByCid("cmpp", "654321").Throughput)
+	c.ClientId = "111111"
+	assert.ErrorIs(t, s.Update(c), auth.ErrNotFound)
+	assert.ErrorIs(t, s.Delete("cmpp", "111111"), auth.ErrNotFound)
+	assert.Nil(t, s.Delete("cmpp", "654321"))
+	assert.Empty(t, s.List())
+	assert.Nil(t, r.Load())
+	assert.Empty(t, r.List())
+}
+
+func TestBoltStore_ExportImport(t *testing.T) {
+	t.Setenv("AUTH_TEST_SECRET", "from env")
+	dir := t.TempDir()
+	s := &auth.BoltStore{Config: filePath(dir + "/auth.db"), Cache: make(map[string]*auth.Client)}
+	c := validClient()
+	c.SharedSecret = "env:AUTH_TEST_SECRET"
+	assert.Nil(t, s.Create(c))
+	sgip := validClient()
+	sgip.ISP, sgip.ClientId, sgip.LoginName = "sgip", "3037196688", "333"
+	assert.Nil(t, s.Create(sgip))
+
+	var buf bytes.Buffer
+	assert.Nil(t, s.Export(&buf))
+	assert.Contains(t, buf.String(), "env:AUTH_TEST_SECRET")
+	assert.NotContains(t, buf.String(), "from env")
+
+	r := &auth.BoltStore{Config: filePath(dir + "/copy.db"), Cache: make(map[string]*auth.Client)}
+	n, err := r.Import(bytes.NewReader(buf.Bytes()))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, s.List(), r.List())
+
+	// 任一客户端有误时不导入任何客户端
+	bad := "clients:\n  - isp: smgp\n    client-id: \"12345678\"\n" + buf.String()[len("clients:\n"):] +
+		"  - isp: cmpp\n    client-id: \"654322\"\n"
+	_, err = r.Import(bytes.NewReader([]byte(bad)))
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "clients[0].")
+	}
+	assert.Len(t, r.List(), 2)
+	assert.Nil(t, r.FindByCid("smgp", "12345678"))
+	_, err = os.Stat(dir + "/copy.db")
+	assert.Nil(t, err)
+}
@@ -11,6 +11,9 @@ import (
 )
 
 func TestYamlStore_FindByCid(t *testing.T) {
+	if pass && ConfigYml.GetString("AuthClient.StoreType") == "mongo" {
+		t.Skip("MongoDB unavailable")
+	}
 	auth.Cache = auth.New(ConfigYml)
 	c := auth.Cache.FindByCid("SMGP", "12345678")
 	assert.True(t, c != nil)
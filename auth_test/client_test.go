@@ -0,0 +1,179 @@
+package auth
+
+import (
+	"net/netip"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hrygo/gosms/auth"
+)
+
+func validClient() *auth.Client {
+	return &auth.Client{
+		ISP:             "CMPP",
+		ClientId:        "654321",
+		SharedSecret:    "shared secret",
+		Version:         0x30,
+		NeedReport:      1,
+		SmsDisplayNo:    "95566",
+		MtValidDuration: 2 * time.Hour,
+		MaxConns:        2,
+		MtWindowSize:    16,
+		Throughput:      100,
+	}
+}
+
+func TestClient_Validate(t *testing.T) {
+	c := validClient()
+	assert.Nil(t, c.Validate())
+	assert.Equal(t, "cmpp", c.ISP)
+	assert.Equal(t, "cmpp_654321", c.Key())
+
+	cases := map[string]func(c *auth.Client){
+		"isp":            func(c *auth.Client) { c.ISP = "smpp" },
+		"client-id":      func(c *auth.Client) { c.ClientId = "1234567" },
+		"shared-secret":  func(c *auth.Client) { c.SharedSecret = "" },
+		"version":        func(c *auth.Client) { c.Version = 0x13 },
+		"sms-display-no": func(c *auth.Client) { c.SmsDisplayNo = "95566a" },
+		"max-conns":      func(c *auth.Client) { c.MaxConns = 0 },
+		"throughput":     func(c *auth.Client) { c.Throughput = -1 },
+		"quotas[0].period": func(c *auth.Client) {
+			c.Quotas = []auth.Quota{{Period: "week", Limit: 1}}
+		},
+		"quotas[0].limit": func(c *auth.Client) {
+			c.Quotas = []auth.Quota{{Period: auth.PeriodDay}}
+		},
+		"quotas[2]": func(c *auth.Client) {
+			c.Quotas = []auth.Quota{{Period: auth.PeriodDay, Limit: 1}, {Period: auth.PeriodMonth, Limit: 1}, {Period: auth.PeriodDay, Limit: 2}}
+		},
+		"src-id-prefixes": func(c *auth.Client) { c.SrcIdPrefixes = []string{"95566", "9556x"} },
+		"sub-number-len":  func(c *auth.Client) { c.SubNumberLen = "6-0" },
+		"fee-types":       func(c *auth.Client) { c.FeeTypes = []string{"free"} },
+		"msg-levels":      func(c *auth.Client) { c.MsgLevels = "0-10" },
+		"login-name": func(c *auth.Client) {
+			c.ISP, c.ClientId, c.Version = "sgip", "3037196688", 0x12
+		},
+	}
+	for field, fn := range cases {
+		c := validClient()
+		fn(c)
+		err := c.Validate()
+		if assert.Error(t, err, field) {
+			assert.Contains(t, err.Error(), field+":")
+		}
+	}
+}
+
+func TestYamlStore_CRUD(t *testing.T) {
+	s := &auth.YamlStore{Config: ConfigYml, Cache: make(map[string]*auth.Client)}
+	s.Load()
+	n := len(s.List())
+	file := ConfigYml.BasePath() + "yml_store/cmpp_654321.yaml"
+	defer func() { _ = os.Remove(file) }()
+
+	c := validClient()
+	assert.Nil(t, s.Create(c))
+	assert.ErrorIs(t, s.Create(validClient()), auth.ErrExists)
+	assert.FileExists(t, file)
+	assert.Len(t, s.List(), n+1)
+
+	// 重新加载后与写入的配置一致
+	r := &auth.YamlStore{Config: ConfigYml, Cache: make(map[string]*auth.Client)}
+	r.Load()
+	assert.Equal(t, c, r.FindByCid("CMPP", "654321"))
+
+	c = validClient()
+	c.Throughput = 200
+	assert.Nil(t, s.Update(c))
+	assert.Equal(t, 200, s.FindByCid("cmpp", "654321").Throughput)
+	c.MaxConns = 0
+	assert.Error(t, s.Update(c))
+	c = validClient()
+	c.ClientId = "111111"
+	assert.ErrorIs(t, s.Update(c), auth.ErrNotFound)
+
+	assert.Nil(t, s.Delete("cmpp", "654321"))
+	assert.ErrorIs(t, s.Delete("cmpp", "654321"), auth.ErrNotFound)
+	assert.NoFileExists(t, file)
+	assert.Nil(t, s.FindByCid("cmpp", "654321"))
+}
+
+func TestClient_AllowIP(t *testing.T) {
+	c := validClient()
+	assert.True(t, c.AllowIP(netip.MustParseAddr("192.168.1.1")))
+
+	c.AllowedIPs = []string{"127.0.0.1", "10.1.0.0/16", "2001:db8::/32"}
+	assert.Nil(t, c.Validate())
+	assert.True(t, c.AllowIP(netip.MustParseAddr("127.0.0.1")))
+	assert.True(t, c.AllowIP(netip.MustParseAddr("::ffff:127.0.0.1")))
+	assert.True(t, c.AllowIP(netip.MustParseAddr("10.1.200.3")))
+	assert.True(t, c.AllowIP(netip.MustParseAddr("2001:db8::1")))
+	assert.False(t, c.AllowIP(netip.MustParseAddr("10.2.0.1")))
+	assert.False(t, c.AllowIP(netip.Addr{}))
+
+	c.AllowedIPs = []string{"10.1.0.0/33"}
+	err := c.Validate()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "allowed-ips:")
+	}
+}
+
+func TestRange(t *testing.T) {
+	min, max, err := auth.Range("").Bounds()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, min)
+	assert.Greater(t, max, 1<<30)
+
+	r := auth.Range(" 2 - 4 ")
+	assert.False(t, r.Contains(1))
+	assert.True(t, r.Contains(2))
+	assert.True(t, r.Contains(4))
+	assert.False(t, r.Contains(5))
+	assert.True(t, auth.Range("3").Contains(3))
+	assert.False(t, auth.Range("3").Contains(4))
+
+	for _, s := range []string{"a-3", "-1", "4-2", "1-2-3"} {
+		_, _, err = auth.Range(s).Bounds()
+		assert.Error(t, err, s)
+		assert.False(t, auth.Range(s).Contains(0), s)
+	}
+}
+
+func TestClient_CheckSubmit(t *testing.T) {
+	c := validClient()
+	// 未配置时发送号码须以 sms-display-no 开头，其余不限制
+	assert.Nil(t, c.CheckSrcId("95566"))
+	assert.Nil(t, c.CheckSrcId("955661234567"))
+	assert.ErrorIs(t, c.CheckSrcId("10086"), auth.ErrSrcId)
+	assert.Nil(t, c.CheckServiceId("anything"))
+	assert.Nil(t, c.CheckFeeType("03"))
+	assert.Nil(t, c.CheckMsgLevel(3, "0-3"))
+	assert.ErrorIs(t, c.CheckMsgLevel(4, "0-3"), auth.ErrMsgLevel)
+
+	c.SrcIdPrefixes = []string{"1065", "106588"}
+	c.SubNumberLen = "0-4"
+	c.ServiceIds = []string{"MI0010"}
+	c.FeeTypes = []string{"01", "2"}
+	c.MsgLevels = "1-2"
+	assert.Nil(t, c.Validate())
+
+	assert.ErrorIs(t, c.CheckSrcId("95566"), auth.ErrSrcId)
+	assert.Nil(t, c.CheckSrcId("10651234"))
+	assert.ErrorIs(t, c.CheckSrcId("106512345"), auth.ErrSubNumber)
+	// 按最长的前缀计算子码长度
+	assert.Nil(t, c.CheckSrcId("1065881234"))
+	assert.ErrorIs(t, c.CheckSrcId("10658812345"), auth.ErrSubNumber)
+
+	assert.Nil(t, c.CheckServiceId("MI0010"))
+	assert.ErrorIs(t, c.CheckServiceId("MI0011"), auth.ErrServiceId)
+	assert.Nil(t, c.CheckFeeType("1"))
+	assert.Nil(t, c.CheckFeeType("02"))
+	assert.ErrorIs(t, c.CheckFeeType("03"), auth.ErrFeeType)
+	assert.ErrorIs(t, c.CheckFeeType(""), auth.ErrFeeType)
+	// 配置的优先级范围优先于协议范围
+	assert.Nil(t, c.CheckMsgLevel(2, "0-3"))
+	assert.ErrorIs(t, c.CheckMsgLevel(0, "0-3"), auth.ErrMsgLevel)
+}
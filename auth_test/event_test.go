@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hrygo/gosms/auth"
+)
+
+func TestYamlStore_Events(t *testing.T) {
+	var events []*auth.Event
+	auth.OnChange(func(e *auth.Event) {
+		if e.Client.ClientId == "654321" {
+			events = append(events, e)
+		}
+	})
+	s := &auth.YamlStore{Config: ConfigYml, Cache: make(map[string]*auth.Client)}
+	s.Load()
+	assert.Empty(t, events)
+	file := ConfigYml.BasePath() + "yml_store/cmpp_654321.yaml"
+	defer func() { _ = os.Remove(file) }()
+
+	assert.Nil(t, s.Create(validClient()))
+	// 重新加载的配置与缓存一致时不产生事件
+	s.Load()
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, auth.EventCreate, events[0].Type)
+		assert.Nil(t, events[0].Old)
+	}
+
+	c := validClient()
+	c.Disabled = true
+	assert.Nil(t, s.Update(c))
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, auth.EventUpdate, events[1].Type)
+		assert.True(t, events[1].Client.Disabled)
+		assert.False(t, events[1].Old.Disabled)
+	}
+
+	// 配置文件在存储外被删除，重新加载后从缓存删除
+	assert.Nil(t, os.Remove(file))
+	s.Load()
+	assert.Nil(t, s.FindByCid("cmpp", "654321"))
+	if assert.Len(t, events, 3) {
+		assert.Equal(t, auth.EventDelete, events[2].Type)
+		assert.Equal(t, "654321", events[2].Client.ClientId)
+	}
+}
@@ -0,0 +1,161 @@
+package auth
+
+import (
+	"os"
+	"testing"
+
+	"github.com/hrygo/yaml_config"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hrygo/gosms/auth"
+)
+
+func TestFileStore_CRUD(t *testing.T) {
+	for _, name := range []string{"clients.yaml", "clients.json", "clients.toml"} {
+		t.Run(name, func(t *testing.T) {
+			file := ConfigYml.BasePath() + name
+			defer func() { _ = os.Remove(file) }()
+			assert.Nil(t, os.WriteFile(file, []byte(emptyDoc[auth.FormatOf(name)]), 0644))
+
+			s := &auth.FileStore{Config: filePath(name), Cache: make(map[string]*auth.Client)}
+			assert.Nil(t, s.Load())
+			assert.Empty(t, s.List())
+
+			c := validClient()
+			c.Quotas = []auth.Quota{{Period: auth.PeriodDay, Limit: 100}}
+			assert.Nil(t, s.Create(c))
+			assert.ErrorIs(t, s.Create(validClient()), auth.ErrExists)
+			sgip := validClient()
+			sgip.ISP, sgip.ClientId, sgip.LoginName = "sgip", "3037196688", "333"
+			assert.Nil(t, s.Create(sgip))
+
+			// 重新加载后与写入的配置一致
+			r := &auth.FileStore{Config: filePath(name), Cache: make(map[string]*auth.Client)}
+			assert.Nil(t, r.Load())
+			assert.Equal(t, s.List(), r.List())
+
+			c = validClient()
+			c.Throughput = 200
+			assert.Nil(t, s.Update(c))
+			assert.Equal(t, 200, s.FindByCid("cmpp", "654321").Throughput)
+			assert.ErrorIs(t, s.Delete("smgp", "654321"), auth.ErrNotFound)
+			assert.Nil(t, s.Delete("cmpp", "654321"))
+			assert.Nil(t, r.Load())
+			assert.Len(t, r.List(), 1)
+			assert.NotNil(t, r.FindByCid("SGIP", "3037196688"))
+		})
+	}
+}
+
+// pathConfig 指定单文件存储及 bolt 存储的文件路径（AuthClient.FilePath、AuthClient.BoltPath），其余配置同 ConfigYml
+type pathConfig struct {
+	yaml_config.YmlConfig
+	path string
+}
+
+func (c pathConfig) GetString(key string) string {
+	if key == "AuthClient.FilePath" || key == "AuthClient.BoltPath" {
+		return c.path
+	}
+	return c.YmlConfig.GetString(key)
+}
+
+func filePath(name string) yaml_config.YmlConfig {
+	return pathConfig{YmlConfig: ConfigYml, path: name}
+}
+
+var emptyDoc = map[string]string{
+	auth.FormatYaml: "clients: []\n",
+	auth.FormatJson: `{"clients": []}`,
+	auth.FormatToml: "clients = []\n",
+}
+
+func TestFileStore_LoadError(t *testing.T) {
+	name := "clients.yaml"
+	file := ConfigYml.BasePath() + name
+	defer func() { _ = os.Remove(file) }()
+	assert.Nil(t, os.WriteFile(file, []byte(`clients:
+  - isp: cmpp
+    client-id: "654321"
+    shared-secret: "secret"
+  - client-id: "654322"
+`), 0644))
+
+	s := &auth.FileStore{Config: filePath(name), Cache: make(map[string]*auth.Client)}
+	err := s.Load()
+	var le auth.LoadError
+	if assert.ErrorAs(t, err, &le) && assert.Len(t, le, 1) {
+		assert.Equal(t, file, le[0].File)
+		assert.Contains(t, err.Error(), "clients[1].isp: required")
+	}
+	assert.NotNil(t, s.FindByCid("cmpp", "654321"))
+
+	// 文件无法解析时保留已加载的配置
+	assert.Nil(t, os.WriteFile(file, []byte("clients: [\n"), 0644))
+	assert.Error(t, s.Load())
+	assert.NotNil(t, s.FindByCid("cmpp", "654321"))
+}
+
+func TestYamlStore_Formats(t *testing.T) {
+	dir := ConfigYml.BasePath() + "yml_store/"
+	files := map[string]string{
+		"cmpp_654321.json": `{"clientId": "654321", "sharedSecret": "secret", "version": 48, "maxConns": 2}`,
+		"cmpp_654322.toml": "shared-secret = \"secret\"\nversion = 48\nmt-valid-duration = \"2h\"\nmax-conns = 3\n",
+		"smgp_654323.toml": "max-conns = \"many\"\n",
+		"smpp_654324.yaml": "isp: cmpp\nclient-id: \"654324\"\n",
+	}
+	for name, data := range files {
+		assert.Nil(t, os.WriteFile(dir+name, []byte(data), 0644))
+	}
+	defer func() {
+		for name := range files {
+			_ = os.Remove(dir + name)
+		}
+	}()
+
+	s := &auth.YamlStore{Config: ConfigYml, Cache: make(map[string]*auth.Client)}
+	err := s.Load()
+	var le auth.LoadError
+	if assert.ErrorAs(t, err, &le) && assert.Len(t, le, 1) {
+		assert.Equal(t, "smgp_654323.toml", le[0].File)
+		assert.Contains(t, le[0].Error(), "max-conns")
+	}
+	assert.Equal(t, 2, s.FindByCid("cmpp", "654321").MaxConns)
+	c := s.FindByCid("cmpp", "654322")
+	if assert.NotNil(t, c) {
+		assert.Equal(t, 3, c.MaxConns)
+		assert.Equal(t, byte(0x30), c.Version)
+		assert.Equal(t, "2h0m0s", c.MtValidDuration.String())
+	}
+	// 文件中配置的 isp 优先于文件名
+	assert.NotNil(t, s.FindByCid("cmpp", "654324"))
+	assert.Nil(t, s.FindByCid("smgp", "654323"))
+}
+
+func TestYamlStore_SourceFile(t *testing.T) {
+	dir := ConfigYml.BasePath() + "yml_store/"
+	file := dir + "custom.yaml"
+	assert.Nil(t, os.WriteFile(file, []byte("isp: cmpp\nclient-id: \"654325\"\nshared-secret: \"secret\"\n"), 0644))
+	defer func() {
+		_ = os.Remove(file)
+		_ = os.Remove(dir + "cmpp_654325.yaml")
+	}()
+
+	s := &auth.YamlStore{Config: ConfigYml, Cache: make(map[string]*auth.Client)}
+	_ = s.Load()
+	assert.NotNil(t, s.FindByCid("cmpp", "654325"))
+
+	// 修改及删除时使用加载时的文件，而不是 <isp>_<client-id>.yaml
+	c := validClient()
+	c.ClientId = "654325"
+	c.MaxConns = 5
+	assert.Nil(t, s.Update(c))
+	data, err := os.ReadFile(file)
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), "max-conns: 5")
+	assert.NoFileExists(t, dir+"cmpp_654325.yaml")
+
+	assert.Nil(t, s.Delete("cmpp", "654325"))
+	assert.NoFileExists(t, file)
+	assert.Nil(t, s.FindByCid("cmpp", "654325"))
+}
@@ -3,6 +3,7 @@ package auth
 import (
 	"context"
 	"encoding/json"
+	"os"
 	"testing"
 
 	"github.com/hrygo/log"
@@ -16,7 +17,18 @@ import (
 
 var pass bool
 
+// 未设置 MongoDB 账号或连接失败时跳过 MongoDB 相关的测试，不影响其他测试
 func init() {
+	pass = true
+	if os.Getenv("MONGO_USER") == "" || os.Getenv("MONGO_PASSWD") == "" {
+		return
+	}
+	defer func() {
+		if e := recover(); e != nil {
+			log.Warnf("MongoDB unavailable, skip: %v", e)
+			db.Mongo = nil
+		}
+	}()
 	db.InitDB(ConfigYml, "Mongo")
 	pass = db.Mongo == nil
 }
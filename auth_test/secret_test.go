@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hrygo/gosms/auth"
+)
+
+func TestResolveSecret(t *testing.T) {
+	t.Setenv("AUTH_TEST_SECRET", "from env")
+	file := t.TempDir() + "/secret"
+	assert.Nil(t, os.WriteFile(file, []byte("from file\n"), 0600))
+
+	for ref, want := range map[string]string{
+		"plain":                   "plain",
+		"env:AUTH_TEST_SECRET":    "from env",
+		auth.SecretFile + file:    "from file",
+		"env:AUTH_TEST_NOT_EXIST": "",
+	} {
+		s, err := auth.ResolveSecret(ref)
+		if want == "" {
+			assert.Error(t, err, ref)
+			continue
+		}
+		assert.Nil(t, err, ref)
+		assert.Equal(t, want, s, ref)
+	}
+
+	key, err := auth.GenerateMasterKey()
+	assert.Nil(t, err)
+	k, err := auth.ParseMasterKey(key)
+	assert.Nil(t, err)
+	assert.Nil(t, auth.SetMasterKey(k))
+	enc, err := auth.EncryptSecret("shared secret")
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(enc, auth.SecretEnc))
+	s, err := auth.ResolveSecret(enc)
+	assert.Nil(t, err)
+	assert.Equal(t, "shared secret", s)
+
+	// 主密钥不匹配时解密失败，错误信息中不含密文及明文
+	key, _ = auth.GenerateMasterKey()
+	k, _ = auth.ParseMasterKey(key)
+	assert.Nil(t, auth.SetMasterKey(k))
+	_, err = auth.ResolveSecret(enc)
+	if assert.Error(t, err) {
+		assert.NotContains(t, err.Error(), enc[len(auth.SecretEnc):])
+	}
+}
+
+func TestYamlStore_SecretRef(t *testing.T) {
+	t.Setenv("AUTH_TEST_SECRET", "from env")
+	file := ConfigYml.BasePath() + "yml_store/cmpp_654321.yaml"
+	defer func() { _ = os.Remove(file) }()
+
+	s := &auth.YamlStore{Config: ConfigYml, Cache: make(map[string]*auth.Client)}
+	_ = s.Load()
+	c := validClient()
+	c.SharedSecret = "env:AUTH_TEST_SECRET"
+	assert.Nil(t, s.Create(c))
+	assert.Equal(t, "from env", s.FindByCid("cmpp", "654321").SharedSecret)
+
+	// 文件中保留引用，重新加载时解析
+	data, err := os.ReadFile(file)
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), "env:AUTH_TEST_SECRET")
+	assert.NotContains(t, string(data), "from env")
+	r := &auth.YamlStore{Config: ConfigYml, Cache: make(map[string]*auth.Client)}
+	_ = r.Load()
+	assert.Equal(t, "from env", r.FindByCid("cmpp", "654321").SharedSecret)
+	assert.Equal(t, "env:AUTH_TEST_SECRET", r.FindByCid("cmpp", "654321").SecretRef())
+
+	// 引用无法解析时拒绝写入
+	c = validClient()
+	c.SharedSecret = "env:AUTH_TEST_NOT_EXIST"
+	err = s.Update(c)
+	if assert.Error(t, err) {
+		assert.True(t, strings.HasPrefix(err.Error(), "shared-secret:"))
+	}
+}
@@ -82,7 +82,7 @@ func (c *Connect) Log() []log.Field {
 	ls := c.MessageHeader.Log()
 	ls = append(ls,
 		log.String("clientID", c.sourceAddr),
-		log.String("authenticatorSource", hex.EncodeToString(c.authenticatorSource)),
+		log.String("authenticatorSource", "******"),
 		log.String("version", hex.EncodeToString([]byte{byte(c.Version)})),
 		log.String("timestamp", fmt.Sprintf("%010d", c.timestamp)))
 	return ls
@@ -103,8 +103,6 @@ func (c *Connect) Check(ac *codec.AuthConf) ConnStatus {
 		[]byte(ac.SharedSecret),
 		[]byte(utils.TimeStamp2Str(c.timestamp)),
 	}, nil))
-	log.Debugf("[AuthCheck] input  : %x", authSource)
-	log.Debugf("[AuthCheck] compute: %x", authMd5)
 	ok := bytes.Equal(authSource, authMd5[:])
 	if ok {
 		c.SetSecret(ac.SharedSecret)
@@ -191,7 +189,7 @@ func (r *ConnectResp) Log() []log.Field {
 	ls := r.MessageHeader.Log()
 	ls = append(ls,
 		log.String("status", r.status.String()),
-		log.String("authenticatorISMG", hex.EncodeToString(r.authenticatorISMG)),
+		log.String("authenticatorISMG", "******"),
 		log.String("version", hex.EncodeToString([]byte{byte(r.Version)})))
 	return ls
 }
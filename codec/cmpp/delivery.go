@@ -84,10 +84,11 @@ func (d *Delivery) Encode() []byte {
 		// 状态报告
 		copy(frame[index:index+l], d.report.Encode())
 	} else {
-		// 上行短信，不支持长短信，固定选用第一片 （New时需处理）
-		slices := utils.MsgSlices(d.msgFmt, d.msgContent)
-		// 不支持长短信，固定选用第一片
-		content := slices[0]
+		// 上行短信，不支持长短信，New时已截取为单条短信的长度
+		content := []byte(d.msgContent)
+		if d.msgFmt == 8 {
+			content, _ = utils.Utf8ToUcs2(d.msgContent)
+		}
 		copy(frame[index:index+l], content)
 	}
 	index += l
@@ -104,7 +105,7 @@ func (d *Delivery) Decode(seq uint32, frame []byte) error {
 	d.SequenceId = seq
 	d.msgId = binary.BigEndian.Uint64(frame[0:8])
 	d.destId = utils.TrimStr(frame[8:29])
-	d.destId = utils.TrimStr(frame[29:39])
+	d.serviceId = utils.TrimStr(frame[29:39])
 	d.tpPid = frame[39]
 	d.tpUdhi = frame[40]
 	d.msgFmt = frame[41]
@@ -131,7 +132,7 @@ func (d *Delivery) Decode(seq uint32, frame []byte) error {
 		}
 		d.report = rpt
 	} else {
-		d.msgContent = utils.TrimStr(frame[index : index+l])
+		d.msgContent = decodeMsgContent(d.msgFmt, d.tpUdhi, frame[index:index+l])
 	}
 	index += l
 	if V30.MajorMatchV(d.Version) {
@@ -178,6 +179,26 @@ func setMsgContent(dly *Delivery, msg string) {
 	dly.msgContent = msg
 }
 
+// decodeMsgContent 按编码格式将上行短信内容转换为 UTF-8，长短信时去掉协议头
+func decodeMsgContent(msgFmt, tpUdhi uint8, content []byte) string {
+	if tpUdhi == 1 && len(content) > 0 && int(content[0]) < len(content) {
+		content = content[content[0]+1:]
+	}
+	switch msgFmt {
+	case 8:
+		bs, err := utils.Ucs2ToUtf8(content)
+		if err == nil {
+			return string(bs)
+		}
+	case 15:
+		s, err := utils.GB18030ToUtf8(string(content))
+		if err == nil {
+			return s
+		}
+	}
+	return utils.TrimStr(content)
+}
+
 func (d *Delivery) IsReport() bool {
 	return d.registeredDelivery == 1
 }
@@ -37,7 +37,9 @@ type MtOptions struct {
 // WithMtOptions 设置配置项
 func WithMtOptions(opt *MtOptions) OptionFunc {
 	return func(mtOps *MtOptions) {
-		mtOps = opt
+		if opt != nil {
+			*mtOps = *opt
+		}
 	}
 }
 
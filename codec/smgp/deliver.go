@@ -1,6 +1,7 @@
 package smgp
 
 import (
+	"bytes"
 	"encoding/binary"
 	"encoding/hex"
 	"fmt"
@@ -133,17 +134,55 @@ func (d *Delivery) Decode(seq uint32, frame []byte) error {
 		if err != nil {
 			return err
 		}
+		index += RptLen
 	} else {
-		bytes, err := GbDecoder.Bytes(frame[index : index+int(d.msgLength)])
-		if err != nil {
-			return err
-		}
-		d.msgContent = string(bytes)
+		d.msgBytes = frame[index : index+int(d.msgLength)]
+		index += int(d.msgLength)
+	}
+	if index+8 <= len(frame) {
+		d.reserve = utils.TrimStr(frame[index : index+8])
+		index += 8
+	}
+	// 一个tlv至少5字节
+	if index+5 <= len(frame) {
+		d.tlvList, _ = utils.Read(bytes.NewBuffer(frame[index:]))
+	}
+	if !d.IsReport() {
+		d.msgContent = d.decodeMsgContent()
 	}
-	// 后续字节不解析了
 	return nil
 }
 
+// decodeMsgContent 按编码格式将上行短信内容转换为 UTF-8，长短信时去掉协议头
+func (d *Delivery) decodeMsgContent() string {
+	content := d.msgBytes
+	if d.tlvValue(TP_udhi) == "\x01" && len(content) > 0 && int(content[0]) < len(content) {
+		content = content[content[0]+1:]
+	}
+	if d.msgFormat == 8 {
+		bs, err := utils.Ucs2ToUtf8(content)
+		if err == nil {
+			return string(bs)
+		}
+	}
+	bs, err := GbDecoder.Bytes(content)
+	if err != nil {
+		return utils.TrimStr(content)
+	}
+	return string(bs)
+}
+
+func (d *Delivery) tlvValue(typ uint16) string {
+	if d.tlvList == nil {
+		return ""
+	}
+	tlv, err := d.tlvList.Get(typ)
+	if err != nil {
+		return ""
+	}
+	return string(tlv.Value())
+}
+
 func (d *Delivery) ToResponse(code uint32) codec.Pdu {
 	resp := &DeliverRsp{Version: d.Version}
 	resp.RequestId = SMGP_DELIVER_RESP
@@ -282,6 +321,16 @@ func (d *Delivery) TlvList() *utils.TlvList {
 	return d.tlvList
 }
 
+// LinkID 点播业务使用的LinkID（TLV可选参数）
+func (d *Delivery) LinkID() string {
+	return utils.TrimStr([]byte(d.tlvValue(LinkID)))
+}
+
+// ServiceId 业务代码（TLV可选参数 MServiceID）
+func (d *Delivery) ServiceId() string {
+	return utils.TrimStr([]byte(d.tlvValue(MServiceID)))
+}
+
 func (r *DeliverRsp) Status() Status {
 	return r.status
 }
@@ -82,8 +82,8 @@ func (l *Login) Decode(seq uint32, frame []byte) error {
 }
 
 func (l *Login) String() string {
-	return fmt.Sprintf("{Header: %s, clientID: %s, authenticatorClient: %x, logoinMode: %x, timestamp: %010d, version: %s}",
-		&l.MessageHeader, l.clientID, l.authenticatorClient, l.loginMode, l.timestamp, l.Version)
+	return fmt.Sprintf("{Header: %s, clientID: %s, authenticatorClient: ******, logoinMode: %x, timestamp: %010d, version: %s}",
+		&l.MessageHeader, l.clientID, l.loginMode, l.timestamp, l.Version)
 }
 
 func (l *Login) Check(cli *codec.AuthConf) Status {
@@ -103,8 +103,6 @@ func (l *Login) Check(cli *codec.AuthConf) Status {
 		[]byte(cli.SharedSecret),
 		[]byte(utils.TimeStamp2Str(l.timestamp)),
 	}, nil))
-	log.Debugf("[AuthCheck] input  : %x", authSource)
-	log.Debugf("[AuthCheck] compute: %x", authMd5)
 	ok := bytes.Equal(authSource, authMd5[:])
 	// 配置不做校验或校验通过时返回0
 	if ok {
@@ -142,7 +140,7 @@ func (l *Login) Log() []log.Field {
 	ls := l.MessageHeader.Log()
 	return append(ls,
 		log.String("clientID", l.clientID),
-		log.String("authenticatorSource", hex.EncodeToString(l.authenticatorClient)),
+		log.String("authenticatorSource", "******"),
 		log.Int8("loginMode", int8(l.loginMode)),
 		log.String("timestamp", fmt.Sprintf("%010d", l.timestamp)),
 		log.String("version", hex.EncodeToString([]byte{byte(l.Version)})),
@@ -177,15 +175,15 @@ func (r *LoginRsp) Decode(seq uint32, frame []byte) error {
 }
 
 func (r *LoginRsp) String() string {
-	return fmt.Sprintf("{ Header: %s, status: \"%s\", authenticatorISMG: %x, version: %s }",
-		&r.MessageHeader, r.status, r.authenticatorServer, r.Version)
+	return fmt.Sprintf("{ Header: %s, status: \"%s\", authenticatorISMG: ******, version: %s }",
+		&r.MessageHeader, r.status, r.Version)
 }
 
 func (r *LoginRsp) Log() []log.Field {
 	ls := r.MessageHeader.Log()
 	return append(ls,
 		log.String("status", r.status.String()),
-		log.String("authenticatorISMG", hex.EncodeToString(r.authenticatorServer)),
+		log.String("authenticatorISMG", "******"),
 		log.String("version", hex.EncodeToString([]byte{byte(r.Version)})),
 	)
 }
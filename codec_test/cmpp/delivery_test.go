@@ -47,6 +47,14 @@ func testcase(t *testing.T, msg string) {
 	assert.Equal(t, d.MsgLength(), uint8(l))
 	assert.Equal(t, d.DestId(), ac.SmsDisplayNo)
 	assert.Equal(t, d.ServiceId(), ac.ServiceId)
+
+	dec := &cmpp.Delivery{Version: d.Version}
+	err := dec.Decode(d.SequenceId, bts[12:])
+	assert.NoError(t, err)
+	assert.Equal(t, d.DestId(), dec.DestId())
+	assert.Equal(t, d.ServiceId(), dec.ServiceId())
+	assert.Equal(t, d.SrcTerminalId(), dec.SrcTerminalId())
+	assert.Equal(t, d.MsgContent(), dec.MsgContent())
 }
 
 const Poem2 = "Will drink\n" +
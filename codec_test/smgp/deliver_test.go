@@ -15,6 +15,20 @@ func TestDeliver_Decode(t *testing.T) {
 	testDeliver(t, dlv)
 }
 
+func TestDeliver_DecodeContent(t *testing.T) {
+	dlv := smgp.NewDeliver(ac, "17011113333", "01", "退订 TD", uint32(codec.B32Seq.NextVal())).(*smgp.Delivery)
+	dt := dlv.Encode()
+	// 追加 LinkID 可选参数
+	dt = append(dt, 0x00, 0x03, 0x00, 0x04, 'l', 'k', '0', '1')
+	dec := &smgp.Delivery{}
+	err := dec.Decode(dlv.SequenceId, dt[12:])
+	assert.NoError(t, err)
+	assert.Equal(t, "退订 TD", dec.MsgContent())
+	assert.Equal(t, "17011113333", dec.SrcTermID())
+	assert.Equal(t, ac.SmsDisplayNo+"01", dec.DestTermID())
+	assert.Equal(t, "lk01", dec.LinkID())
+}
+
 func TestDeliver_ReportDecode(t *testing.T) {
 	mts := smgp.NewSubmit(ac, []string{"17011113333"}, "hello world，世界", uint32(codec.B32Seq.NextVal()))
 	mt := mts[0]
@@ -0,0 +1,162 @@
+package sms
+
+import (
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hrygo/log"
+)
+
+// Account 发送账号，同一运营商（协议）可配置多个账号，每个账号拥有独立的认证信息、地址、窗口及吞吐量
+type Account struct {
+	Name         string        `mapstructure:"name"`           // 账号名称，全局唯一
+	ISP          string        `mapstructure:"isp"`            // 协议（运营商）cmpp、sgip、smgp
+	ClientId     string        `mapstructure:"client-id"`      // 认证信息中的客户端ID，对应 auth.Client
+	Address      string        `mapstructure:"address"`        // 网关地址
+	MaxConns     int           `mapstructure:"max-conns"`      // 最大连接数
+	MtWindowSize int           `mapstructure:"mt-window-size"` // 发送窗口大小
+	Throughput   int           `mapstructure:"throughput"`     // 最大吞吐, 单位tps
+	TickDuration time.Duration `mapstructure:"tick-duration"`  // 定时器调度间隔
+	Weight       int           `mapstructure:"weight"`         // 负载均衡权重，默认为1
+	Groups       []string      `mapstructure:"groups"`         // 所属分组，路由结果可以是账号名或分组名
+
+	UnsubscribeKeywords []string `mapstructure:"unsubscribe-keywords"` // 退订关键字，为空时使用 Unsubscribe.keywords
+	Signature           string   `mapstructure:"signature"`            // 短信签名，如【某某科技】，发送时添加到未签名的内容之前
+}
+
+var (
+	accountMu    sync.RWMutex
+	accounts     map[string]*Account   // 账号名 => 账号
+	accountGroup map[string][]*Account // 分组名 => 账号列表
+	accountOnce  sync.Once
+)
+
+// loadAccounts 从配置文件加载账号：
+// 兼容旧版配置，顶层的 cmpp、sgip、smgp 配置项各自作为一个同名账号；
+// Accounts 列表中可配置任意数量的账号。
+func loadAccounts() {
+	var list []*Account
+	for _, isp := range ISPS {
+		if ConfigYml.GetString(isp+".client-id") == "" {
+			continue
+		}
+		a := &Account{Name: isp, ISP: isp}
+		if err := ConfigYml.Viper().UnmarshalKey(isp, a); err != nil {
+			log.Errorf("[Account] %s config error: %v", isp, err)
+			continue
+		}
+		a.Name, a.ISP = isp, isp
+		list = append(list, a)
+	}
+	var more []*Account
+	if err := ConfigYml.Viper().UnmarshalKey("Accounts", &more); err != nil {
+		log.Errorf("[Account] Accounts config error: %v", err)
+	}
+	setAccounts(append(list, more...))
+}
+
+// SetAccounts 替换全部账号配置，如从其他配置源加载账号；已创建的 SessionFactory 不受影响。
+// 每个账号属于与其协议同名的分组及 groups 中的分组，名称无效或重复的账号被忽略
+func SetAccounts(list []*Account) {
+	accountOnce.Do(func() {})
+	setAccounts(list)
+}
+
+func setAccounts(list []*Account) {
+	byName := make(map[string]*Account, len(list))
+	groups := make(map[string][]*Account)
+	for _, a := range list {
+		a.Name = strings.ToLower(a.Name)
+		a.ISP = strings.ToLower(a.ISP)
+		if a.Name == "" || !validISP(a.ISP) {
+			log.Errorf("[Account] Invalid account: name=%s, isp=%s", a.Name, a.ISP)
+			continue
+		}
+		if _, ok := byName[a.Name]; ok {
+			log.Errorf("[Account] Duplicate account name: %s", a.Name)
+			continue
+		}
+		if a.Weight <= 0 {
+			a.Weight = 1
+		}
+		byName[a.Name] = a
+		seen := make(map[string]bool, len(a.Groups)+1)
+		for _, g := range append([]string{a.ISP}, a.Groups...) {
+			g = strings.ToLower(g)
+			if seen[g] {
+				continue
+			}
+			seen[g] = true
+			groups[g] = append(groups[g], a)
+		}
+	}
+	accountMu.Lock()
+	accounts, accountGroup = byName, groups
+	accountMu.Unlock()
+}
+
+// FindAccount 根据账号名称获取账号配置
+func FindAccount(name string) *Account {
+	accountOnce.Do(loadAccounts)
+	accountMu.RLock()
+	defer accountMu.RUnlock()
+	return accounts[strings.ToLower(name)]
+}
+
+// Accounts 获取所有账号配置
+func Accounts() []*Account {
+	accountOnce.Do(loadAccounts)
+	accountMu.RLock()
+	defer accountMu.RUnlock()
+	ret := make([]*Account, 0, len(accounts))
+	for _, a := range accounts {
+		ret = append(ret, a)
+	}
+	return ret
+}
+
+// ResolveAccounts 将路由结果（分组名或账号名）解析为候选账号，分组优先于同名的账号
+// （如旧版配置的 cmpp 账号与 cmpp 分组同名，路由到 cmpp 时在该分组的全部账号间负载均衡），
+// 并按权重随机排序，排在前面的账号优先使用，其余账号用于故障转移。
+func ResolveAccounts(target string) []*Account {
+	accountOnce.Do(loadAccounts)
+	target = strings.ToLower(target)
+	accountMu.RLock()
+	group, a := accountGroup[target], accounts[target]
+	accountMu.RUnlock()
+	if len(group) > 0 {
+		return weightedOrder(group)
+	}
+	if a != nil {
+		return []*Account{a}
+	}
+	return nil
+}
+
+// weightedOrder 按权重进行不放回的随机抽样，得到候选账号的使用顺序
+func weightedOrder(list []*Account) []*Account {
+	if len(list) < 2 {
+		return list
+	}
+	rest := make([]*Account, len(list))
+	copy(rest, list)
+	ret := make([]*Account, 0, len(list))
+	for len(rest) > 0 {
+		total := 0
+		for _, a := range rest {
+			total += a.Weight
+		}
+		n := rand.Intn(total)
+		for i, a := range rest {
+			n -= a.Weight
+			if n < 0 {
+				ret = append(ret, a)
+				rest = append(rest[:i], rest[i+1:]...)
+				break
+			}
+		}
+	}
+	return ret
+}
@@ -2,12 +2,10 @@ package sms
 
 import (
 	"context"
+	"strconv"
 	"time"
 
-	"github.com/hrygo/log"
-
-	"github.com/hrygo/gosms/database/mongodb"
-
+	"github.com/hrygo/gosms/smc_client/quiet"
 	"github.com/hrygo/gosms/smc_client/session"
 
 	"github.com/hrygo/gosms/codec"
@@ -19,38 +17,161 @@ func Send(message, phone string, options ...codec.OptionFunc) (queryId int64) {
 
 // SendN sms to phones return query id
 func SendN(message string, phones []string, options ...codec.OptionFunc) (queryId int64) {
-	if len(phones) < 1 {
+	return SendMessage(&Message{Content: message, Phones: phones, Options: options})
+}
+
+// SendMessage 按路由规则为每个手机号码选择账号发送短信，返回查询编号。
+// 启用发件箱时，消息先写入发件箱再发送，相同 ClientMsgId 与手机号码的消息只发送一次。
+func SendMessage(m *Message) (queryId int64) {
+	queryId, _ = sendMessage(context.Background(), m, false)
+	return
+}
+
+// SendMessageWait 与 SendMessage 相同，但号码暂无可用会话（发送窗口已满、被限速或连接中断）时阻塞等待，
+// 直到提交网关或 ctx 结束，用于批量发送时的背压控制。ctx 结束时返回 ctx.Err()，已提交的结果仍可按查询编号查询。
+// 号码没有路由时不等待，发送结果的 RejectReason 为 NO_ROUTE。
+func SendMessageWait(ctx context.Context, m *Message) (queryId int64, err error) {
+	return sendMessage(ctx, m, true)
+}
+
+func sendMessage(ctx context.Context, m *Message, wait bool) (queryId int64, err error) {
+	if m == nil || len(m.Phones) < 1 {
 		return
 	}
 	queryId = codec.B64Seq.NextVal()
-	var probLen = len(phones) * (len(message)/70 + 1)
+	_, err = sendQuery(ctx, queryId, m, wait)
+	return
+}
+
+// sendQuery 使用指定的查询编号发送消息，用于定时发送等预先分配了查询编号的场景。
+// accepted 为已提交网关、暂存或写入发件箱等待重试的号码数，出错时之前号码的发送不受影响
+func sendQuery(ctx context.Context, queryId int64, m *Message, wait bool) (accepted int, err error) {
+	msg := *m
+	if msg.ClientMsgId == "" {
+		msg.ClientMsgId = strconv.FormatInt(queryId, 10)
+	}
+	var probLen = len(msg.Phones) * (len(msg.Content)/70 + 1)
 	var results = make([]any, 0, probLen)
-	for _, phone := range phones {
-		phone := phone
-		sc := SelectSession(phone)
-		if sc == nil {
-			// 无可用链接或被限速，当前消息丢弃
+	for _, phone := range msg.Phones {
+		if reason := rejectCheck(phone, &msg); reason != "" {
+			results = append(results, rejected(phone, &msg, reason))
+			continue
+		}
+		m := &msg
+		p, open := quietCheck(phone, m)
+		if p != nil && (p.Action == quiet.ActionReject || open.IsZero()) {
+			results = append(results, rejected(phone, m, RejectQuietHours))
+			continue
+		}
+		if p != nil && p.Action == quiet.ActionAtTime {
+			m = withAtTime(m, open)
+		}
+		if !enqueue(queryId, phone, m) {
 			continue
 		}
-		results = append(results, sc.Send(phone, message, options...)...)
-		sc.AddCounter()
+		if p != nil && p.Action == quiet.ActionHold {
+			// 暂存的消息在发送时再检查频率限制
+			if hold(queryId, phone, m, open) != nil {
+				reject(phone, m, RejectQuietHours)
+				results = append(results, rejected(phone, m, RejectQuietHours))
+			} else {
+				accepted++
+			}
+			continue
+		}
+		if reason := limitCheck(phone, m); reason != "" {
+			reject(phone, m, reason)
+			results = append(results, rejected(phone, m, reason))
+			continue
+		}
+		if !wait {
+			rs := sendTo(phone, m)
+			if len(rs) == 0 && box == nil {
+				// 未启用发件箱时不会重试
+				rs = []any{rejected(phone, m, RejectDropped)}
+			} else {
+				accepted++
+			}
+			results = append(results, rs...)
+			continue
+		}
+		if !claim(phone, m) {
+			// 发件箱正在重试该消息
+			continue
+		}
+		var rs []any
+		rs, err = sendWait(ctx, phone, m)
+		unclaim(phone, m)
+		results = append(results, rs...)
+		if err != nil {
+			if box != nil {
+				// 已写入发件箱，稍后重试
+				accepted++
+			}
+			break
+		}
+		if rs[0].(*session.Result).RejectReason == "" {
+			accepted++
+		}
 	}
 	saveQueryCache(queryId, results)
 	return
 }
 
+// sendWait 重试发送直到提交网关或 ctx 结束，号码无路由时拒绝发送
+func sendWait(ctx context.Context, phone string, m *Message) ([]any, error) {
+	delay := 5 * time.Millisecond
+	for {
+		if rs := sendTo(phone, m); rs != nil {
+			return rs, nil
+		}
+		if !Routable(phone, m) {
+			reject(phone, m, RejectNoRoute)
+			return []any{rejected(phone, m, RejectNoRoute)}, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+		if delay < 100*time.Millisecond {
+			delay *= 2
+		}
+	}
+}
+
+// Routable 号码是否有可用的发送账号（或分组），不检查账号的连接状态
+func Routable(phone string, m *Message) bool {
+	return len(ResolveAccounts(CurrentRouter().Route(phone, m))) > 0
+}
+
+// sendTo 为单个手机号码选择会话并发送短信，无可用会话时返回空
+func sendTo(phone string, m *Message) []any {
+	sc := selectSession(phone, m)
+	if sc == nil {
+		// 无可用链接或被限速，未启用发件箱时当前消息丢弃，否则等待重试
+		return nil
+	}
+	results := sc.SendWithId(m.ClientMsgId, phone, signContent(sc.Account(), m.Content), m.Options...)
+	sc.AddCounter()
+	acquireWindow(sc.Account(), len(results))
+	submitted(phone, m, len(results))
+	return results
+}
+
+// Query 根据查询编号获取发送结果，内存缓存过期后从发送记录存储中查找
 func Query(queryId int64) []any {
 	value, ok := resultQueryCacheMap.Load(queryId)
 	if ok {
 		result, ok := value.([]any)
-		if ok {
+		if ok && len(result) > 0 {
 			_, ok := result[0].(*session.Result)
 			if ok {
 				return result
 			}
 		}
 	}
-	return nil
+	return queryJournal(queryId)
 }
 
 func saveQueryCache(key int64, value []any) {
@@ -58,22 +179,9 @@ func saveQueryCache(key int64, value []any) {
 		r := a.(*session.Result)
 		r.QueryId = key
 	}
+	if old, ok := resultQueryCacheMap.Load(key); ok {
+		// 发件箱重试时追加到原查询编号下
+		value = append(old.([]any), value...)
+	}
 	resultQueryCacheMap.Store(key, value)
 }
-
-func PersistenceSmsJournal() {
-	mongodb.InitDB(ConfigYml, "Mongo")
-	coll := mongodb.Collection("smsdb", "journal")
-	StartCacheExpireTicker(func(results []any) {
-		_ = AsyncPool().Submit(func() {
-			log.Infof("[Persistence] Save %d send results to db.", len(results))
-			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-			defer cancel()
-			many, err := coll.InsertMany(ctx, results)
-			if err != nil {
-				return
-			}
-			log.Infof("[Persistence] Save to mongodb success: %v", many)
-		})
-	})
-}
@@ -0,0 +1,327 @@
+package bench
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/hrygo/gosms/codec"
+	"github.com/hrygo/gosms/smc_client"
+	"github.com/hrygo/gosms/smc_client/session"
+)
+
+// Config 压测配置
+type Config struct {
+	Accounts    []string      // 压测账号，按顺序轮流使用
+	TPS         int           // 目标提交速率（条/秒），0 表示不限速，仅受并发数限制
+	Concurrency int           // 并发提交的协程数，默认 16
+	Duration    time.Duration // 提交持续时间，0 表示直到提交 Count 条
+	Count       int           // 提交的消息总数，0 表示不限，仅受 Duration 限制
+	Drain       time.Duration // 提交结束后等待网关响应及状态报告的最长时间，默认 10s
+	Content     string        // 短信内容
+
+	Send  func(context.Context, *sms.Message) (int64, error) // 默认 sms.SendMessageWait
+	Query func(int64) []any                                  // 按查询编号获取发送结果，默认 sms.Query
+	Stats func() []sms.FactoryStat                           // 默认 sms.FactoryStats
+}
+
+// Runner 压测执行器，通过 OnResult 接收本次压测消息的网关响应及状态报告
+type Runner struct {
+	conf   Config
+	prefix string // 本次压测消息的 ClientMsgId 前缀
+
+	seq        uint64
+	submitted  uint64
+	segments   uint64
+	rejected   uint64
+	errors     uint64
+	responses  uint64
+	reports    uint64
+	submitWait Latency
+	response   Latency
+	report     Latency
+
+	mu          sync.Mutex
+	codes       map[string]uint64
+	reportStats map[string]uint64
+}
+
+func New(conf Config) *Runner {
+	if conf.Concurrency <= 0 {
+		conf.Concurrency = 16
+	}
+	if conf.Drain <= 0 {
+		conf.Drain = 10 * time.Second
+	}
+	if conf.Send == nil {
+		conf.Send = sms.SendMessageWait
+	}
+	if conf.Query == nil {
+		conf.Query = sms.Query
+	}
+	if conf.Stats == nil {
+		conf.Stats = sms.FactoryStats
+	}
+	return &Runner{
+		conf:        conf,
+		prefix:      "bench" + strconv.FormatInt(time.Now().UnixNano(), 36) + "-",
+		codes:       make(map[string]uint64),
+		reportStats: make(map[string]uint64),
+	}
+}
+
+// OnResult 统计本次压测消息的网关响应及状态报告，需注册为 session.ResultListener
+func (r *Runner) OnResult(event session.Event, res *session.Result) {
+	if !strings.HasPrefix(res.ClientMsgId, r.prefix) {
+		return
+	}
+	switch event {
+	case session.EventResponse:
+		atomic.AddUint64(&r.responses, 1)
+		r.response.Add(res.ResponseTime.Sub(res.SendTime))
+		r.mu.Lock()
+		r.codes[res.Account+":"+strconv.FormatUint(uint64(res.Result), 10)]++
+		r.mu.Unlock()
+	case session.EventReport:
+		atomic.AddUint64(&r.reports, 1)
+		r.report.Add(res.ReportTime.Sub(res.SendTime))
+		r.mu.Lock()
+		r.reportStats[res.Report]++
+		r.mu.Unlock()
+	}
+}
+
+// Run 按配置的速率及并发数提交消息，持续 Duration 或提交 Count 条后等待响应及状态报告，返回压测报告
+func (r *Runner) Run(ctx context.Context) *Report {
+	before := statIndex(r.conf.Stats())
+	var limiter *rate.Limiter
+	if r.conf.TPS > 0 {
+		limiter = rate.NewLimiter(rate.Limit(r.conf.TPS), r.conf.Concurrency)
+	}
+
+	start := time.Now()
+	var runCtx context.Context
+	var cancel context.CancelFunc
+	if r.conf.Duration > 0 {
+		runCtx, cancel = context.WithTimeout(ctx, r.conf.Duration)
+	} else {
+		runCtx, cancel = context.WithCancel(ctx)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < r.conf.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.worker(runCtx, limiter)
+		}()
+	}
+	wg.Wait()
+	cancel()
+	elapsed := time.Since(start)
+
+	// 等待响应及状态报告
+	deadline := time.Now().Add(r.conf.Drain)
+	for time.Now().Before(deadline) && ctx.Err() == nil {
+		n := atomic.LoadUint64(&r.segments)
+		if atomic.LoadUint64(&r.responses) >= n && atomic.LoadUint64(&r.reports) >= n {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return r.newReport(elapsed, before)
+}
+
+func (r *Runner) worker(ctx context.Context, limiter *rate.Limiter) {
+	for {
+		if limiter != nil {
+			if limiter.Wait(ctx) != nil {
+				return
+			}
+		}
+		n := atomic.AddUint64(&r.seq, 1)
+		if r.conf.Count > 0 && n > uint64(r.conf.Count) {
+			return
+		}
+		account := r.conf.Accounts[int(n%uint64(len(r.conf.Accounts)))]
+		m := &sms.Message{
+			ClientMsgId: r.prefix + strconv.FormatUint(n, 10),
+			Content:     r.conf.Content,
+			Phones:      []string{phone(account)},
+			Account:     account,
+			Options:     []codec.OptionFunc{codec.MtNeedReport(1)},
+		}
+		t := time.Now()
+		queryId, err := r.conf.Send(ctx, m)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			atomic.AddUint64(&r.errors, 1)
+			continue
+		}
+		// 被拒绝（如号码没有路由）的消息未提交网关，不计入提交数
+		segments := submittedSegments(r.conf.Query(queryId))
+		if segments == 0 {
+			atomic.AddUint64(&r.rejected, 1)
+			continue
+		}
+		r.submitWait.Add(time.Since(t))
+		atomic.AddUint64(&r.submitted, 1)
+		atomic.AddUint64(&r.segments, segments)
+	}
+}
+
+// submittedSegments 发送结果中已提交网关的分段数
+func submittedSegments(results []any) uint64 {
+	var n uint64
+	for _, a := range results {
+		if res, ok := a.(*session.Result); ok && res.RejectReason == "" {
+			n++
+		}
+	}
+	return n
+}
+
+// phone 按账号的协议生成随机手机号码
+func phone(account string) string {
+	prefix := "138"
+	if a := sms.FindAccount(account); a != nil {
+		switch a.ISP {
+		case session.SGIP:
+			prefix = "130"
+		case session.SMGP:
+			prefix = "133"
+		}
+	}
+	return fmt.Sprintf("%s%08d", prefix, rand.Intn(1e8))
+}
+
+// Report 压测报告，延迟单位为毫秒
+type Report struct {
+	Accounts      []string          `json:"accounts"`
+	TargetTPS     int               `json:"targetTps"`
+	Concurrency   int               `json:"concurrency"`
+	Elapsed       float64           `json:"elapsed"`   // 提交阶段的实际耗时，单位秒
+	Submitted     uint64            `json:"submitted"` // 提交网关的消息数
+	Segments      uint64            `json:"segments"`  // 提交网关的分段数（长短信拆分后），与响应及状态报告数对应
+	Rejected      uint64            `json:"rejected"`  // 被拒绝发送（如号码没有路由）的消息数
+	Errors        uint64            `json:"errors"`
+	Responses     uint64            `json:"responses"`
+	Reports       uint64            `json:"reports"`
+	SubmitTPS     float64           `json:"submitTps"`
+	SubmitWait    Summary           `json:"submitWait"` // 提交阻塞时间，含等待发送窗口及吞吐量限制
+	Response      Summary           `json:"response"`   // 提交至收到网关响应
+	Report        Summary           `json:"report"`     // 提交至收到状态报告
+	ResponseCodes map[string]uint64 `json:"responseCodes"`
+	ReportStats   map[string]uint64 `json:"reportStats"`
+	Sessions      []sms.FactoryStat `json:"sessions"` // 各账号压测期间的计数增量
+}
+
+func (r *Runner) newReport(elapsed time.Duration, before map[string]sms.FactoryStat) *Report {
+	ret := &Report{
+		Accounts:    r.conf.Accounts,
+		TargetTPS:   r.conf.TPS,
+		Concurrency: r.conf.Concurrency,
+		Elapsed:     elapsed.Seconds(),
+		Submitted:   atomic.LoadUint64(&r.submitted),
+		Segments:    atomic.LoadUint64(&r.segments),
+		Rejected:    atomic.LoadUint64(&r.rejected),
+		Errors:      atomic.LoadUint64(&r.errors),
+		Responses:   atomic.LoadUint64(&r.responses),
+		Reports:     atomic.LoadUint64(&r.reports),
+		SubmitWait:  r.submitWait.Summary(),
+		Response:    r.response.Summary(),
+		Report:      r.report.Summary(),
+	}
+	if elapsed > 0 {
+		ret.SubmitTPS = float64(ret.Submitted) / elapsed.Seconds()
+	}
+	r.mu.Lock()
+	ret.ResponseCodes = copyMap(r.codes)
+	ret.ReportStats = copyMap(r.reportStats)
+	r.mu.Unlock()
+	for _, s := range r.conf.Stats() {
+		b := before[s.Account]
+		s.Connects -= b.Connects
+		s.ConnectErrors -= b.ConnectErrors
+		s.Disconnects -= b.Disconnects
+		s.WindowFull -= b.WindowFull
+		s.Throttled -= b.Throttled
+		ret.Sessions = append(ret.Sessions, s)
+	}
+	return ret
+}
+
+func statIndex(stats []sms.FactoryStat) map[string]sms.FactoryStat {
+	ret := make(map[string]sms.FactoryStat, len(stats))
+	for _, s := range stats {
+		ret[s.Account] = s
+	}
+	return ret
+}
+
+func copyMap(m map[string]uint64) map[string]uint64 {
+	ret := make(map[string]uint64, len(m))
+	for k, v := range m {
+		ret[k] = v
+	}
+	return ret
+}
+
+// Print 输出文本格式的压测报告
+func (rp *Report) Print(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "accounts:\t%s\n", strings.Join(rp.Accounts, ","))
+	_, _ = fmt.Fprintf(tw, "target tps / concurrency:\t%d / %d\n", rp.TargetTPS, rp.Concurrency)
+	_, _ = fmt.Fprintf(tw, "elapsed:\t%.2fs\n", rp.Elapsed)
+	_, _ = fmt.Fprintf(tw, "submitted:\t%d (%.1f/s), %d segments, rejected %d, errors %d\n", rp.Submitted, rp.SubmitTPS, rp.Segments, rp.Rejected, rp.Errors)
+	_, _ = fmt.Fprintf(tw, "responses / reports:\t%d / %d\n", rp.Responses, rp.Reports)
+	_, _ = fmt.Fprintln(tw)
+	_, _ = fmt.Fprintln(tw, "latency(ms)\tcount\tmin\tmean\tp50\tp90\tp99\tp99.9\tmax")
+	for _, l := range []struct {
+		name string
+		s    Summary
+	}{{"submit wait", rp.SubmitWait}, {"response", rp.Response}, {"report", rp.Report}} {
+		_, _ = fmt.Fprintf(tw, "%s\t%d\t%.3f\t%.3f\t%.3f\t%.3f\t%.3f\t%.3f\t%.3f\n",
+			l.name, l.s.Count, l.s.Min, l.s.Mean, l.s.P50, l.s.P90, l.s.P99, l.s.P999, l.s.Max)
+	}
+	_, _ = fmt.Fprintln(tw)
+	_, _ = fmt.Fprintf(tw, "response codes:\t%s\n", formatCounts(rp.ResponseCodes))
+	_, _ = fmt.Fprintf(tw, "report stats:\t%s\n", formatCounts(rp.ReportStats))
+	_, _ = fmt.Fprintln(tw)
+	_, _ = fmt.Fprintln(tw, "account\tsessions\tinflight\tconnects\tconnect errors\tdisconnects\twindow full\tthrottled")
+	for _, s := range rp.Sessions {
+		_, _ = fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%d\t%d\t%d\n",
+			s.Account, s.Sessions, s.Inflight, s.Connects, s.ConnectErrors, s.Disconnects, s.WindowFull, s.Throttled)
+	}
+	_ = tw.Flush()
+}
+
+func formatCounts(m map[string]uint64) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var sb strings.Builder
+	for i, k := range keys {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		_, _ = fmt.Fprintf(&sb, "%s=%d", k, m[k])
+	}
+	if sb.Len() == 0 {
+		return "-"
+	}
+	return sb.String()
+}
@@ -0,0 +1,72 @@
+package bench
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Latency 延迟样本，并发安全
+type Latency struct {
+	mu      sync.Mutex
+	samples []time.Duration
+}
+
+func (l *Latency) Add(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	l.mu.Lock()
+	l.samples = append(l.samples, d)
+	l.mu.Unlock()
+}
+
+// Summary 延迟统计，单位毫秒
+type Summary struct {
+	Count int     `json:"count"`
+	Min   float64 `json:"min"`
+	Mean  float64 `json:"mean"`
+	P50   float64 `json:"p50"`
+	P90   float64 `json:"p90"`
+	P99   float64 `json:"p99"`
+	P999  float64 `json:"p999"`
+	Max   float64 `json:"max"`
+}
+
+// Summary 计算延迟分位数（最近秩法）
+func (l *Latency) Summary() Summary {
+	l.mu.Lock()
+	s := make([]time.Duration, len(l.samples))
+	copy(s, l.samples)
+	l.mu.Unlock()
+	if len(s) == 0 {
+		return Summary{}
+	}
+	sort.Slice(s, func(i, j int) bool { return s[i] < s[j] })
+	var total time.Duration
+	for _, d := range s {
+		total += d
+	}
+	pct := func(p float64) float64 {
+		i := int(math.Ceil(p*float64(len(s)))) - 1
+		if i < 0 {
+			i = 0
+		}
+		return ms(s[i])
+	}
+	return Summary{
+		Count: len(s),
+		Min:   ms(s[0]),
+		Mean:  ms(total / time.Duration(len(s))),
+		P50:   pct(0.50),
+		P90:   pct(0.90),
+		P99:   pct(0.99),
+		P999:  pct(0.999),
+		Max:   ms(s[len(s)-1]),
+	}
+}
+
+func ms(d time.Duration) float64 {
+	return math.Round(float64(d)/float64(time.Microsecond)) / 1000
+}
@@ -0,0 +1,260 @@
+package campaign
+
+import (
+	"context"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hrygo/log"
+	"golang.org/x/time/rate"
+
+	"github.com/hrygo/gosms/codec"
+	"github.com/hrygo/gosms/smc_client"
+	"github.com/hrygo/gosms/smc_client/gateway"
+	"github.com/hrygo/gosms/smc_client/session"
+)
+
+// 未提交网关的号码状态，已提交的号码状态见 gateway.StatusDelivered 等
+const (
+	StatusInvalid   = "invalid"   // 号码格式错误
+	StatusDuplicate = "duplicate" // 号码与之前的行重复
+	StatusNoRoute   = "no_route"  // 没有可用的发送账号
+	StatusBadVars   = "bad_vars"  // 变量不满足模板要求
+)
+
+// Result 单个号码的处理结果
+type Result struct {
+	Line        int       `json:"line"`
+	Phone       string    `json:"phone"`
+	Status      string    `json:"status"`
+	Reason      string    `json:"reason,omitempty"`
+	QueryId     int64     `json:"queryId,omitempty"`
+	ClientMsgId string    `json:"clientMsgId,omitempty"`
+	Time        time.Time `json:"time"`
+
+	Segments []gateway.Segment `json:"segments,omitempty"` // 各分段的网关响应及状态报告
+}
+
+// final 状态不会再变化
+func (r *Result) final() bool {
+	switch r.Status {
+	case gateway.StatusQueued, gateway.StatusSubmitted, gateway.StatusResponded:
+		return false
+	}
+	return true
+}
+
+// Config 群发任务配置
+type Config struct {
+	Id      string                                             // 任务标识，作为 ClientMsgId 的前缀
+	Content func(r *Recipient) (string, error)                 // 生成接收人的短信内容
+	Account string                                             // 指定发送账号或分组
+	Tag     string                                             // 业务标签
+	Options []codec.OptionFunc                                 // 协议相关的可选项
+	TPS     int                                                // 最大发送速率，0 时仅受账号吞吐量限制
+	Wait    time.Duration                                      // 全部提交后等待状态报告的最长时间，默认1分钟
+	Send    func(context.Context, *sms.Message) (int64, error) // 默认 sms.SendMessageWait
+	Query   func(queryId int64) []any                          // 默认 sms.Query
+	Check   func(phone string, m *sms.Message) string          // 发送前检查，返回号码状态，默认检查退订名单及路由
+}
+
+// Campaign 群发任务，按接收人列表逐个提交，进度记录在 State 中
+type Campaign struct {
+	conf  Config
+	state *State
+}
+
+func New(conf Config, state *State) *Campaign {
+	if conf.Send == nil {
+		conf.Send = sms.SendMessageWait
+	}
+	if conf.Query == nil {
+		conf.Query = sms.Query
+	}
+	if conf.Check == nil {
+		conf.Check = check
+	}
+	if conf.Wait <= 0 {
+		conf.Wait = time.Minute
+	}
+	return &Campaign{conf: conf, state: state}
+}
+
+// check 退订的号码标记为 rejected，无路由的号码标记为 no_route
+func check(phone string, m *sms.Message) string {
+	if sms.IsSuppressed(phone) {
+		return gateway.StatusRejected
+	}
+	if !sms.Routable(phone, m) {
+		return StatusNoRoute
+	}
+	return ""
+}
+
+// Run 依次提交尚未处理的接收人，提交速度受账号的发送窗口及吞吐量限制（另可由 TPS 限制），
+// ctx 结束时停止提交并返回 ctx.Err()，已提交的进度保留在 State 中。
+func (c *Campaign) Run(ctx context.Context, rs []*Recipient) (submitted int, err error) {
+	var limiter *rate.Limiter
+	if c.conf.TPS > 0 {
+		limiter = rate.NewLimiter(rate.Limit(c.conf.TPS), 1)
+	}
+	for _, r := range rs {
+		if err = ctx.Err(); err != nil {
+			return submitted, err
+		}
+		if c.state.Get(r.Phone) != nil {
+			continue
+		}
+		res := &Result{Line: r.Line, Phone: r.Phone, ClientMsgId: c.conf.Id + "-" + r.Phone}
+		m, err := c.message(r, res.ClientMsgId)
+		if err != nil {
+			res.Status, res.Reason = StatusBadVars, err.Error()
+		} else if res.Status = c.conf.Check(r.Phone, m); res.Status == gateway.StatusRejected {
+			res.Reason = sms.RejectSuppressed
+		}
+		if res.Status == "" {
+			if limiter != nil {
+				if err = limiter.Wait(ctx); err != nil {
+					return submitted, err
+				}
+			}
+			if res.QueryId, err = c.conf.Send(ctx, m); err != nil {
+				return submitted, err
+			}
+			res.Status = gateway.StatusSubmitted
+			submitted++
+		}
+		res.Time = time.Now()
+		if err = c.state.Put(res); err != nil {
+			return submitted, err
+		}
+	}
+	return submitted, c.state.Flush()
+}
+
+func (c *Campaign) message(r *Recipient, clientMsgId string) (*sms.Message, error) {
+	content, err := c.conf.Content(r)
+	if err != nil {
+		return nil, err
+	}
+	return &sms.Message{
+		ClientMsgId: clientMsgId,
+		Content:     content,
+		Phones:      []string{r.Phone},
+		Account:     c.conf.Account,
+		Tag:         c.conf.Tag,
+		Options:     c.conf.Options,
+	}, nil
+}
+
+// WaitReports 定期查询已提交号码的发送结果，直到全部得到最终状态（投递成功、失败或被拒绝）、
+// 超过 Config.Wait 或 ctx 结束，返回所有已处理的结果
+func (c *Campaign) WaitReports(ctx context.Context) []*Result {
+	deadline := time.Now().Add(c.conf.Wait)
+	results := c.state.Results()
+	for {
+		pending := 0
+		for _, r := range results {
+			if r.QueryId == 0 || r.final() {
+				continue
+			}
+			c.refresh(r)
+			if !r.final() {
+				pending++
+			}
+		}
+		if pending == 0 || !time.Now().Before(deadline) {
+			break
+		}
+		log.Infof("[Campaign] Waiting for %d report(s).", pending)
+		select {
+		case <-ctx.Done():
+			return results
+		case <-time.After(time.Second):
+		}
+	}
+	for _, r := range results {
+		if r.QueryId != 0 {
+			_ = c.state.Put(r)
+		}
+	}
+	_ = c.state.Flush()
+	return results
+}
+
+// refresh 按查询编号更新号码的状态及分段结果
+func (c *Campaign) refresh(r *Result) {
+	var results []any
+	for _, a := range c.conf.Query(r.QueryId) {
+		if sr, ok := a.(*session.Result); ok && sr.Phone == r.Phone {
+			results = append(results, sr)
+		}
+	}
+	if len(results) == 0 {
+		return
+	}
+	p := gateway.NewSendResponse(r.QueryId, r.ClientMsgId, []string{r.Phone}, results).Phones[0]
+	r.Status, r.Segments = p.Status, p.Segments
+	if p.RejectReason != "" {
+		r.Reason = p.RejectReason
+	}
+}
+
+var resultHeader = []string{"line", "phone", "status", "reason", "query_id", "client_msg_id", "segments", "submit_results", "msg_ids", "reports"}
+
+// WriteResult 按行号顺序写入结果文件（CSV），每个号码一行，多个分段的结果以 ; 分隔
+func WriteResult(path string, results ...[]*Result) error {
+	var all []*Result
+	for _, rs := range results {
+		all = append(all, rs...)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Line < all[j].Line })
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(f)
+	_ = w.Write(resultHeader)
+	for _, r := range all {
+		var codes, msgIds, reports []string
+		for _, s := range r.Segments {
+			codes = append(codes, strconv.FormatUint(uint64(s.Result), 10))
+			msgIds = append(msgIds, s.MsgId)
+			reports = append(reports, s.Report)
+		}
+		queryId := ""
+		if r.QueryId != 0 {
+			queryId = strconv.FormatInt(r.QueryId, 10)
+		}
+		_ = w.Write([]string{
+			strconv.Itoa(r.Line), r.Phone, r.Status, r.Reason, queryId, r.ClientMsgId, strconv.Itoa(len(r.Segments)),
+			strings.Join(codes, ";"), strings.Join(msgIds, ";"), strings.Join(reports, ";"),
+		})
+	}
+	w.Flush()
+	if err = w.Error(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Summary 按状态统计结果数
+func Summary(results ...[]*Result) map[string]int {
+	ret := make(map[string]int)
+	for _, rs := range results {
+		for _, r := range rs {
+			ret[r.Status]++
+		}
+	}
+	return ret
+}
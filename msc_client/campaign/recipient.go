@@ -0,0 +1,144 @@
+package campaign
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hrygo/gosms/smc_client/route"
+)
+
+// Recipient 接收人，Vars 为同一行中除手机号码外的其他列，用于模板变量
+type Recipient struct {
+	Line  int               `json:"line"` // 在文件中的行号（JSON 为数组下标，从1开始）
+	Phone string            `json:"phone"`
+	Vars  map[string]string `json:"vars,omitempty"`
+}
+
+var phoneReg = regexp.MustCompile(`^(1[3-9][0-9]{9}|\+[1-9][0-9]{6,14})$`)
+
+// Load 按扩展名读取接收人文件，.json 为 JSON 数组，其他为带表头的 CSV
+func Load(path string) ([]*Recipient, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func(f *os.File) {
+		_ = f.Close()
+	}(f)
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return ReadJSON(f)
+	}
+	return ReadCSV(f)
+}
+
+// ReadCSV 读取 CSV，首行为表头且须包含 phone 列，其余列作为变量
+func ReadCSV(r io.Reader) ([]*Recipient, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+	col := -1
+	for i, h := range header {
+		header[i] = strings.TrimSpace(strings.TrimPrefix(h, "\ufeff"))
+		if strings.EqualFold(header[i], "phone") {
+			col = i
+		}
+	}
+	if col < 0 {
+		return nil, errors.New("csv: phone column is required")
+	}
+	var ret []*Recipient
+	for line := 2; ; line++ {
+		row, err := cr.Read()
+		if err == io.EOF {
+			return ret, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(row) == 1 && strings.TrimSpace(row[0]) == "" {
+			continue
+		}
+		rc := &Recipient{Line: line, Vars: make(map[string]string, len(header)-1)}
+		for i, v := range row {
+			switch {
+			case i == col:
+				rc.Phone = strings.TrimSpace(v)
+			case i < len(header):
+				rc.Vars[header[i]] = v
+			}
+		}
+		ret = append(ret, rc)
+	}
+}
+
+// ReadJSON 读取 JSON 数组，元素须包含 phone，变量可放在 vars 对象中，也可与 phone 平级
+func ReadJSON(r io.Reader) ([]*Recipient, error) {
+	var items []map[string]any
+	if err := json.NewDecoder(r).Decode(&items); err != nil {
+		return nil, err
+	}
+	ret := make([]*Recipient, 0, len(items))
+	for i, item := range items {
+		rc := &Recipient{Line: i + 1, Vars: make(map[string]string)}
+		for k, v := range item {
+			switch {
+			case k == "phone":
+				rc.Phone = strings.TrimSpace(jsonString(v))
+			case k == "vars":
+				vars, ok := v.(map[string]any)
+				if !ok {
+					return nil, fmt.Errorf("json: item %d: vars must be an object", i+1)
+				}
+				for vk, vv := range vars {
+					rc.Vars[vk] = jsonString(vv)
+				}
+			default:
+				rc.Vars[k] = jsonString(v)
+			}
+		}
+		ret = append(ret, rc)
+	}
+	return ret, nil
+}
+
+// jsonString 数值按原样输出，避免科学计数法
+func jsonString(v any) string {
+	switch n := v.(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(n, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+// Check 规范化并校验手机号码，返回有效的接收人，以及号码无效（invalid）或重复（duplicate）的结果
+func Check(rs []*Recipient) (valid []*Recipient, skipped []*Result) {
+	seen := make(map[string]int, len(rs))
+	for _, r := range rs {
+		r.Phone = route.Normalize(r.Phone)
+		switch {
+		case !phoneReg.MatchString(r.Phone):
+			skipped = append(skipped, &Result{Line: r.Line, Phone: r.Phone, Status: StatusInvalid})
+		case seen[r.Phone] > 0:
+			skipped = append(skipped, &Result{Line: r.Line, Phone: r.Phone, Status: StatusDuplicate,
+				Reason: fmt.Sprintf("same as line %d", seen[r.Phone])})
+		default:
+			seen[r.Phone] = r.Line
+			valid = append(valid, r)
+		}
+	}
+	return
+}
@@ -0,0 +1,120 @@
+package campaign
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var bucket = []byte("campaign")
+
+// 每累计多少条记录写入一次文件
+const flushSize = 100
+
+// State 发送进度，记录每个号码的处理结果，中断后重新执行时跳过已处理的号码。
+// 记录按批写入文件，进程异常退出时最后一批可能丢失，这些号码会被再次发送，
+// 启用发件箱时相同 ClientMsgId 与号码的消息只发送一次。
+type State struct {
+	mu      sync.Mutex
+	db      *bolt.DB
+	results map[string]*Result
+	dirty   []*Result
+}
+
+// OpenState 打开（或创建）进度文件，并加载已有的记录
+func OpenState(path string) (*State, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 3 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	s := &State{db: db, results: make(map[string]*Result)}
+	err = db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(bucket)
+		if err != nil {
+			return err
+		}
+		return b.ForEach(func(k, v []byte) error {
+			r := &Result{}
+			if err := json.Unmarshal(v, r); err != nil {
+				return err
+			}
+			s.results[r.Phone] = r
+			return nil
+		})
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Get 获取号码的处理结果，未处理时返回nil
+func (s *State) Get(phone string) *Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.results[phone]
+}
+
+// Put 记录号码的处理结果
+func (s *State) Put(r *Result) error {
+	s.mu.Lock()
+	s.results[r.Phone] = r
+	s.dirty = append(s.dirty, r)
+	full := len(s.dirty) >= flushSize
+	s.mu.Unlock()
+	if full {
+		return s.Flush()
+	}
+	return nil
+}
+
+// Results 所有已处理的结果
+func (s *State) Results() []*Result {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ret := make([]*Result, 0, len(s.results))
+	for _, r := range s.results {
+		ret = append(ret, r)
+	}
+	return ret
+}
+
+// Flush 将未写入的记录写入文件
+func (s *State) Flush() error {
+	s.mu.Lock()
+	dirty := s.dirty
+	s.dirty = nil
+	data := make([][]byte, len(dirty))
+	for i, r := range dirty {
+		data[i], _ = json.Marshal(r)
+	}
+	s.mu.Unlock()
+	if len(dirty) == 0 {
+		return nil
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket)
+		for i, r := range dirty {
+			if err := b.Put([]byte(r.Phone), data[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *State) Close() error {
+	err := s.Flush()
+	if e := s.db.Close(); err == nil {
+		err = e
+	}
+	return err
+}
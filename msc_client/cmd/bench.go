@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hrygo/log"
+
+	"github.com/hrygo/gosms/event_manager"
+	"github.com/hrygo/gosms/smc_client"
+	"github.com/hrygo/gosms/smc_client/bench"
+	"github.com/hrygo/gosms/smc_client/session"
+)
+
+// runBench 压测：smscli bench -isp cmpp,sgip -tps 1000 -d 1m [-n 10000] [-json report.json]
+func runBench(args []string) {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	isps := fs.String("isp", "", "protocols to test, comma separated, default all")
+	accounts := fs.String("a", "", "accounts to test, comma separated, overrides -isp")
+	tps := fs.Int("tps", 0, "target submits per second, 0 means unlimited")
+	concurrency := fs.Int("c", 16, "concurrent submitters")
+	duration := fs.Duration("d", 30*time.Second, "submit duration, 0 means until -n messages are submitted")
+	count := fs.Int("n", 0, "total messages to submit, 0 means unlimited")
+	drain := fs.Duration("drain", 10*time.Second, "max time to wait for responses and reports after submitting")
+	message := fs.String("m", "hello world, 你好世界！", "message")
+	jsonPath := fs.String("json", "", "write the report as json to this file")
+	_ = fs.Parse(args)
+	if *duration <= 0 && *count <= 0 {
+		log.Fatalf("[Bench] Either -d or -n must be positive.")
+	}
+
+	names := split(*accounts)
+	if len(names) == 0 {
+		want := split(*isps)
+		for _, a := range sms.Accounts() {
+			if len(want) == 0 || contains(want, a.ISP) {
+				names = append(names, a.Name)
+			}
+		}
+		sort.Strings(names)
+	}
+	if len(names) == 0 {
+		log.Fatalf("[Bench] No account to test.")
+	}
+	// 预先建立连接，连接计数只统计压测期间的重连
+	for _, name := range names {
+		if sms.FindAccount(name) == nil {
+			log.Fatalf("[Bench] Account %s not found.", name)
+		}
+		if sms.CreateSessionFactory(name) == nil {
+			log.Fatalf("[Bench] Account %s unavailable.", name)
+		}
+	}
+
+	r := bench.New(bench.Config{
+		Accounts:    names,
+		TPS:         *tps,
+		Concurrency: *concurrency,
+		Duration:    *duration,
+		Count:       *count,
+		Drain:       *drain,
+		Content:     *message,
+	})
+	session.AddResultListener(r.OnResult)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	event_manager.RegisterShutdownHooker("Stop_Bench", func(args ...any) {
+		cancel()
+	})
+	log.Infof("[Bench] Start with accounts %v, tps=%d, concurrency=%d, duration=%s, count=%d.", names, *tps, *concurrency, *duration, *count)
+	report := r.Run(ctx)
+	report.Print(os.Stdout)
+	if *jsonPath != "" {
+		data, _ := json.MarshalIndent(report, "", "  ")
+		if err := os.WriteFile(*jsonPath, data, 0644); err != nil {
+			log.Errorf("[Bench] Write %s error: %v", *jsonPath, err)
+		}
+	}
+	log.Sync()
+	os.Exit(0)
+}
+
+func split(s string) []string {
+	var ret []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.ToLower(strings.TrimSpace(v)); v != "" {
+			ret = append(ret, v)
+		}
+	}
+	return ret
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hrygo/log"
+
+	"github.com/hrygo/gosms/event_manager"
+	"github.com/hrygo/gosms/smc_client"
+	"github.com/hrygo/gosms/smc_client/campaign"
+)
+
+// runCampaign 群发：smscli campaign -f recipients.csv -t <模板编号> | -m <内容>
+// 中断后使用相同参数重新执行，将跳过进度文件中已处理的号码
+func runCampaign(args []string) {
+	fs := flag.NewFlagSet("campaign", flag.ExitOnError)
+	file := fs.String("f", "", "recipient file, .csv with a phone column or .json array")
+	templateId := fs.String("t", "", "template id, other columns are template vars")
+	message := fs.String("m", "", "message content, ${column} is replaced with the recipient's column")
+	account := fs.String("a", "", "account or group")
+	tag := fs.String("tag", "", "business tag for routing")
+	tps := fs.Int("tps", 0, "max submits per second, 0 means limited by account throughput only")
+	wait := fs.Duration("wait", 0, "max time to wait for reports after all submitted, default 1m")
+	id := fs.String("id", "", "campaign id used as client msg id prefix, default file name")
+	out := fs.String("o", "", "result file, default <file>.result.csv")
+	statePath := fs.String("state", "", "progress file for resuming, default <file>.state")
+	_ = fs.Parse(args)
+
+	if *file == "" || (*templateId == "") == (*message == "") {
+		fs.Usage()
+		os.Exit(2)
+	}
+	base := strings.TrimSuffix(*file, filepath.Ext(*file))
+	if *id == "" {
+		*id = filepath.Base(base)
+	}
+	if *out == "" {
+		*out = base + ".result.csv"
+	}
+	if *statePath == "" {
+		*statePath = base + ".state"
+	}
+	if *wait == 0 {
+		*wait = sms.ConfigYml.GetDuration("Campaign.wait")
+	}
+
+	rs, err := campaign.Load(*file)
+	if err != nil {
+		log.Fatalf("[Campaign] Load %s error: %v", *file, err)
+	}
+	valid, skipped := campaign.Check(rs)
+	state, err := campaign.OpenState(*statePath)
+	if err != nil {
+		log.Fatalf("[Campaign] Open %s error: %v", *statePath, err)
+	}
+
+	content := func(r *campaign.Recipient) (string, error) {
+		return os.Expand(*message, func(k string) string { return r.Vars[k] }), nil
+	}
+	if *templateId != "" {
+		if sms.Templates().Get(*templateId) == nil {
+			log.Fatalf("[Campaign] Template %s not found.", *templateId)
+		}
+		content = func(r *campaign.Recipient) (string, error) {
+			vars := make(map[string]any, len(r.Vars))
+			for k, v := range r.Vars {
+				vars[k] = v
+			}
+			return sms.RenderTemplate(*templateId, vars)
+		}
+	}
+	c := campaign.New(campaign.Config{
+		Id:      *id,
+		Content: content,
+		Account: *account,
+		Tag:     *tag,
+		TPS:     *tps,
+		Wait:    *wait,
+	}, state)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	event_manager.RegisterShutdownHooker("Stop_Campaign", func(args ...any) {
+		cancel()
+	})
+
+	log.Infof("[Campaign] %s: %d recipient(s), %d valid.", *id, len(rs), len(valid))
+	n, err := c.Run(ctx, valid)
+	log.Infof("[Campaign] %s: %d submitted.", *id, n)
+	if err != nil && !errors.Is(err, context.Canceled) {
+		log.Errorf("[Campaign] %s error: %v", *id, err)
+	}
+	results := state.Results()
+	if err == nil {
+		results = c.WaitReports(ctx)
+	}
+	if e := campaign.WriteResult(*out, skipped, results); e != nil {
+		log.Errorf("[Campaign] Write %s error: %v", *out, e)
+	}
+	_ = state.Close()
+	log.Infof("[Campaign] %s: %v, result in %s.", *id, campaign.Summary(skipped, results), *out)
+	log.Sync()
+	if err != nil {
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
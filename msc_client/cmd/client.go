@@ -12,15 +12,18 @@ import (
 )
 
 func main() {
-	// 启动记录数据库的程序
-	if sms.ConfigYml.GetString("Mongo.URI") != "" {
-		sms.PersistenceSmsJournal()
-	} else {
-		sms.StartCacheExpireTicker(nil)
+	start()
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "campaign":
+			runCampaign(os.Args[2:])
+			return
+		case "bench":
+			runBench(os.Args[2:])
+			return
+		}
 	}
 
-	auth.Cache = auth.New(sms.ConfigYml)
-
 	phone := flag.String("p", "13800001111,13300001111,18600001111", "phone")
 	message := flag.String("m", "hello world, 你好世界！", "message")
 	iterates := flag.Int("i", 1, "iterates")
@@ -49,3 +52,41 @@ func main() {
 	log.Sync()
 	os.Exit(0)
 }
+
+// start 启动发送记录、认证、发件箱、退订及上行短信处理
+func start() {
+	// 启动记录数据库的程序
+	if err := sms.StartJournal(); err != nil {
+		log.Fatalf("Start journal error: %v", err)
+	}
+
+	auth.Cache = auth.New(sms.ConfigYml)
+
+	if err := sms.StartOutbox(); err != nil {
+		log.Fatalf("Start outbox error: %v", err)
+	}
+
+	if err := sms.StartUnsubscribe(); err != nil {
+		log.Fatalf("Start unsubscribe error: %v", err)
+	}
+	if err := sms.StartFrequencyCap(); err != nil {
+		log.Fatalf("Start frequency cap error: %v", err)
+	}
+	if err := sms.StartIdempotency(); err != nil {
+		log.Fatalf("Start idempotency error: %v", err)
+	}
+	if err := sms.StartScheduler(); err != nil {
+		log.Fatalf("Start scheduler error: %v", err)
+	}
+	if err := sms.StartQuietHours(); err != nil {
+		log.Fatalf("Start quiet hours error: %v", err)
+	}
+
+	sms.HandleInbound("log", sms.InboundFilter{}, func(m *sms.InboundMessage) error {
+		log.Infof("[Inbound] Receive from %s to %s: %s", m.Phone, m.DestId, m.Content)
+		return nil
+	})
+	if err := sms.StartInbound(); err != nil {
+		log.Fatalf("Start inbound error: %v", err)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/hrygo/log"
+
+	"github.com/hrygo/gosms/auth"
+	"github.com/hrygo/gosms/event_manager"
+	"github.com/hrygo/gosms/smc_client"
+	"github.com/hrygo/gosms/smc_client/gateway"
+	"github.com/hrygo/gosms/smc_client/rpc"
+)
+
+// smcgw 短信客户端的 HTTP 及 gRPC 网关，供无法直接引用 sms 包的服务使用
+func main() {
+	if err := sms.StartJournal(); err != nil {
+		log.Fatalf("Start journal error: %v", err)
+	}
+
+	auth.Cache = auth.New(sms.ConfigYml)
+
+	if err := sms.StartOutbox(); err != nil {
+		log.Fatalf("Start outbox error: %v", err)
+	}
+	if err := sms.StartUnsubscribe(); err != nil {
+		log.Fatalf("Start unsubscribe error: %v", err)
+	}
+	if err := sms.StartFrequencyCap(); err != nil {
+		log.Fatalf("Start frequency cap error: %v", err)
+	}
+	if err := sms.StartIdempotency(); err != nil {
+		log.Fatalf("Start idempotency error: %v", err)
+	}
+	if err := sms.StartScheduler(); err != nil {
+		log.Fatalf("Start scheduler error: %v", err)
+	}
+	if err := sms.StartQuietHours(); err != nil {
+		log.Fatalf("Start quiet hours error: %v", err)
+	}
+	if err := sms.StartInbound(); err != nil {
+		log.Fatalf("Start inbound error: %v", err)
+	}
+
+	var conf gateway.Config
+	if err := sms.ConfigYml.Viper().UnmarshalKey("Gateway", &conf); err != nil {
+		log.Fatalf("Gateway config error: %v", err)
+	}
+	gw, err := gateway.New(conf)
+	if err != nil {
+		log.Fatalf("Create gateway error: %v", err)
+	}
+	event_manager.RegisterShutdownHooker("Stop_Gateway", func(args ...any) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = gw.Shutdown(ctx)
+	})
+	go func() {
+		if err := gw.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Gateway error: %v", err)
+		}
+	}()
+
+	if conf.GrpcAddr != "" {
+		lis, err := net.Listen("tcp", conf.GrpcAddr)
+		if err != nil {
+			log.Fatalf("Listen %s error: %v", conf.GrpcAddr, err)
+		}
+		rs := rpc.NewServer(conf.Token)
+		event_manager.RegisterShutdownHooker("Stop_Rpc", func(args ...any) {
+			rs.Stop()
+		})
+		go func() {
+			if err := rs.Serve(lis); err != nil {
+				log.Fatalf("Rpc error: %v", err)
+			}
+		}()
+	}
+
+	<-sms.StatChan()
+
+	log.Warn("main goroutine exit.")
+	log.Sync()
+	os.Exit(0)
+}
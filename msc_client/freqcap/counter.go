@@ -0,0 +1,140 @@
+package freqcap
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Counter 频率限制的计数存储，多个客户端进程共享限制时使用 RedisCounter
+type Counter interface {
+	// Incr 计数加一并返回当前值，ttl 后计数过期
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	// Decr 计数减一，用于撤销未生效的计数
+	Decr(ctx context.Context, key string) error
+	// SetNX 键不存在时设置并返回 true，ttl 后过期
+	SetNX(ctx context.Context, key string, ttl time.Duration) (bool, error)
+	// Del 删除键
+	Del(ctx context.Context, key string) error
+	Close() error
+}
+
+type memoryEntry struct {
+	n      int64
+	expire time.Time
+}
+
+// MemoryCounter 进程内的计数存储
+type MemoryCounter struct {
+	mu      sync.Mutex
+	entries map[string]*memoryEntry
+	ops     int
+}
+
+func NewMemoryCounter() *MemoryCounter {
+	return &MemoryCounter{entries: make(map[string]*memoryEntry)}
+}
+
+// get 获取未过期的计数，每 1024 次操作清理一次过期计数
+func (c *MemoryCounter) get(key string, now time.Time) *memoryEntry {
+	if c.ops++; c.ops >= 1024 {
+		c.ops = 0
+		for k, e := range c.entries {
+			if !now.Before(e.expire) {
+				delete(c.entries, k)
+			}
+		}
+	}
+	e := c.entries[key]
+	if e != nil && !now.Before(e.expire) {
+		delete(c.entries, key)
+		return nil
+	}
+	return e
+}
+
+func (c *MemoryCounter) Incr(_ context.Context, key string, ttl time.Duration) (int64, error) {
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e := c.get(key, now)
+	if e == nil {
+		e = &memoryEntry{expire: now.Add(ttl)}
+		c.entries[key] = e
+	}
+	e.n++
+	return e.n, nil
+}
+
+func (c *MemoryCounter) Decr(_ context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e := c.get(key, time.Now()); e != nil {
+		e.n--
+	}
+	return nil
+}
+
+func (c *MemoryCounter) SetNX(_ context.Context, key string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.get(key, now) != nil {
+		return false, nil
+	}
+	c.entries[key] = &memoryEntry{n: 1, expire: now.Add(ttl)}
+	return true, nil
+}
+
+func (c *MemoryCounter) Del(_ context.Context, key string) error {
+	c.mu.Lock()
+	delete(c.entries, key)
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *MemoryCounter) Close() error {
+	return nil
+}
+
+// RedisCounter 基于 Redis 的计数存储，键名均加上 prefix
+type RedisCounter struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+func NewRedisCounter(client redis.UniversalClient, prefix string) *RedisCounter {
+	return &RedisCounter{client: client, prefix: prefix}
+}
+
+func (c *RedisCounter) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	key = c.prefix + key
+	var incr *redis.IntCmd
+	_, err := c.client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		incr = p.Incr(ctx, key)
+		p.Expire(ctx, key, ttl)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
+
+func (c *RedisCounter) Decr(ctx context.Context, key string) error {
+	return c.client.Decr(ctx, c.prefix+key).Err()
+}
+
+func (c *RedisCounter) SetNX(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return c.client.SetNX(ctx, c.prefix+key, 1, ttl).Result()
+}
+
+func (c *RedisCounter) Del(ctx context.Context, key string) error {
+	return c.client.Del(ctx, c.prefix+key).Err()
+}
+
+func (c *RedisCounter) Close() error {
+	return c.client.Close()
+}
@@ -0,0 +1,97 @@
+package freqcap
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"time"
+)
+
+// 拒绝发送的原因
+const (
+	Frequency = "FREQUENCY" // 超过单号码发送频率限制
+	Duplicate = "DUPLICATE" // 相同内容在重复检查窗口内已发送给该号码
+)
+
+// Rule 固定时间窗口内发送给同一号码的最大条数，窗口按本地时间对齐（如自然日）
+type Rule struct {
+	Window time.Duration
+	Max    int64
+}
+
+// Limiter 单号码发送频率及重复内容限制
+type Limiter struct {
+	counter   Counter
+	rules     []Rule
+	dupWindow time.Duration
+	now       func() time.Time
+}
+
+// NewLimiter 创建限制器，dupWindow 为 0 时不检查重复内容
+func NewLimiter(counter Counter, rules []Rule, dupWindow time.Duration) *Limiter {
+	var valid []Rule
+	for _, r := range rules {
+		if r.Window > 0 && r.Max > 0 {
+			valid = append(valid, r)
+		}
+	}
+	return &Limiter{counter: counter, rules: valid, dupWindow: dupWindow, now: time.Now}
+}
+
+// WithClock 替换获取当前时间的函数，用于测试中固定时间窗口
+func (l *Limiter) WithClock(now func() time.Time) *Limiter {
+	l.now = now
+	return l
+}
+
+// Allow 检查并计数一次发送，返回拒绝原因，为空表示允许发送。
+// 被拒绝的发送不计入频率，计数存储出错时返回错误，由调用方决定是否放行。
+func (l *Limiter) Allow(ctx context.Context, phone, content string) (string, error) {
+	now := l.now()
+	var dupKey string
+	if l.dupWindow > 0 {
+		dupKey = fmt.Sprintf("dup:%s:%x", phone, hash(content))
+		ok, err := l.counter.SetNX(ctx, dupKey, l.dupWindow)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			return Duplicate, nil
+		}
+	}
+
+	_, offset := now.Zone()
+	var counted []string
+	rollback := func() {
+		for _, k := range counted {
+			_ = l.counter.Decr(ctx, k)
+		}
+		if dupKey != "" {
+			_ = l.counter.Del(ctx, dupKey)
+		}
+	}
+	for _, r := range l.rules {
+		sec := int64(r.Window / time.Second)
+		if sec <= 0 {
+			sec = 1
+		}
+		key := fmt.Sprintf("freq:%s:%d:%d", phone, sec, (now.Unix()+int64(offset))/sec)
+		n, err := l.counter.Incr(ctx, key, r.Window)
+		if err != nil {
+			rollback()
+			return "", err
+		}
+		counted = append(counted, key)
+		if n > r.Max {
+			rollback()
+			return Frequency, nil
+		}
+	}
+	return "", nil
+}
+
+func hash(content string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(content))
+	return h.Sum64()
+}
@@ -0,0 +1,104 @@
+package sms
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/hrygo/log"
+
+	"github.com/hrygo/gosms/event_manager"
+	"github.com/hrygo/gosms/smc_client/freqcap"
+	"github.com/hrygo/gosms/smc_client/route"
+)
+
+// freqLimiter 单号码发送频率限制，未启用时为nil
+var freqLimiter *freqcap.Limiter
+
+// FrequencyCapConfig 发送频率限制配置
+type FrequencyCapConfig struct {
+	Enable          bool          `mapstructure:"enable"`
+	Store           string        `mapstructure:"store"`            // memory、redis
+	PerMinute       int64         `mapstructure:"per-minute"`       // 每分钟最多发送给同一号码的条数，0 不限制
+	PerHour         int64         `mapstructure:"per-hour"`         // 每小时最多条数
+	PerDay          int64         `mapstructure:"per-day"`          // 每自然日最多条数
+	DuplicateWindow time.Duration `mapstructure:"duplicate-window"` // 相同内容发送给同一号码的最小间隔，0 不检查
+	Redis           RedisConfig   `mapstructure:"redis"`
+}
+
+// RedisConfig Redis 连接配置
+type RedisConfig struct {
+	Addr      string `mapstructure:"addr"`
+	Password  string `mapstructure:"password"`
+	DB        int    `mapstructure:"db"`
+	KeyPrefix string `mapstructure:"key-prefix"`
+}
+
+// newRedisClient 创建 Redis 客户端并检查连接
+func newRedisClient(conf RedisConfig) (*redis.Client, error) {
+	client := redis.NewClient(&redis.Options{Addr: conf.Addr, Password: conf.Password, DB: conf.DB})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
+// StartFrequencyCap 按配置文件启用发送频率限制（FrequencyCap.enable）：
+// 超过单号码发送条数限制的消息被拒绝，RejectReason 为 FREQUENCY；
+// 重复检查窗口内发送给同一号码的相同内容被拒绝，RejectReason 为 DUPLICATE。
+// store 为 redis 时多个客户端进程共享计数。
+func StartFrequencyCap() error {
+	var conf FrequencyCapConfig
+	if err := ConfigYml.Viper().UnmarshalKey("FrequencyCap", &conf); err != nil {
+		return err
+	}
+	if !conf.Enable {
+		return nil
+	}
+	var counter freqcap.Counter
+	switch conf.Store {
+	case "", "memory":
+		counter = freqcap.NewMemoryCounter()
+	case "redis":
+		client, err := newRedisClient(conf.Redis)
+		if err != nil {
+			return err
+		}
+		counter = freqcap.NewRedisCounter(client, conf.Redis.KeyPrefix)
+	default:
+		log.Warnf("[FrequencyCap] Unknown store %s, use memory.", conf.Store)
+		counter = freqcap.NewMemoryCounter()
+	}
+	freqLimiter = freqcap.NewLimiter(counter, []freqcap.Rule{
+		{Window: time.Minute, Max: conf.PerMinute},
+		{Window: time.Hour, Max: conf.PerHour},
+		{Window: 24 * time.Hour, Max: conf.PerDay},
+	}, conf.DuplicateWindow)
+	event_manager.RegisterShutdownHooker("Close_FrequencyCap", func(args ...any) {
+		_ = counter.Close()
+	})
+	log.Infof("[FrequencyCap] Started with %s store.", conf.Store)
+	return nil
+}
+
+// limitCheck 检查并计数单号码的发送频率，返回拒绝原因。
+// 仅对新消息调用，发件箱重发的消息不再计数；计数存储异常时放行。
+func limitCheck(phone string, m *Message) string {
+	if freqLimiter == nil {
+		return ""
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	reason, err := freqLimiter.Allow(ctx, route.Normalize(phone), m.Content)
+	if err != nil {
+		log.Errorf("[FrequencyCap] Check %s error: %v", phone, err)
+		return ""
+	}
+	if reason != "" {
+		log.Warnf("[FrequencyCap] Message %s to %s rejected: %s.", m.ClientMsgId, phone, reason)
+	}
+	return reason
+}
@@ -0,0 +1,275 @@
+package gateway
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hrygo/log"
+
+	"github.com/hrygo/gosms/smc_client"
+	"github.com/hrygo/gosms/smc_client/session"
+)
+
+// Config 网关配置，对应配置文件的 Gateway 配置项
+type Config struct {
+	Addr     string `mapstructure:"addr"`      // 监听地址
+	Token    string `mapstructure:"token"`     // 访问令牌，非空时请求须携带 Authorization: Bearer <token>
+	MaxBatch int    `mapstructure:"max-batch"` // 单次批量提交的最大消息数
+	GrpcAddr string `mapstructure:"grpc-addr"` // gRPC 服务监听地址，为空时不启用，见 rpc 包
+	Webhook  struct {
+		Timeout       time.Duration `mapstructure:"timeout"`        // 推送超时时间
+		Retries       int           `mapstructure:"retries"`        // 推送失败的最大重试次数
+		RetryInterval time.Duration `mapstructure:"retry-interval"` // 首次重试间隔，此后每次加倍
+	} `mapstructure:"webhook"`
+	Webhooks []Webhook `mapstructure:"webhooks"` // 启动时注册的 Webhook
+}
+
+const maxBodySize = 8 << 20
+
+// Server smcgw HTTP 网关，将 sms.SendMessage、sms.Query 封装为 JSON 接口：
+//
+//	POST   /v1/messages         发送短信，请求体为 SendRequest，可通过 Idempotency-Key 请求头指定幂等键
+//	POST   /v1/messages/batch   批量发送，请求体为 {"messages": [SendRequest...]}
+//	GET    /v1/messages/{id}    按查询编号查询发送结果
+//	GET    /v1/webhooks         列出状态报告推送地址
+//	POST   /v1/webhooks         注册推送地址，请求体为 Webhook
+//	DELETE /v1/webhooks?url=    注销推送地址
+type Server struct {
+	conf     Config
+	notifier *Notifier
+	http     *http.Server
+}
+
+// New 创建网关，注册配置中的 Webhook 并监听状态报告
+func New(conf Config) (*Server, error) {
+	if conf.Addr == "" {
+		conf.Addr = ":8090"
+	}
+	if conf.MaxBatch <= 0 {
+		conf.MaxBatch = 1000
+	}
+	n := NewNotifier(conf.Webhook.Timeout, conf.Webhook.Retries, conf.Webhook.RetryInterval)
+	for _, w := range conf.Webhooks {
+		if err := n.Register(w); err != nil {
+			return nil, err
+		}
+	}
+	s := &Server{conf: conf, notifier: n}
+	s.http = &http.Server{Addr: conf.Addr, Handler: s.Handler()}
+	session.AddResultListener(n.OnResult)
+	return s, nil
+}
+
+// Notifier 状态报告推送器
+func (s *Server) Notifier() *Notifier {
+	return s.notifier
+}
+
+// ListenAndServe 启动 HTTP 服务，Shutdown 后返回 http.ErrServerClosed
+func (s *Server) ListenAndServe() error {
+	log.Infof("[Gateway] Listening on %s.", s.conf.Addr)
+	return s.http.ListenAndServe()
+}
+
+// Shutdown 停止 HTTP 服务
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.http.Shutdown(ctx)
+}
+
+// Handler 网关的 HTTP 处理器
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/messages", s.handleSend)
+	mux.HandleFunc("/v1/messages/batch", s.handleBatch)
+	mux.HandleFunc("/v1/messages/", s.handleQuery)
+	mux.HandleFunc("/v1/webhooks", s.handleWebhooks)
+	return s.auth(mux)
+}
+
+func (s *Server) auth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.conf.Token != "" &&
+			subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+s.conf.Token)) != 1 {
+			writeError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handleSend(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodPost) {
+		return
+	}
+	var req SendRequest
+	if err := decode(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	m, err := req.Message()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	key := req.IdempotencyKey
+	if key == "" {
+		key = r.Header.Get("Idempotency-Key")
+	}
+	resp, err := send(m, key)
+	if errors.Is(err, sms.ErrIdempotencyDisabled) {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleBatch(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodPost) {
+		return
+	}
+	var req struct {
+		Messages []SendRequest `json:"messages"`
+	}
+	if err := decode(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if len(req.Messages) == 0 {
+		writeError(w, http.StatusBadRequest, errors.New("messages is required"))
+		return
+	}
+	if len(req.Messages) > s.conf.MaxBatch {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("too many messages, max %d", s.conf.MaxBatch))
+		return
+	}
+	results := make([]*SendResponse, 0, len(req.Messages))
+	for i := range req.Messages {
+		m, err := req.Messages[i].Message()
+		if err != nil {
+			results = append(results, &SendResponse{ClientMsgId: req.Messages[i].ClientMsgId, Phones: []PhoneResult{}, Error: err.Error()})
+			continue
+		}
+		resp, err := send(m, req.Messages[i].IdempotencyKey)
+		if err != nil {
+			resp = &SendResponse{ClientMsgId: req.Messages[i].ClientMsgId, Phones: []PhoneResult{}, Error: err.Error()}
+		}
+		results = append(results, resp)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"results": results})
+}
+
+func (s *Server) handleQuery(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodGet) {
+		return
+	}
+	queryId, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/v1/messages/"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusNotFound, errors.New("invalid query id"))
+		return
+	}
+	results := sms.Query(queryId)
+	if len(results) == 0 {
+		writeError(w, http.StatusNotFound, errors.New("not found"))
+		return
+	}
+	writeJSON(w, http.StatusOK, NewSendResponse(queryId, "", nil, results))
+}
+
+func (s *Server) handleWebhooks(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		hooks := s.notifier.List()
+		for i := range hooks {
+			if hooks[i].Secret != "" {
+				hooks[i].Secret = "******"
+			}
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"webhooks": hooks})
+	case http.MethodPost:
+		var hook Webhook
+		if err := decode(w, r, &hook); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		if err := s.notifier.Register(hook); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		log.Infof("[Gateway] Webhook %s registered.", hook.URL)
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodDelete:
+		u := r.URL.Query().Get("url")
+		if !s.notifier.Unregister(u) {
+			writeError(w, http.StatusNotFound, errors.New("not found"))
+			return
+		}
+		log.Infof("[Gateway] Webhook %s unregistered.", u)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+	}
+}
+
+// send 发送短信并返回当前的发送结果，网关响应及状态报告需通过查询接口或 Webhook 获取。
+// 指定幂等键时，重复的请求返回首次请求的查询编号及当前结果
+func send(m *sms.Message, key string) (*SendResponse, error) {
+	var queryId int64
+	var dup bool
+	if key == "" {
+		queryId = sms.SendMessage(m)
+	} else {
+		var err error
+		if queryId, dup, err = sms.SendMessageKey(key, m); err != nil {
+			return nil, err
+		}
+	}
+	results := sms.Query(queryId)
+	clientMsgId := m.ClientMsgId
+	if clientMsgId == "" {
+		clientMsgId = strconv.FormatInt(queryId, 10)
+	}
+	resp := NewSendResponse(queryId, clientMsgId, m.Phones, results)
+	resp.Duplicate = dup
+	return resp, nil
+}
+
+func allow(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return true
+	}
+	w.Header().Set("Allow", method)
+	writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+	return false
+}
+
+func decode(w http.ResponseWriter, r *http.Request, v any) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("invalid request body: %v", err)
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Errorf("[Gateway] Write response error: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
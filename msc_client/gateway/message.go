@@ -0,0 +1,298 @@
+package gateway
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hrygo/gosms/codec"
+	"github.com/hrygo/gosms/smc_client"
+	"github.com/hrygo/gosms/smc_client/session"
+)
+
+// SendRequest 发送请求，对应 sms.Message
+type SendRequest struct {
+	ClientMsgId string         `json:"clientMsgId"` // 客户端消息ID，为空时自动生成
+	Content     string         `json:"content"`     // 短信内容，与 templateId 二选一
+	TemplateId  string         `json:"templateId"`  // 短信模板编号，见 sms.Templates
+	Vars        map[string]any `json:"vars"`        // 模板变量
+	Phones      []string       `json:"phones"`      // 接收短信的手机号码
+	Account     string         `json:"account"`     // 指定发送账号或分组，为空时按路由规则选择
+	Tag         string         `json:"tag"`         // 业务标签，用于路由规则匹配，使用模板时默认为模板的 tag
+	Options     *Options       `json:"options"`     // 协议相关的可选项
+
+	IdempotencyKey string `json:"idempotencyKey"` // 幂等键，保留时间内重复的请求不再发送，返回首次请求的结果
+}
+
+// Options 发送可选项，对应 codec.MtOptions，未设置的字段使用账号的默认值
+type Options struct {
+	NeedReport      *uint8 `json:"needReport"`      // 是否需要状态报告，0：不需要；1：需要
+	MsgLevel        *uint8 `json:"msgLevel"`        // 消息优先级 0-9
+	AtTime          string `json:"atTime"`          // 定时发送时间，RFC3339 或 yyMMddHHmmss
+	ValidTime       string `json:"validTime"`       // 存活有效期，RFC3339 或 SMPP3.3 格式
+	SpSubNo         string `json:"spSubNo"`         // 扩展子号码
+	ServiceId       string `json:"serviceId"`       // 业务标识
+	LinkID          string `json:"linkId"`          // 点播业务的LinkID
+	FeeUsertype     *uint8 `json:"feeUsertype"`     // 计费用户类型 0-3
+	FeeTerminalType *uint8 `json:"feeTerminalType"` // 计费号码类型，0：真实号码；1：伪码
+	FeeTerminalId   string `json:"feeTerminalId"`   // 计费号码
+	FeeType         string `json:"feeType"`         // 资费类别 01-05
+	FeeCode         string `json:"feeCode"`         // 资费代码（以分为单位）
+}
+
+// MtOptions 转换为 codec.OptionFunc，取值非法时返回错误
+func (o *Options) MtOptions() ([]codec.OptionFunc, error) {
+	if o == nil {
+		return nil, nil
+	}
+	var ops []codec.OptionFunc
+	if o.NeedReport != nil {
+		if *o.NeedReport > 1 {
+			return nil, fmt.Errorf("invalid needReport: %d", *o.NeedReport)
+		}
+		ops = append(ops, codec.MtNeedReport(*o.NeedReport))
+	}
+	if o.MsgLevel != nil {
+		if *o.MsgLevel > 9 {
+			return nil, fmt.Errorf("invalid msgLevel: %d", *o.MsgLevel)
+		}
+		ops = append(ops, codec.MtMsgLevel(*o.MsgLevel))
+	}
+	if o.AtTime != "" {
+		if t, err := time.Parse(time.RFC3339, o.AtTime); err == nil {
+			ops = append(ops, codec.MtAtTime(t.Local()))
+		} else if isDigits(o.AtTime, 12) {
+			ops = append(ops, codec.MtAtTimeStr(o.AtTime))
+		} else {
+			return nil, fmt.Errorf("invalid atTime: %s", o.AtTime)
+		}
+	}
+	if o.ValidTime != "" {
+		if t, err := time.Parse(time.RFC3339, o.ValidTime); err == nil {
+			ops = append(ops, codec.MtValidTime(t.Local().Format("060102150405")+"032+"))
+		} else if len(o.ValidTime) == 16 && isDigits(o.ValidTime[:12], 12) {
+			ops = append(ops, codec.MtValidTime(o.ValidTime))
+		} else {
+			return nil, fmt.Errorf("invalid validTime: %s", o.ValidTime)
+		}
+	}
+	if o.SpSubNo != "" {
+		if !isDigits(o.SpSubNo, len(o.SpSubNo)) {
+			return nil, fmt.Errorf("invalid spSubNo: %s", o.SpSubNo)
+		}
+		ops = append(ops, codec.MtSpSubNo(o.SpSubNo))
+	}
+	if o.ServiceId != "" {
+		ops = append(ops, codec.MtServiceId(o.ServiceId))
+	}
+	if o.LinkID != "" {
+		ops = append(ops, codec.MtLinkID(o.LinkID))
+	}
+	if o.FeeUsertype != nil {
+		if *o.FeeUsertype > 3 {
+			return nil, fmt.Errorf("invalid feeUsertype: %d", *o.FeeUsertype)
+		}
+		ops = append(ops, codec.MtFeeUsertype(*o.FeeUsertype))
+	}
+	if o.FeeTerminalType != nil {
+		if *o.FeeTerminalType > 1 {
+			return nil, fmt.Errorf("invalid feeTerminalType: %d", *o.FeeTerminalType)
+		}
+		ops = append(ops, codec.MtFeeTerminalType(*o.FeeTerminalType))
+	}
+	if o.FeeTerminalId != "" {
+		ops = append(ops, codec.MtFeeTerminalId(o.FeeTerminalId))
+	}
+	if o.FeeType != "" {
+		switch o.FeeType {
+		case "01", "02", "03", "04", "05":
+		default:
+			return nil, fmt.Errorf("invalid feeType: %s", o.FeeType)
+		}
+		ops = append(ops, codec.MtFeeType(o.FeeType))
+	}
+	if o.FeeCode != "" {
+		ops = append(ops, codec.MtFeeCode(o.FeeCode))
+	}
+	return ops, nil
+}
+
+func isDigits(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Message 校验请求并转换为 sms.Message
+func (r *SendRequest) Message() (*sms.Message, error) {
+	content, tag := r.Content, r.Tag
+	if r.TemplateId != "" {
+		if content != "" {
+			return nil, errors.New("content and templateId are mutually exclusive")
+		}
+		var err error
+		if content, err = sms.RenderTemplate(r.TemplateId, r.Vars); err != nil {
+			return nil, err
+		}
+		if t := sms.Templates().Get(r.TemplateId); tag == "" && t != nil {
+			tag = t.Tag
+		}
+	}
+	if strings.TrimSpace(content) == "" {
+		return nil, errors.New("content is required")
+	}
+	var phones []string
+	for _, p := range r.Phones {
+		if p = strings.TrimSpace(p); p != "" {
+			phones = append(phones, p)
+		}
+	}
+	if len(phones) == 0 {
+		return nil, errors.New("phones is required")
+	}
+	ops, err := r.Options.MtOptions()
+	if err != nil {
+		return nil, err
+	}
+	return &sms.Message{
+		ClientMsgId: r.ClientMsgId,
+		Content:     content,
+		Phones:      phones,
+		Account:     r.Account,
+		Tag:         tag,
+		Options:     ops,
+	}, nil
+}
+
+// 手机号码的发送状态
+const (
+	StatusQueued      = "queued"      // 未提交网关，等待发件箱重试或暂存至允许发送的时段
+	StatusRejected    = "rejected"    // 客户端拒绝发送，见 rejectReason
+	StatusDropped     = "dropped"     // 无可用会话且未启用发件箱，消息已丢弃
+	StatusSubmitted   = "submitted"   // 已提交网关，等待响应
+	StatusFailed      = "failed"      // 网关拒绝
+	StatusResponded   = "responded"   // 网关已接收，等待状态报告
+	StatusDelivered   = "delivered"   // 全部分段投递成功
+	StatusUndelivered = "undelivered" // 存在投递失败的分段
+)
+
+// SendResponse 发送结果，按手机号码及分段组织
+type SendResponse struct {
+	QueryId     int64         `json:"queryId,string,omitempty"` // 查询编号
+	ClientMsgId string        `json:"clientMsgId,omitempty"`    // 客户端消息ID
+	Phones      []PhoneResult `json:"phones"`                   // 各号码的发送结果
+	Error       string        `json:"error,omitempty"`          // 批量提交时单条消息的错误
+	Duplicate   bool          `json:"duplicate,omitempty"`      // 幂等键重复，未再次发送
+}
+
+// PhoneResult 单个手机号码的发送结果
+type PhoneResult struct {
+	Phone        string    `json:"phone"`
+	Status       string    `json:"status"`
+	RejectReason string    `json:"rejectReason,omitempty"`
+	Segments     []Segment `json:"segments"` // 长短信按提交顺序的各分段
+}
+
+// Segment 单个分段（一次提交）的结果
+type Segment struct {
+	SequenceId   uint64     `json:"sequenceId,string"`
+	Result       uint32     `json:"result"` // 网关响应码
+	MsgId        string     `json:"msgId,omitempty"`
+	Report       string     `json:"report,omitempty"` // 状态报告，如 DELIVRD
+	SendTime     *time.Time `json:"sendTime,omitempty"`
+	ResponseTime *time.Time `json:"responseTime,omitempty"`
+	ReportTime   *time.Time `json:"reportTime,omitempty"`
+}
+
+// NewSendResponse 将发送结果按手机号码分组，phones 中没有结果的号码状态为 queued
+func NewSendResponse(queryId int64, clientMsgId string, phones []string, results []any) *SendResponse {
+	resp := &SendResponse{QueryId: queryId, ClientMsgId: clientMsgId, Phones: []PhoneResult{}}
+	index := make(map[string]int)
+	get := func(phone string) *PhoneResult {
+		i, ok := index[phone]
+		if !ok {
+			i = len(resp.Phones)
+			index[phone] = i
+			resp.Phones = append(resp.Phones, PhoneResult{Phone: phone, Segments: []Segment{}})
+		}
+		return &resp.Phones[i]
+	}
+	for _, p := range phones {
+		get(p)
+	}
+	for _, a := range results {
+		r, ok := a.(*session.Result)
+		if !ok {
+			continue
+		}
+		if resp.ClientMsgId == "" {
+			resp.ClientMsgId = r.ClientMsgId
+		}
+		pr := get(r.Phone)
+		if r.RejectReason != "" {
+			pr.RejectReason = r.RejectReason
+			continue
+		}
+		pr.Segments = append(pr.Segments, Segment{
+			SequenceId:   r.SequenceId,
+			Result:       r.Result,
+			MsgId:        r.MsgId,
+			Report:       r.Report,
+			SendTime:     timePtr(r.SendTime),
+			ResponseTime: timePtr(r.ResponseTime),
+			ReportTime:   timePtr(r.ReportTime),
+		})
+	}
+	for i := range resp.Phones {
+		resp.Phones[i].Status = status(&resp.Phones[i])
+	}
+	return resp
+}
+
+func status(pr *PhoneResult) string {
+	if pr.RejectReason == sms.RejectDropped {
+		return StatusDropped
+	}
+	if pr.RejectReason != "" {
+		return StatusRejected
+	}
+	if len(pr.Segments) == 0 {
+		return StatusQueued
+	}
+	reported, delivered := 0, 0
+	for _, s := range pr.Segments {
+		if s.ResponseTime != nil && s.Result != 0 {
+			return StatusFailed
+		}
+		if s.ResponseTime == nil && s.Report == "" {
+			return StatusSubmitted
+		}
+		if s.Report != "" {
+			reported++
+			if s.Report == "DELIVRD" {
+				delivered++
+			}
+		}
+	}
+	switch {
+	case reported < len(pr.Segments):
+		return StatusResponded
+	case delivered < reported:
+		return StatusUndelivered
+	}
+	return StatusDelivered
+}
+
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
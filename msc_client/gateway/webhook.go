@@ -0,0 +1,186 @@
+package gateway
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hrygo/log"
+
+	"github.com/hrygo/gosms/smc_client/session"
+)
+
+// 推送状态报告时的签名请求头
+const (
+	HeaderTimestamp = "X-Gosms-Timestamp" // 推送时的 Unix 时间戳（秒）
+	HeaderSignature = "X-Gosms-Signature" // sha256=hex(HMAC-SHA256(secret, timestamp + "." + body))
+)
+
+// Webhook 状态报告推送地址
+type Webhook struct {
+	URL    string `mapstructure:"url" json:"url"`
+	Secret string `mapstructure:"secret" json:"secret,omitempty"` // 签名密钥，为空时不签名
+}
+
+// ReportEvent 推送的状态报告，重试可能导致重复推送，接收方可按 msgId 去重
+type ReportEvent struct {
+	Event       string     `json:"event"` // report 或 late-report（发送结果已从缓存清除，仅有 msgId、report 及 reportTime）
+	QueryId     int64      `json:"queryId,string,omitempty"`
+	ClientMsgId string     `json:"clientMsgId,omitempty"`
+	Phone       string     `json:"phone,omitempty"`
+	SequenceId  uint64     `json:"sequenceId,string,omitempty"`
+	MsgId       string     `json:"msgId"`
+	Report      string     `json:"report"`
+	SendTime    *time.Time `json:"sendTime,omitempty"`
+	ReportTime  *time.Time `json:"reportTime,omitempty"`
+}
+
+// Sign 计算推送内容的签名
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Notifier 将状态报告推送到已注册的 Webhook，失败时按指数退避重试
+type Notifier struct {
+	client   *http.Client
+	retries  int
+	interval time.Duration
+
+	mu    sync.RWMutex
+	hooks map[string]Webhook
+}
+
+// NewNotifier 创建推送器，retries 为失败后的最大重试次数，interval 为首次重试间隔，此后每次加倍
+func NewNotifier(timeout time.Duration, retries int, interval time.Duration) *Notifier {
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	if retries < 0 {
+		retries = 0
+	}
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+	return &Notifier{
+		client:   &http.Client{Timeout: timeout},
+		retries:  retries,
+		interval: interval,
+		hooks:    make(map[string]Webhook),
+	}
+}
+
+// Register 注册 Webhook，相同 URL 时覆盖
+func (n *Notifier) Register(w Webhook) error {
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid webhook url: %s", w.URL)
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.hooks[w.URL] = w
+	return nil
+}
+
+// Unregister 注销 Webhook，返回是否存在
+func (n *Notifier) Unregister(url string) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	_, ok := n.hooks[url]
+	delete(n.hooks, url)
+	return ok
+}
+
+// List 列出已注册的 Webhook，按 URL 排序
+func (n *Notifier) List() []Webhook {
+	n.mu.RLock()
+	ret := make([]Webhook, 0, len(n.hooks))
+	for _, w := range n.hooks {
+		ret = append(ret, w)
+	}
+	n.mu.RUnlock()
+	sort.Slice(ret, func(i, j int) bool { return ret[i].URL < ret[j].URL })
+	return ret
+}
+
+// OnResult 发送结果监听器，收到状态报告时推送，见 session.AddResultListener
+func (n *Notifier) OnResult(event session.Event, r *session.Result) {
+	if event != session.EventReport && event != session.EventLateReport {
+		return
+	}
+	e := &ReportEvent{
+		Event:       event.String(),
+		QueryId:     r.QueryId,
+		ClientMsgId: r.ClientMsgId,
+		Phone:       r.Phone,
+		SequenceId:  r.SequenceId,
+		MsgId:       r.MsgId,
+		Report:      r.Report,
+		SendTime:    timePtr(r.SendTime),
+		ReportTime:  timePtr(r.ReportTime),
+	}
+	n.Notify(e)
+}
+
+// Notify 异步推送到所有已注册的 Webhook
+func (n *Notifier) Notify(e *ReportEvent) {
+	body, err := json.Marshal(e)
+	if err != nil {
+		log.Errorf("[Webhook] Marshal report %s error: %v", e.MsgId, err)
+		return
+	}
+	for _, w := range n.List() {
+		w := w
+		go n.deliver(w, body, 0)
+	}
+}
+
+func (n *Notifier) deliver(w Webhook, body []byte, attempt int) {
+	err := n.post(w, body)
+	if err == nil {
+		return
+	}
+	if attempt >= n.retries {
+		log.Errorf("[Webhook] Push to %s failed after %d attempts: %v, body: %s", w.URL, attempt+1, err, body)
+		return
+	}
+	delay := n.interval << attempt
+	log.Warnf("[Webhook] Push to %s error: %v, retry in %v.", w.URL, err, delay)
+	time.AfterFunc(delay, func() { n.deliver(w, body, attempt+1) })
+}
+
+func (n *Notifier) post(w Webhook, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.Secret != "" {
+		ts := time.Now().Unix()
+		req.Header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+		req.Header.Set(HeaderSignature, Sign(w.Secret, ts, body))
+	}
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("status %s", resp.Status)
+	}
+	return nil
+}
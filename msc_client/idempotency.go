@@ -0,0 +1,116 @@
+package sms
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/hrygo/log"
+
+	"github.com/hrygo/gosms/codec"
+	"github.com/hrygo/gosms/event_manager"
+	"github.com/hrygo/gosms/smc_client/idempotency"
+)
+
+var (
+	idemStore idempotency.Store // 幂等键存储，未启用时为nil
+	idemTTL   time.Duration
+)
+
+// ErrIdempotencyDisabled 未启用幂等发送
+var ErrIdempotencyDisabled = errors.New("idempotency is not enabled")
+
+// IdempotencyConfig 幂等发送配置
+type IdempotencyConfig struct {
+	Enable bool          `mapstructure:"enable"`
+	Store  string        `mapstructure:"store"` // memory、redis
+	TTL    time.Duration `mapstructure:"ttl"`   // 幂等键的保留时间，默认 24h
+	Redis  RedisConfig   `mapstructure:"redis"`
+}
+
+// StartIdempotency 按配置文件启用幂等发送（Idempotency.enable），
+// store 为 redis 时多个客户端进程共享幂等键
+func StartIdempotency() error {
+	var conf IdempotencyConfig
+	if err := ConfigYml.Viper().UnmarshalKey("Idempotency", &conf); err != nil {
+		return err
+	}
+	if !conf.Enable {
+		return nil
+	}
+	var st idempotency.Store
+	switch conf.Store {
+	case "", "memory":
+		st = idempotency.NewMemoryStore()
+	case "redis":
+		client, err := newRedisClient(conf.Redis)
+		if err != nil {
+			return err
+		}
+		st = idempotency.NewRedisStore(client, conf.Redis.KeyPrefix)
+	default:
+		log.Warnf("[Idempotency] Unknown store %s, use memory.", conf.Store)
+		st = idempotency.NewMemoryStore()
+	}
+	SetIdempotencyStore(st, conf.TTL)
+	event_manager.RegisterShutdownHooker("Close_Idempotency", func(args ...any) {
+		_ = st.Close()
+	})
+	log.Infof("[Idempotency] Started with %s store.", conf.Store)
+	return nil
+}
+
+// SetIdempotencyStore 使用自定义的幂等键存储，ttl 为幂等键的保留时间，不大于0时使用 24h
+func SetIdempotencyStore(st idempotency.Store, ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	idemStore, idemTTL = st, ttl
+}
+
+// SendMessageKey 幂等发送：保留时间内使用相同幂等键的调用不再发送，返回首次调用的查询编号且 dup 为 true，
+// 发送结果通过 Query 获取。幂等键存储出错时不发送并返回错误，由调用方重试；
+// 发送出错（如等待可用会话时 ctx 结束）且尚无号码提交网关（或暂存、写入发件箱）时释放幂等键，调用方可使用相同的幂等键重试；
+// 已有号码提交时保留幂等键，返回的查询编号可查询已提交部分的结果。
+func SendMessageKey(key string, m *Message) (queryId int64, dup bool, err error) {
+	return sendMessageKey(context.Background(), key, m, false)
+}
+
+// SendMessageKeyWait 与 SendMessageKey 相同，但号码暂无可用会话时阻塞等待，见 SendMessageWait
+func SendMessageKeyWait(ctx context.Context, key string, m *Message) (queryId int64, dup bool, err error) {
+	return sendMessageKey(ctx, key, m, true)
+}
+
+func sendMessageKey(ctx context.Context, key string, m *Message, wait bool) (queryId int64, dup bool, err error) {
+	if idemStore == nil {
+		return 0, false, ErrIdempotencyDisabled
+	}
+	if key == "" {
+		return 0, false, errors.New("idempotency key is required")
+	}
+	if m == nil || len(m.Phones) < 1 {
+		return
+	}
+	queryId = codec.B64Seq.NextVal()
+	rctx, cancel := context.WithTimeout(ctx, time.Second)
+	old, ok, err := idemStore.Reserve(rctx, key, queryId, idemTTL)
+	cancel()
+	if err != nil {
+		log.Errorf("[Idempotency] Reserve %s error: %v", key, err)
+		return 0, false, err
+	}
+	if !ok {
+		log.Warnf("[Idempotency] Duplicate key %s, query id %d.", key, old)
+		return old, true, nil
+	}
+	accepted, err := sendQuery(ctx, queryId, m, wait)
+	if err != nil && accepted == 0 {
+		// 尚无号码提交网关或写入发件箱，释放幂等键以便调用方重试
+		rctx, cancel = context.WithTimeout(context.Background(), time.Second)
+		if e := idemStore.Release(rctx, key, queryId); e != nil {
+			log.Errorf("[Idempotency] Release %s error: %v", key, e)
+		}
+		cancel()
+	}
+	return queryId, false, err
+}
@@ -0,0 +1,123 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Store 幂等键存储，记录幂等键对应的查询编号，多个客户端进程共享时使用 RedisStore
+type Store interface {
+	// Reserve 幂等键不存在时记录其查询编号并返回 (queryId, true)，ttl 后过期；
+	// 已存在时返回原查询编号及 false
+	Reserve(ctx context.Context, key string, queryId int64, ttl time.Duration) (int64, bool, error)
+	// Release 幂等键仍对应 queryId 时删除，用于发送失败后允许使用相同的幂等键重试
+	Release(ctx context.Context, key string, queryId int64) error
+	Close() error
+}
+
+type memoryEntry struct {
+	queryId int64
+	expire  time.Time
+}
+
+// MemoryStore 进程内的幂等键存储
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+	ops     int
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]memoryEntry)}
+}
+
+// Reserve 每 1024 次操作清理一次过期的幂等键
+func (s *MemoryStore) Reserve(_ context.Context, key string, queryId int64, ttl time.Duration) (int64, bool, error) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ops++; s.ops >= 1024 {
+		s.ops = 0
+		for k, e := range s.entries {
+			if !now.Before(e.expire) {
+				delete(s.entries, k)
+			}
+		}
+	}
+	if e, ok := s.entries[key]; ok && now.Before(e.expire) {
+		return e.queryId, false, nil
+	}
+	s.entries[key] = memoryEntry{queryId: queryId, expire: now.Add(ttl)}
+	return queryId, true, nil
+}
+
+func (s *MemoryStore) Release(_ context.Context, key string, queryId int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.entries[key]; ok && e.queryId == queryId {
+		delete(s.entries, key)
+	}
+	return nil
+}
+
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+// RedisStore 基于 Redis 的幂等键存储，键名均加上 prefix
+type RedisStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+func NewRedisStore(client redis.UniversalClient, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+func (s *RedisStore) Reserve(ctx context.Context, key string, queryId int64, ttl time.Duration) (int64, bool, error) {
+	key = s.prefix + "idem:" + key
+	// 读取已有键时可能恰好过期，重试一次
+	for i := 0; i < 2; i++ {
+		ok, err := s.client.SetNX(ctx, key, queryId, ttl).Result()
+		if err != nil {
+			return 0, false, err
+		}
+		if ok {
+			return queryId, true, nil
+		}
+		v, err := s.client.Get(ctx, key).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return 0, false, err
+		}
+		old, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return 0, false, err
+		}
+		return old, false, nil
+	}
+	return 0, false, errors.New("idempotency: reserve conflict")
+}
+
+// releaseScript 键的值仍为指定的查询编号时删除
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+func (s *RedisStore) Release(ctx context.Context, key string, queryId int64) error {
+	return releaseScript.Run(ctx, s.client, []string{s.prefix + "idem:" + key}, strconv.FormatInt(queryId, 10)).Err()
+}
+
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
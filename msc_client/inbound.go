@@ -0,0 +1,213 @@
+package sms
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hrygo/log"
+
+	"github.com/hrygo/gosms/event_manager"
+	"github.com/hrygo/gosms/smc_client/inbox"
+	"github.com/hrygo/gosms/smc_client/session"
+)
+
+// InboundMessage 上行短信
+type InboundMessage = session.InboundMessage
+
+// InboundFilter 上行短信过滤条件，按扩展子号码前缀或关键字匹配
+type InboundFilter = inbox.Filter
+
+// InboundHandler 上行短信处理器，返回错误时稍后重试，同一消息可能被处理多次
+type InboundHandler func(m *InboundMessage) error
+
+type inboundEntry struct {
+	name    string
+	filter  InboundFilter
+	handler InboundHandler
+}
+
+var (
+	inboundHandlers  []inboundEntry
+	inboundListeners []func(m *InboundMessage)
+	inboundLock      sync.RWMutex
+	inboundQueue     *inbox.Queue
+	inboundSignal    = make(chan struct{}, 1)
+)
+
+// HandleInbound 注册上行短信处理器，name 全局唯一，用于记录处理进度。
+// 处理器在 StartInbound 启动的协程中依次调用，未注册处理器时消息保留在队列中。
+func HandleInbound(name string, filter InboundFilter, h InboundHandler) {
+	inboundLock.Lock()
+	defer inboundLock.Unlock()
+	for _, e := range inboundHandlers {
+		if e.name == name {
+			log.Panicf("Inbound handler \"%s\" already registered!", name)
+		}
+	}
+	inboundHandlers = append(inboundHandlers, inboundEntry{name: name, filter: filter, handler: h})
+	// 分发注册前收到的消息
+	select {
+	case inboundSignal <- struct{}{}:
+	default:
+	}
+}
+
+// AddInboundListener 注册上行短信监听器，会话收到上行短信（应答网关前）时同步回调，不保证送达且可能重复，
+// 耗时操作需自行异步处理。需要可靠处理时使用 HandleInbound。
+func AddInboundListener(l func(m *InboundMessage)) {
+	if l == nil {
+		return
+	}
+	inboundLock.Lock()
+	inboundListeners = append(inboundListeners, l)
+	inboundLock.Unlock()
+	session.SetInboundHook(acceptInbound)
+}
+
+// StartInbound 按配置文件启动上行短信处理（Inbound.enable）：会话收到的上行短信先写入本地队列（Inbound.path）再应答网关，
+// 然后分发给匹配的处理器，处理失败的消息按 Inbound.retry-duration 间隔重试，保证至少处理一次。
+func StartInbound() error {
+	if !ConfigYml.GetBool("Inbound.enable") {
+		return nil
+	}
+	path := ConfigYml.GetString("Inbound.path")
+	if path == "" {
+		path = "data/inbox.db"
+	}
+	q, err := inbox.Open(BasePath + path)
+	if err != nil {
+		return err
+	}
+	inboundQueue = q
+	session.SetInboundHook(acceptInbound)
+
+	retry := ConfigYml.GetDuration("Inbound.retry-duration")
+	if retry <= 0 {
+		retry = 10 * time.Second
+	}
+	go func() {
+		ticker := time.NewTicker(retry)
+		defer ticker.Stop()
+
+		cancel := event_manager.RegisterShutdownHookerAddChan("Stop_InboundDispatcher",
+			func(args ...any) {
+				session.SetInboundHook(nil)
+				ticker.Stop()
+			},
+		)
+		// 处理上次退出时未完成的消息
+		dispatchInbound(false)
+		for {
+			select {
+			case <-cancel:
+				_ = q.Close()
+				return
+			case <-inboundSignal:
+				dispatchInbound(false)
+			case <-ticker.C:
+				dispatchInbound(true)
+			}
+		}
+	}()
+	log.Infof("[Inbound] Started with %s.", path)
+	return nil
+}
+
+// acceptInbound 会话收到上行短信时调用：先处理退订，再写入队列等待分发
+func acceptInbound(m *session.InboundMessage) error {
+	if err := onUnsubscribe(m); err != nil {
+		return err
+	}
+	notifyInbound(m)
+	if inboundQueue == nil {
+		return nil
+	}
+	dup, err := inboundQueue.Push(m)
+	if err != nil {
+		log.Errorf("[Inbound] Save %s error: %v", inbox.Key(m), err)
+		return err
+	}
+	if dup {
+		log.Warnf("[Inbound] Duplicate message %s, ignored.", inbox.Key(m))
+		return nil
+	}
+	select {
+	case inboundSignal <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func notifyInbound(m *InboundMessage) {
+	inboundLock.RLock()
+	ls := inboundListeners
+	inboundLock.RUnlock()
+	for _, l := range ls {
+		func() {
+			defer func() {
+				if p := recover(); p != nil {
+					log.Errorf("[Inbound] Listener panic: %v", p)
+				}
+			}()
+			l(m)
+		}()
+	}
+}
+
+// dispatchInbound 将队列中的消息分发给匹配且尚未处理成功的处理器，retry 为 false 时只处理新消息
+func dispatchInbound(retry bool) {
+	inboundLock.RLock()
+	handlers := inboundHandlers
+	inboundLock.RUnlock()
+	if len(handlers) == 0 {
+		// 尚未注册处理器，消息留待注册后分发
+		return
+	}
+	records, err := inboundQueue.List()
+	if err != nil {
+		log.Errorf("[Inbound] Load messages error: %v", err)
+		return
+	}
+
+	for _, r := range records {
+		if r.Attempts > 0 && !retry {
+			continue
+		}
+		var done []string
+		failed := false
+		for _, e := range handlers {
+			if r.IsDone(e.name) || !e.filter.Match(r.Message) {
+				continue
+			}
+			if err = callInbound(e, r.Message); err != nil {
+				log.Errorf("[Inbound] Handler %s process %s error: %v", e.name, r.Key(), err)
+				failed = true
+				continue
+			}
+			done = append(done, e.name)
+		}
+
+		key := r.Key()
+		if !failed {
+			err = inboundQueue.Delete(key)
+		} else {
+			err = inboundQueue.Update(key, func(r *inbox.Record) {
+				r.Attempts++
+				r.Done = append(r.Done, done...)
+			})
+		}
+		if err != nil {
+			log.Errorf("[Inbound] Update %s error: %v", key, err)
+		}
+	}
+}
+
+func callInbound(e inboundEntry, m *InboundMessage) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return e.handler(m)
+}
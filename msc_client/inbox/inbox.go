@@ -0,0 +1,164 @@
+package inbox
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/hrygo/gosms/smc_client/session"
+)
+
+// ErrNotFound 记录不存在
+var ErrNotFound = errors.New("inbox: record not found")
+
+// Filter 上行短信过滤条件，所有非空条件均满足时匹配
+type Filter struct {
+	SubNo    string   // 扩展子号码前缀
+	Keywords []string // 关键字，短信内容（忽略首尾空白及大小写）以任一关键字开头即匹配
+}
+
+// Match 是否匹配
+func (f Filter) Match(m *session.InboundMessage) bool {
+	if f.SubNo != "" && !strings.HasPrefix(m.SubNo, f.SubNo) {
+		return false
+	}
+	if len(f.Keywords) == 0 {
+		return true
+	}
+	content := strings.ToUpper(strings.TrimSpace(m.Content))
+	for _, kw := range f.Keywords {
+		if kw != "" && strings.HasPrefix(content, strings.ToUpper(kw)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Record 待处理的上行短信
+type Record struct {
+	Message  *session.InboundMessage `json:"message"`
+	Done     []string                `json:"done"`     // 已成功处理的处理器名称
+	Attempts int                     `json:"attempts"` // 处理次数
+}
+
+// Key 记录的存储键
+func (r *Record) Key() string {
+	return Key(r.Message)
+}
+
+// Key 上行短信的存储键，同一账号收到的相同网关消息标识只保存一次
+func Key(m *session.InboundMessage) string {
+	return m.Account + "/" + m.Id
+}
+
+// IsDone 处理器是否已处理
+func (r *Record) IsDone(handler string) bool {
+	for _, d := range r.Done {
+		if d == handler {
+			return true
+		}
+	}
+	return false
+}
+
+var bucket = []byte("inbox")
+
+// Queue 基于 bbolt 的上行短信持久化队列，保证处理器至少处理一次
+type Queue struct {
+	db *bolt.DB
+}
+
+// Open 打开（或创建）队列数据库文件
+func Open(path string) (*Queue, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 3 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucket)
+		return err
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return &Queue{db: db}, nil
+}
+
+// Push 写入上行短信，网关重复推送（消息标识相同）时返回 dup=true
+func (q *Queue) Push(m *session.InboundMessage) (dup bool, err error) {
+	data, err := json.Marshal(&Record{Message: m})
+	if err != nil {
+		return false, err
+	}
+	err = q.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket)
+		key := []byte(Key(m))
+		if b.Get(key) != nil {
+			dup = true
+			return nil
+		}
+		return b.Put(key, data)
+	})
+	return dup, err
+}
+
+// List 按接收时间顺序列出所有待处理的记录
+func (q *Queue) List() ([]*Record, error) {
+	var ret []*Record
+	err := q.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).ForEach(func(_, data []byte) error {
+			r := &Record{}
+			if err := json.Unmarshal(data, r); err != nil {
+				return err
+			}
+			ret = append(ret, r)
+			return nil
+		})
+	})
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Message.ReceiveTime.Before(ret[j].Message.ReceiveTime)
+	})
+	return ret, err
+}
+
+// Update 在事务中修改记录
+func (q *Queue) Update(key string, fn func(r *Record)) error {
+	return q.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket)
+		data := b.Get([]byte(key))
+		if data == nil {
+			return ErrNotFound
+		}
+		r := &Record{}
+		if err := json.Unmarshal(data, r); err != nil {
+			return err
+		}
+		fn(r)
+		data, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(key), data)
+	})
+}
+
+// Delete 删除处理完毕的记录
+func (q *Queue) Delete(key string) error {
+	return q.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Delete([]byte(key))
+	})
+}
+
+func (q *Queue) Close() error {
+	return q.db.Close()
+}
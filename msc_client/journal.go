@@ -0,0 +1,144 @@
+package sms
+
+import (
+	"time"
+
+	"github.com/hrygo/log"
+
+	"github.com/hrygo/gosms/database/mongodb"
+	"github.com/hrygo/gosms/event_manager"
+	"github.com/hrygo/gosms/smc_client/journal"
+	"github.com/hrygo/gosms/smc_client/session"
+)
+
+// journalStore 发送记录存储，未启用时为nil
+var journalStore journal.Store
+
+// StartJournal 按配置文件（Journal.store）启动发送记录持久化及缓存过期检查器：
+// mongo 使用 mongodb 的 smsdb.journal 集合，bolt 使用本地文件 Journal.path；
+// 未配置时，若配置了 Mongo.URI 则使用 mongo，否则仅使用内存缓存。
+func StartJournal() error {
+	storeType := ConfigYml.GetString("Journal.store")
+	if storeType == "" && ConfigYml.GetString("Mongo.URI") != "" {
+		storeType = "mongo"
+	}
+	switch storeType {
+	case "mongo":
+		PersistenceSmsJournal()
+	case "bolt":
+		path := ConfigYml.GetString("Journal.path")
+		if path == "" {
+			path = "data/journal.db"
+		}
+		st, err := journal.OpenBolt(BasePath + path)
+		if err != nil {
+			return err
+		}
+		startJournal(st)
+	default:
+		StartCacheExpireTicker(nil)
+	}
+	return nil
+}
+
+// PersistenceSmsJournal 使用 mongodb 持久化发送记录
+func PersistenceSmsJournal() {
+	mongodb.InitDB(ConfigYml, "Mongo")
+	startJournal(journal.NewMongoStore(mongodb.Collection("smsdb", "journal")))
+}
+
+func startJournal(st journal.Store) {
+	journalStore = st
+	session.AddResultListener(onJournalResult)
+	StartCacheExpireTicker(func(results []any) {
+		_ = AsyncPool().Submit(func() {
+			log.Infof("[Persistence] Save %d send results to journal.", len(results))
+			rs := make([]*session.Result, 0, len(results))
+			for _, r := range results {
+				rs = append(rs, r.(*session.Result))
+			}
+			if err := st.Save(rs); err != nil {
+				log.Errorf("[Persistence] Save send results error: %v", err)
+			}
+		})
+	})
+	startJournalPurgeTicker(st)
+}
+
+func startJournalPurgeTicker(st journal.Store) {
+	retention := ConfigYml.GetDuration("Journal.retention")
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+
+		cancel := event_manager.RegisterShutdownHookerAddChan("Stop_JournalTicker",
+			func(args ...any) {
+				ticker.Stop()
+				_ = st.Close()
+			},
+		)
+		for {
+			select {
+			case <-cancel:
+				return
+			case <-ticker.C:
+				if retention <= 0 {
+					continue
+				}
+				n, err := st.Purge(time.Now().Add(-retention))
+				if err != nil {
+					log.Errorf("[Persistence] Purge journal error: %v", err)
+				} else if n > 0 {
+					log.Infof("[Persistence] Purge %d expired send results.", n)
+				}
+			}
+		}
+	}()
+}
+
+// queryJournal 从发送记录存储中查询
+func queryJournal(queryId int64) []any {
+	if journalStore == nil {
+		return nil
+	}
+	rs, err := journalStore.Query(queryId)
+	if err != nil {
+		log.Errorf("[Persistence] Query %d error: %v", queryId, err)
+		return nil
+	}
+	if len(rs) == 0 {
+		return nil
+	}
+	ret := make([]any, len(rs))
+	for i, r := range rs {
+		ret[i] = r
+	}
+	return ret
+}
+
+// onJournalResult 状态报告晚于缓存过期到达时，更新已持久化的发送记录
+func onJournalResult(event session.Event, r *session.Result) {
+	switch event {
+	case session.EventLateReport:
+	case session.EventReport:
+		// 查询缓存已过期的发送结果可能已经持久化
+		d := ConfigYml.GetDuration("Cache.expire-time")
+		if d == 0 {
+			d = time.Minute
+		}
+		if r.SendTime.Add(d).After(time.Now()) {
+			return
+		}
+	default:
+		return
+	}
+	msgId, report, reportTime := r.MsgId, r.Report, r.ReportTime
+	_ = AsyncPool().Submit(func() {
+		found, err := journalStore.UpdateReport(msgId, report, reportTime)
+		if err != nil {
+			log.Errorf("[Persistence] Update report of %s error: %v", msgId, err)
+		} else if !found {
+			log.Warnf("[Persistence] Report of %s has no send result, dropped.", msgId)
+		}
+	})
+}
@@ -0,0 +1,165 @@
+package journal
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/hrygo/gosms/smc_client/session"
+)
+
+var (
+	resultBucket = []byte("results") // queryId(8字节)+sequenceId(8字节) => Result
+	msgIdBucket  = []byte("msgids")  // msgId => results 中的键
+)
+
+// BoltStore 基于 bbolt 本地文件的发送记录存储
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// OpenBolt 打开（或创建）本地发送记录数据库文件
+func OpenBolt(path string) (*BoltStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 3 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, b := range [][]byte{resultBucket, msgIdBucket} {
+			if _, err := tx.CreateBucketIfNotExists(b); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return &BoltStore{db: db}, nil
+}
+
+func resultKey(queryId int64, sequenceId uint64) []byte {
+	key := make([]byte, 16)
+	binary.BigEndian.PutUint64(key, uint64(queryId))
+	binary.BigEndian.PutUint64(key[8:], sequenceId)
+	return key
+}
+
+func (s *BoltStore) Save(results []*session.Result) error {
+	if len(results) == 0 {
+		return nil
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		rb, mb := tx.Bucket(resultBucket), tx.Bucket(msgIdBucket)
+		for _, r := range results {
+			data, err := json.Marshal(r)
+			if err != nil {
+				return err
+			}
+			key := resultKey(r.QueryId, r.SequenceId)
+			if err = rb.Put(key, data); err != nil {
+				return err
+			}
+			if r.MsgId == "" {
+				continue
+			}
+			if err = mb.Put([]byte(r.MsgId), key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *BoltStore) Query(queryId int64) ([]*session.Result, error) {
+	var ret []*session.Result
+	prefix := resultKey(queryId, 0)[:8]
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(resultBucket).Cursor()
+		for k, v := c.Seek(prefix); k != nil && string(k[:8]) == string(prefix); k, v = c.Next() {
+			r := &session.Result{}
+			if err := json.Unmarshal(v, r); err != nil {
+				return err
+			}
+			ret = append(ret, r)
+		}
+		return nil
+	})
+	return ret, err
+}
+
+func (s *BoltStore) UpdateReport(msgId, report string, reportTime time.Time) (found bool, err error) {
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		rb := tx.Bucket(resultBucket)
+		key := tx.Bucket(msgIdBucket).Get([]byte(msgId))
+		if key == nil {
+			return nil
+		}
+		data := rb.Get(key)
+		if data == nil {
+			return nil
+		}
+		r := &session.Result{}
+		if err := json.Unmarshal(data, r); err != nil {
+			return err
+		}
+		r.Report, r.ReportTime = report, reportTime
+		data, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		found = true
+		return rb.Put(append([]byte(nil), key...), data)
+	})
+	return found, err
+}
+
+func (s *BoltStore) Purge(before time.Time) (int, error) {
+	n := 0
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		rb, mb := tx.Bucket(resultBucket), tx.Bucket(msgIdBucket)
+		// 遍历过程中删除会导致游标跳过元素，先收集再删除
+		var keys, msgIds [][]byte
+		err := rb.ForEach(func(k, v []byte) error {
+			r := &session.Result{}
+			if err := json.Unmarshal(v, r); err != nil {
+				return err
+			}
+			if r.SendTime.Before(before) {
+				keys = append(keys, append([]byte(nil), k...))
+				if r.MsgId != "" {
+					msgIds = append(msgIds, []byte(r.MsgId))
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range keys {
+			if err = rb.Delete(k); err != nil {
+				return err
+			}
+		}
+		for _, k := range msgIds {
+			if err = mb.Delete(k); err != nil {
+				return err
+			}
+		}
+		n = len(keys)
+		return nil
+	})
+	return n, err
+}
+
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,20 @@
+package journal
+
+import (
+	"time"
+
+	"github.com/hrygo/gosms/smc_client/session"
+)
+
+// Store 短信发送记录存储，内存缓存过期后的发送结果写入此处，供查询及状态报告更新
+type Store interface {
+	// Save 保存发送结果
+	Save(results []*session.Result) error
+	// Query 根据查询编号查找发送结果，无记录时返回空
+	Query(queryId int64) ([]*session.Result, error)
+	// UpdateReport 根据msgId更新状态报告，返回是否找到对应的记录
+	UpdateReport(msgId, report string, reportTime time.Time) (bool, error)
+	// Purge 删除发送时间早于 before 的记录，返回删除的条数
+	Purge(before time.Time) (int, error)
+	Close() error
+}
@@ -0,0 +1,82 @@
+package journal
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/hrygo/gosms/smc_client/session"
+)
+
+const timeout = 30 * time.Second
+
+// MongoStore 基于 mongodb 的发送记录存储，字段名采用驱动默认的小写形式（如 queryid、msgid）
+type MongoStore struct {
+	coll *mongo.Collection
+}
+
+// NewMongoStore 创建 mongodb 存储，并创建 queryid、msgid 索引
+func NewMongoStore(coll *mongo.Collection) *MongoStore {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	_, _ = coll.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "queryid", Value: 1}}},
+		{Keys: bson.D{{Key: "msgid", Value: 1}}},
+	})
+	return &MongoStore{coll: coll}
+}
+
+func (s *MongoStore) Save(results []*session.Result) error {
+	if len(results) == 0 {
+		return nil
+	}
+	docs := make([]any, len(results))
+	for i, r := range results {
+		docs[i] = r
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	_, err := s.coll.InsertMany(ctx, docs)
+	return err
+}
+
+func (s *MongoStore) Query(queryId int64) ([]*session.Result, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	cur, err := s.coll.Find(ctx, bson.M{"queryid": queryId})
+	if err != nil {
+		return nil, err
+	}
+	var ret []*session.Result
+	err = cur.All(ctx, &ret)
+	return ret, err
+}
+
+func (s *MongoStore) UpdateReport(msgId, report string, reportTime time.Time) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	ret, err := s.coll.UpdateMany(ctx,
+		bson.M{"msgid": msgId},
+		bson.M{"$set": bson.M{"report": report, "reporttime": reportTime}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return ret.MatchedCount > 0, nil
+}
+
+func (s *MongoStore) Purge(before time.Time) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	ret, err := s.coll.DeleteMany(ctx, bson.M{"sendtime": bson.M{"$lt": before}})
+	if err != nil {
+		return 0, err
+	}
+	return int(ret.DeletedCount), nil
+}
+
+func (s *MongoStore) Close() error {
+	return nil
+}
@@ -0,0 +1,23 @@
+package sms
+
+import (
+	"github.com/hrygo/gosms/codec"
+)
+
+// Message 待发送的短信
+type Message struct {
+	ClientMsgId string             // 客户端消息ID，启用发件箱时用于去重，为空时自动生成
+	Content     string             // 短信内容
+	Phones      []string           // 接收短信的手机号码
+	Account     string             // 指定发送账号或分组，不为空时忽略路由规则
+	Tag         string             // 业务标签，可用于路由规则匹配
+	Options     []codec.OptionFunc // 协议相关的可选项，见 codec.MtOptions
+}
+
+// Sender 消息的发送方子号码（即 SpSubNo）
+func (m *Message) Sender() string {
+	if m == nil || len(m.Options) == 0 {
+		return ""
+	}
+	return codec.LoadMtOptions(m.Options...).SpSubNo
+}
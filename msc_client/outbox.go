@@ -0,0 +1,239 @@
+package sms
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/hrygo/log"
+
+	"github.com/hrygo/gosms/codec"
+	"github.com/hrygo/gosms/event_manager"
+	"github.com/hrygo/gosms/smc_client/outbox"
+	"github.com/hrygo/gosms/smc_client/session"
+)
+
+// box 发件箱，未启用时为nil
+var box outbox.Store
+
+// StartOutbox 按配置文件启用发件箱（Outbox.enable）。
+// 消息在发送前写入本地嵌入式数据库，并记录网关响应及状态报告；
+// 进程重启后，尚未提交或已提交但未收到响应的消息将重新发送，
+// 未能选择到会话的消息按 Outbox.retry-duration 间隔重试。
+func StartOutbox() error {
+	if !ConfigYml.GetBool("Outbox.enable") {
+		return nil
+	}
+	path := ConfigYml.GetString("Outbox.path")
+	if path == "" {
+		path = "data/outbox.db"
+	}
+	st, err := outbox.Open(BasePath + path)
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	SetOutbox(st, ConfigYml.GetDuration("Outbox.retry-duration"))
+	_ = AsyncPool().Submit(func() {
+		replayOutbox(func(r *outbox.Record) bool {
+			return r.CreateTime.Before(start) && (r.State == outbox.StatePending || r.State == outbox.StateSubmitted)
+		})
+	})
+	log.Infof("[Outbox] Started with %s.", path)
+	return nil
+}
+
+// SetOutbox 使用自定义的发件箱存储，retry 为未能提交的消息的重试间隔，不大于0时使用 10s。
+// 只能调用一次，进程退出时关闭存储
+func SetOutbox(st outbox.Store, retry time.Duration) {
+	if retry <= 0 {
+		retry = 10 * time.Second
+	}
+	box = st
+	session.AddResultListener(onOutboxResult)
+	startOutboxTicker(st, retry)
+}
+
+func startOutboxTicker(st outbox.Store, retry time.Duration) {
+	retention := ConfigYml.GetDuration("Outbox.retention")
+	if retention <= 0 {
+		retention = 72 * time.Hour
+	}
+	go func() {
+		ticker := time.NewTicker(retry)
+		defer ticker.Stop()
+
+		cancel := event_manager.RegisterShutdownHookerAddChan("Stop_OutboxTicker",
+			func(args ...any) {
+				ticker.Stop()
+				_ = st.Close()
+			},
+		)
+		for {
+			select {
+			case <-cancel:
+				return
+			case <-ticker.C:
+				// 1. 重试未能提交的消息
+				replayOutbox(func(r *outbox.Record) bool {
+					return r.State == outbox.StatePending && r.UpdateTime.Add(retry).Before(time.Now())
+				})
+				// 2. 清理过期记录
+				n, err := st.Purge(func(r *outbox.Record) bool {
+					return r.UpdateTime.Add(retention).Before(time.Now())
+				})
+				if err != nil {
+					log.Errorf("[Outbox] Purge error: %v", err)
+				} else if n > 0 {
+					log.Infof("[Outbox] Purge %d expired records.", n)
+				}
+			}
+		}
+	}()
+}
+
+// replayOutbox 重新发送满足条件的记录
+func replayOutbox(filter func(r *outbox.Record) bool) {
+	records, err := box.Find(filter)
+	if err != nil {
+		log.Errorf("[Outbox] Load records error: %v", err)
+		return
+	}
+	if len(records) > 0 {
+		log.Warnf("[Outbox] Replay %d records.", len(records))
+	}
+	for _, r := range records {
+		replayRecord(r, filter)
+	}
+}
+
+func replayRecord(r *outbox.Record, filter func(r *outbox.Record) bool) {
+	m := &Message{
+		ClientMsgId: r.ClientMsgId,
+		Content:     r.Content,
+		Phones:      []string{r.Phone},
+		Account:     r.Account,
+		Tag:         r.Tag,
+		Options:     []codec.OptionFunc{codec.WithMtOptions(r.Options)},
+	}
+	if !claim(r.Phone, m) {
+		return
+	}
+	defer unclaim(r.Phone, m)
+
+	// 查找后记录可能已被其他协程发送，更新时再次检查
+	reason, skip := rejectCheck(r.Phone, m), false
+	err := box.Update(r.ClientMsgId, r.Phone, func(r *outbox.Record) {
+		if skip = !filter(r); skip {
+			return
+		}
+		r.Reset()
+		if reason != "" {
+			r.Rejected(reason)
+		}
+	})
+	if err != nil {
+		log.Errorf("[Outbox] Update %s error: %v", r.Key(), err)
+		return
+	}
+	if skip {
+		return
+	}
+	if reason != "" {
+		log.Warnf("[Outbox] Message %s rejected: %s.", r.Key(), reason)
+		saveQueryCache(r.QueryId, []any{rejected(r.Phone, m, reason)})
+		return
+	}
+	if results := sendTo(r.Phone, m); len(results) > 0 {
+		saveQueryCache(r.QueryId, results)
+	}
+}
+
+// inflight 正在发送的发件箱记录，同一记录不会被重试与等待发送（SendMessageWait）并发提交
+var inflight sync.Map
+
+// claim 标记记录正在发送，已被标记时返回false
+func claim(phone string, m *Message) bool {
+	if box == nil {
+		return true
+	}
+	_, loaded := inflight.LoadOrStore(outbox.Key(m.ClientMsgId, phone), struct{}{})
+	return !loaded
+}
+
+// unclaim 清除记录正在发送的标记
+func unclaim(phone string, m *Message) {
+	if box != nil {
+		inflight.Delete(outbox.Key(m.ClientMsgId, phone))
+	}
+}
+
+// enqueue 将消息写入发件箱，返回false表示消息重复，不应再次发送。
+// 未启用发件箱或写入失败时不影响发送。
+func enqueue(queryId int64, phone string, m *Message) bool {
+	if box == nil {
+		return true
+	}
+	r := &outbox.Record{
+		ClientMsgId: m.ClientMsgId,
+		Phone:       phone,
+		QueryId:     queryId,
+		Content:     m.Content,
+		Account:     m.Account,
+		Tag:         m.Tag,
+		Options:     codec.LoadMtOptions(m.Options...),
+	}
+	dup, err := box.Enqueue(r)
+	if err != nil {
+		log.Errorf("[Outbox] Enqueue %s error: %v", r.Key(), err)
+		return true
+	}
+	if dup {
+		log.Warnf("[Outbox] Duplicate message %s, ignored.", r.Key())
+		return false
+	}
+	return true
+}
+
+// reject 标记发件箱中的消息被拒绝发送
+func reject(phone string, m *Message, reason string) {
+	if box == nil {
+		return
+	}
+	err := box.Update(m.ClientMsgId, phone, func(r *outbox.Record) { r.Rejected(reason) })
+	if err != nil && !errors.Is(err, outbox.ErrNotFound) {
+		log.Errorf("[Outbox] Update %s error: %v", outbox.Key(m.ClientMsgId, phone), err)
+	}
+}
+
+// submitted 标记消息已提交到网关
+func submitted(phone string, m *Message, segments int) {
+	if box == nil || segments == 0 {
+		return
+	}
+	err := box.Update(m.ClientMsgId, phone, func(r *outbox.Record) { r.Submitted(segments) })
+	if err != nil && !errors.Is(err, outbox.ErrNotFound) {
+		log.Errorf("[Outbox] Update %s error: %v", outbox.Key(m.ClientMsgId, phone), err)
+	}
+}
+
+// onOutboxResult 异步记录网关响应及状态报告，避免阻塞会话的接收协程
+func onOutboxResult(event session.Event, r *session.Result) {
+	if box == nil || r.ClientMsgId == "" {
+		return
+	}
+	id, phone, result, msgId, report := r.ClientMsgId, r.Phone, r.Result, r.MsgId, r.Report
+	_ = AsyncPool().Submit(func() {
+		err := box.Update(id, phone, func(rec *outbox.Record) {
+			switch event {
+			case session.EventResponse:
+				rec.Responded(result, msgId)
+			case session.EventReport:
+				rec.Reported(report)
+			}
+		})
+		if err != nil && !errors.Is(err, outbox.ErrNotFound) {
+			log.Errorf("[Outbox] Update %s on %s error: %v", outbox.Key(id, phone), event, err)
+		}
+	})
+}
@@ -0,0 +1,140 @@
+package outbox
+
+import (
+	"time"
+
+	"github.com/hrygo/gosms/codec"
+)
+
+// State 发件箱记录的状态
+type State byte
+
+const (
+	StatePending   State = iota // 已入队，尚未提交到网关
+	StateSubmitted              // 已提交到网关，尚未收到全部响应
+	StateResponded              // 已收到网关的全部响应，等待状态报告
+	StateReported               // 已收到全部状态报告
+	StateRejected               // 客户端拒绝发送，见 RejectReason
+	StateHeld                   // 不在允许发送的时段内，暂存至 HoldUntil 后发送
+)
+
+func (s State) String() string {
+	switch s {
+	case StatePending:
+		return "pending"
+	case StateSubmitted:
+		return "submitted"
+	case StateResponded:
+		return "responded"
+	case StateReported:
+		return "reported"
+	case StateRejected:
+		return "rejected"
+	case StateHeld:
+		return "held"
+	}
+	return "unknown"
+}
+
+// Record 发件箱记录，每个客户端消息ID与手机号码对应一条记录
+type Record struct {
+	ClientMsgId string           `json:"clientMsgId"` // 客户端消息ID，用于去重
+	Phone       string           `json:"phone"`       // 手机号
+	QueryId     int64            `json:"queryId"`     // 查询编号
+	Content     string           `json:"content"`     // 短信内容
+	Account     string           `json:"account"`     // 指定的发送账号或分组
+	Tag         string           `json:"tag"`         // 业务标签
+	Options     *codec.MtOptions `json:"options"`     // 协议相关的可选项
+	State       State            `json:"state"`       // 状态
+	Attempts    int              `json:"attempts"`    // 提交次数
+	Segments    int              `json:"segments"`    // 最近一次提交的短信条数（长短信拆分后）
+	Responses   int              `json:"responses"`   // 已收到的网关响应数
+	Reports     int              `json:"reports"`     // 已收到的状态报告数
+	Result      uint32           `json:"result"`      // 网关响应码，多条时取首个非0值
+	MsgIds      []string         `json:"msgIds"`      // 网关返回的msgId
+	Report      string           `json:"report"`      // 状态报告，多条时取首个非 DELIVRD 值
+	Reject      string           `json:"reject"`      // 客户端拒绝发送的原因
+	HoldUntil   time.Time        `json:"holdUntil"`   // 暂存消息的发送时间
+	CreateTime  time.Time        `json:"createTime"`  // 入队时间
+	UpdateTime  time.Time        `json:"updateTime"`  // 最后更新时间
+}
+
+// Key 记录的存储键
+func Key(clientMsgId, phone string) string {
+	return clientMsgId + "/" + phone
+}
+
+// Key 记录的存储键
+func (r *Record) Key() string {
+	return Key(r.ClientMsgId, r.Phone)
+}
+
+// Reset 重新提交前清除上次提交的结果
+func (r *Record) Reset() {
+	r.Segments, r.Responses, r.Reports = 0, 0, 0
+	r.Result, r.Report, r.MsgIds = 0, "", nil
+	r.HoldUntil = time.Time{}
+	r.refresh()
+}
+
+// Submitted 记录提交到网关，segments 为拆分后的短信条数。
+// 网关响应可能先于本方法到达，因此这里不清除响应计数。
+func (r *Record) Submitted(segments int) {
+	r.Attempts++
+	r.Segments = segments
+	r.refresh()
+}
+
+// Responded 记录收到一条网关响应
+func (r *Record) Responded(result uint32, msgId string) {
+	r.Responses++
+	if r.Result == 0 {
+		r.Result = result
+	}
+	r.MsgIds = append(r.MsgIds, msgId)
+	r.refresh()
+}
+
+// Reported 记录收到一条状态报告
+func (r *Record) Reported(stat string) {
+	r.Reports++
+	if r.Report == "" || r.Report == "DELIVRD" {
+		r.Report = stat
+	}
+	r.refresh()
+}
+
+// Rejected 记录客户端拒绝发送
+func (r *Record) Rejected(reason string) {
+	r.Reject = reason
+	r.refresh()
+}
+
+// Held 记录消息暂存至 until 后发送
+func (r *Record) Held(until time.Time) {
+	r.HoldUntil = until
+	r.refresh()
+}
+
+// Finished 是否已完成（客户端或网关拒绝，或已收到全部状态报告）
+func (r *Record) Finished() bool {
+	return r.State == StateReported || r.State == StateRejected || (r.State == StateResponded && r.Result != 0)
+}
+
+// refresh 根据计数重新计算状态，响应可能先于提交标记到达，因此不依赖调用次序
+func (r *Record) refresh() {
+	switch {
+	case r.Reject != "":
+		r.State = StateRejected
+	case r.Segments == 0 && !r.HoldUntil.IsZero():
+		r.State = StateHeld
+	case r.Segments == 0:
+		r.State = StatePending
+	case r.Reports >= r.Segments:
+		r.State = StateReported
+	case r.Responses >= r.Segments:
+		r.State = StateResponded
+	default:
+		r.State = StateSubmitted
+	}
+}
@@ -0,0 +1,165 @@
+package outbox
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// ErrNotFound 记录不存在
+var ErrNotFound = errors.New("outbox: record not found")
+
+// Store 发件箱存储
+type Store interface {
+	// Enqueue 写入一条新记录，同一客户端消息ID与手机号码的记录已存在时返回 dup=true 且不覆盖
+	Enqueue(r *Record) (dup bool, err error)
+	// Get 获取记录
+	Get(clientMsgId, phone string) (*Record, error)
+	// Update 在事务中修改记录
+	Update(clientMsgId, phone string, fn func(r *Record)) error
+	// Find 查找满足条件的记录
+	Find(filter func(r *Record) bool) ([]*Record, error)
+	// Purge 删除满足条件的记录，返回删除的条数
+	Purge(filter func(r *Record) bool) (int, error)
+	Close() error
+}
+
+var bucket = []byte("outbox")
+
+// BoltStore 基于 bbolt 嵌入式数据库的发件箱存储
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// Open 打开（或创建）发件箱数据库文件
+func Open(path string) (*BoltStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 3 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucket)
+		return err
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return &BoltStore{db: db}, nil
+}
+
+func (s *BoltStore) Enqueue(r *Record) (dup bool, err error) {
+	now := time.Now()
+	if r.CreateTime.IsZero() {
+		r.CreateTime = now
+	}
+	r.UpdateTime = now
+	data, err := json.Marshal(r)
+	if err != nil {
+		return false, err
+	}
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket)
+		key := []byte(r.Key())
+		if b.Get(key) != nil {
+			dup = true
+			return nil
+		}
+		return b.Put(key, data)
+	})
+	return dup, err
+}
+
+func (s *BoltStore) Get(clientMsgId, phone string) (*Record, error) {
+	var r *Record
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(bucket).Get([]byte(Key(clientMsgId, phone)))
+		if data == nil {
+			return ErrNotFound
+		}
+		r = &Record{}
+		return json.Unmarshal(data, r)
+	})
+	return r, err
+}
+
+// Update 使用 Batch 合并并发的更新请求，以降低网关响应较多时的磁盘同步开销
+func (s *BoltStore) Update(clientMsgId, phone string, fn func(r *Record)) error {
+	return s.db.Batch(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket)
+		key := []byte(Key(clientMsgId, phone))
+		data := b.Get(key)
+		if data == nil {
+			return ErrNotFound
+		}
+		r := &Record{}
+		if err := json.Unmarshal(data, r); err != nil {
+			return err
+		}
+		fn(r)
+		r.UpdateTime = time.Now()
+		data, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		return b.Put(key, data)
+	})
+}
+
+func (s *BoltStore) Find(filter func(r *Record) bool) ([]*Record, error) {
+	var ret []*Record
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).ForEach(func(_, data []byte) error {
+			r := &Record{}
+			if err := json.Unmarshal(data, r); err != nil {
+				return err
+			}
+			if filter == nil || filter(r) {
+				ret = append(ret, r)
+			}
+			return nil
+		})
+	})
+	return ret, err
+}
+
+func (s *BoltStore) Purge(filter func(r *Record) bool) (int, error) {
+	n := 0
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket)
+		// 遍历过程中删除会导致游标跳过元素，先收集再删除
+		var keys [][]byte
+		err := b.ForEach(func(k, data []byte) error {
+			r := &Record{}
+			if err := json.Unmarshal(data, r); err != nil {
+				return err
+			}
+			if filter == nil || filter(r) {
+				keys = append(keys, append([]byte(nil), k...))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range keys {
+			if err = b.Delete(k); err != nil {
+				return err
+			}
+		}
+		n = len(keys)
+		return nil
+	})
+	return n, err
+}
+
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,114 @@
+package sms
+
+import (
+	"errors"
+	"time"
+
+	"github.com/hrygo/log"
+
+	"github.com/hrygo/gosms/codec"
+	"github.com/hrygo/gosms/smc_client/outbox"
+	"github.com/hrygo/gosms/smc_client/quiet"
+	"github.com/hrygo/gosms/smc_client/schedule"
+)
+
+// quietPolicies 发送时段策略，未启用时为空
+var quietPolicies quiet.Policies
+
+// atTimeZone codec.MtAtTime 固定使用 UTC+8 的时区后缀
+var atTimeZone = time.FixedZone("UTC+8", 8*3600)
+
+// StartQuietHours 按配置文件启用发送时段限制（QuietHours.enable）。
+// 按账号（或运营商、分组）及业务标签匹配首个策略，不在策略允许的时段内时：
+// reject 拒绝发送，RejectReason 为 QUIET_HOURS；
+// hold 暂存到定时任务存储（Schedule.path），到允许发送的时段后自动发送，结果追加到原查询编号下；
+// attime 设置网关定时发送时间（codec.MtAtTime）为下一个允许发送的时刻后立即提交。
+func StartQuietHours() error {
+	if !ConfigYml.GetBool("QuietHours.enable") {
+		return nil
+	}
+	var policies quiet.Policies
+	if err := ConfigYml.Viper().UnmarshalKey("QuietHours.policies", &policies); err != nil {
+		return err
+	}
+	needHold := false
+	for _, p := range policies {
+		if err := p.Compile(); err != nil {
+			return err
+		}
+		needHold = needHold || p.Action == quiet.ActionHold
+	}
+	if needHold {
+		if err := startScheduler(); err != nil {
+			return err
+		}
+	}
+	quietPolicies = policies
+	log.Infof("[QuietHours] Started with %d policies.", len(policies))
+	return nil
+}
+
+// quietCheck 返回号码当前不允许发送时适用的策略及下一个允许发送的时刻，允许发送时返回nil
+func quietCheck(phone string, m *Message) (*quiet.Policy, time.Time) {
+	if len(quietPolicies) == 0 {
+		return nil, time.Time{}
+	}
+	target := CurrentRouter().Route(phone, m)
+	p := quietPolicies.Find(routeNames(target), m.Tag)
+	now := time.Now()
+	if p == nil || p.Allowed(now) {
+		return nil, time.Time{}
+	}
+	return p, p.NextOpen(now)
+}
+
+// routeNames 路由结果对应的账号名及其所属的运营商、分组名
+func routeNames(target string) []string {
+	if target == "" {
+		return nil
+	}
+	names := []string{target}
+	for _, a := range ResolveAccounts(target) {
+		names = append(names, a.Name, a.ISP)
+		names = append(names, a.Groups...)
+	}
+	return names
+}
+
+// withAtTime 复制消息并设置网关定时发送时间
+func withAtTime(m *Message, t time.Time) *Message {
+	c := *m
+	c.Options = append(append([]codec.OptionFunc(nil), m.Options...), codec.MtAtTime(t.In(atTimeZone)))
+	return &c
+}
+
+// hold 暂存已入队的消息，until 后由定时任务发送
+func hold(queryId int64, phone string, m *Message, until time.Time) error {
+	if jobs == nil {
+		return ErrSchedulerDisabled
+	}
+	j := &schedule.Job{
+		Id:          "hold/" + outbox.Key(m.ClientMsgId, phone),
+		QueryId:     queryId,
+		FireTime:    until,
+		ClientMsgId: m.ClientMsgId,
+		Content:     m.Content,
+		Phones:      []string{phone},
+		Account:     m.Account,
+		Tag:         m.Tag,
+		Options:     codec.LoadMtOptions(m.Options...),
+		Reason:      quiet.Reason,
+	}
+	if err := jobs.Put(j); err != nil {
+		log.Errorf("[QuietHours] Hold %s error: %v", j.Id, err)
+		return err
+	}
+	if box != nil {
+		err := box.Update(m.ClientMsgId, phone, func(r *outbox.Record) { r.Held(until) })
+		if err != nil && !errors.Is(err, outbox.ErrNotFound) {
+			log.Errorf("[QuietHours] Update %s error: %v", outbox.Key(m.ClientMsgId, phone), err)
+		}
+	}
+	log.Infof("[QuietHours] Message %s to %s held until %s.", m.ClientMsgId, phone, until.Format(time.RFC3339))
+	return nil
+}
@@ -0,0 +1,192 @@
+package quiet
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Reason 因不在允许发送的时段内被拒绝的原因，见 session.Result.RejectReason
+const Reason = "QUIET_HOURS"
+
+// Action 不在允许发送的时段内时的处理方式
+type Action string
+
+const (
+	ActionReject Action = "reject" // 拒绝发送
+	ActionHold   Action = "hold"   // 暂存，到允许发送的时段后自动发送
+	ActionAtTime Action = "attime" // 设置定时发送时间（codec.MtAtTime）后立即提交网关
+)
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+	"7": time.Sunday, "1": time.Monday, "2": time.Tuesday, "3": time.Wednesday,
+	"4": time.Thursday, "5": time.Friday, "6": time.Saturday,
+}
+
+// span 一天内允许发送的时段，单位为分钟，[from, to)
+type span struct {
+	from, to int
+}
+
+// Policy 发送时段策略，所有非空条件均满足时命中策略
+type Policy struct {
+	Name     string   `mapstructure:"name"`
+	Accounts []string `mapstructure:"accounts"`  // 账号、运营商或分组名
+	Tags     []string `mapstructure:"tags"`      // 业务标签（短信类别），与 Message.Tag 相等
+	Hours    []string `mapstructure:"hours"`     // 允许发送的时段，如 08:00-21:00，结束时间早于开始时间表示跨零点；为空表示全天
+	Weekdays []string `mapstructure:"weekdays"`  // 允许发送的星期，如 mon-fri、sat，或 1-7（7 为周日）；为空表示每天
+	TimeZone string   `mapstructure:"time-zone"` // 时区，如 Asia/Shanghai，默认本地时区
+	Action   Action   `mapstructure:"action"`    // 时段外的处理方式，默认 reject
+
+	loc   *time.Location
+	spans []span
+	days  [7]bool
+}
+
+// Compile 校验策略并解析时段、星期及时区
+func (p *Policy) Compile() error {
+	switch p.Action {
+	case "":
+		p.Action = ActionReject
+	case ActionReject, ActionHold, ActionAtTime:
+	default:
+		return fmt.Errorf("quiet policy %s: unknown action %s", p.Name, p.Action)
+	}
+	p.loc = time.Local
+	if p.TimeZone != "" {
+		loc, err := time.LoadLocation(p.TimeZone)
+		if err != nil {
+			return fmt.Errorf("quiet policy %s: %v", p.Name, err)
+		}
+		p.loc = loc
+	}
+
+	p.spans = p.spans[:0]
+	for _, h := range p.Hours {
+		from, to, ok := strings.Cut(h, "-")
+		f, err1 := parseClock(from)
+		t, err2 := parseClock(to)
+		if !ok || err1 != nil || err2 != nil || f == t {
+			return fmt.Errorf("quiet policy %s: invalid hours %q", p.Name, h)
+		}
+		if f < t {
+			p.spans = append(p.spans, span{f, t})
+		} else {
+			// 跨零点拆分为两段
+			p.spans = append(p.spans, span{f, 24 * 60}, span{0, t})
+		}
+	}
+	if len(p.Hours) == 0 {
+		p.spans = append(p.spans, span{0, 24 * 60})
+	}
+	sort.Slice(p.spans, func(i, j int) bool { return p.spans[i].from < p.spans[j].from })
+
+	p.days = [7]bool{}
+	for _, d := range p.Weekdays {
+		from, to, isRange := strings.Cut(strings.ToLower(strings.TrimSpace(d)), "-")
+		f, ok1 := weekdayNames[strings.TrimSpace(from)]
+		t, ok2 := f, true
+		if isRange {
+			t, ok2 = weekdayNames[strings.TrimSpace(to)]
+		}
+		if !ok1 || !ok2 {
+			return fmt.Errorf("quiet policy %s: invalid weekday %q", p.Name, d)
+		}
+		for w := f; ; w = (w + 1) % 7 {
+			p.days[w] = true
+			if w == t {
+				break
+			}
+		}
+	}
+	if len(p.Weekdays) == 0 {
+		p.days = [7]bool{true, true, true, true, true, true, true}
+	}
+	return nil
+}
+
+// parseClock 解析 HH:MM 格式的时刻，返回距零点的分钟数，允许 24:00
+func parseClock(s string) (int, error) {
+	hh, mm, ok := strings.Cut(strings.TrimSpace(s), ":")
+	h, err1 := strconv.Atoi(hh)
+	m, err2 := strconv.Atoi(mm)
+	if !ok || err1 != nil || err2 != nil || h < 0 || m < 0 || m > 59 || h*60+m > 24*60 {
+		return 0, fmt.Errorf("invalid clock %q", s)
+	}
+	return h*60 + m, nil
+}
+
+// Match 策略是否适用于消息，names 为消息路由到的账号及其所属的运营商、分组名
+func (p *Policy) Match(names []string, tag string) bool {
+	if len(p.Tags) > 0 && !contains(p.Tags, tag) {
+		return false
+	}
+	if len(p.Accounts) == 0 {
+		return true
+	}
+	for _, n := range names {
+		if contains(p.Accounts, n) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Allowed t 是否在允许发送的时段内，跨零点的时段按各自所在的日期判断星期
+func (p *Policy) Allowed(t time.Time) bool {
+	t = t.In(p.loc)
+	if !p.days[t.Weekday()] {
+		return false
+	}
+	m := t.Hour()*60 + t.Minute()
+	for _, s := range p.spans {
+		if m >= s.from && m < s.to {
+			return true
+		}
+	}
+	return false
+}
+
+// NextOpen t 之后（含 t）最近的允许发送时刻，策略不允许任何时段发送时返回零值
+func (p *Policy) NextOpen(t time.Time) time.Time {
+	if p.Allowed(t) {
+		return t
+	}
+	local := t.In(p.loc)
+	y, mon, d := local.Date()
+	for i := 0; i <= 7; i++ {
+		for _, s := range p.spans {
+			c := time.Date(y, mon, d+i, s.from/60, s.from%60, 0, 0, p.loc)
+			if c.After(t) && p.Allowed(c) {
+				return c
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// Policies 按顺序匹配的策略列表
+type Policies []*Policy
+
+// Find 返回首个适用于消息的策略，没有时返回nil
+func (ps Policies) Find(names []string, tag string) *Policy {
+	for _, p := range ps {
+		if p.Match(names, tag) {
+			return p
+		}
+	}
+	return nil
+}
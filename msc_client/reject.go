@@ -0,0 +1,36 @@
+package sms
+
+import (
+	"errors"
+	"time"
+
+	"github.com/hrygo/gosms/smc_client/freqcap"
+	"github.com/hrygo/gosms/smc_client/quiet"
+	"github.com/hrygo/gosms/smc_client/session"
+)
+
+// 客户端拒绝发送的原因，见 session.Result.RejectReason
+const (
+	RejectSuppressed = "SUPPRESSED"      // 号码在退订名单中
+	RejectFrequency  = freqcap.Frequency // 超过单号码发送频率限制
+	RejectDuplicate  = freqcap.Duplicate // 相同内容重复发送给同一号码
+	RejectQuietHours = quiet.Reason      // 不在允许发送的时段内
+	RejectDropped    = "DROPPED"         // 无可用会话且未启用发件箱，消息已丢弃
+	RejectNoRoute    = "NO_ROUTE"        // 号码没有可用的发送账号（或分组）
+)
+
+// ErrUnsubscribeDisabled 未启用退订处理
+var ErrUnsubscribeDisabled = errors.New("unsubscribe is not enabled")
+
+// rejectCheck 发送前检查，返回拒绝发送的原因，为空表示允许发送
+func rejectCheck(phone string, _ *Message) string {
+	if IsSuppressed(phone) {
+		return RejectSuppressed
+	}
+	return ""
+}
+
+// rejected 构造被拒绝发送的结果
+func rejected(phone string, m *Message, reason string) *session.Result {
+	return &session.Result{ClientMsgId: m.ClientMsgId, Phone: phone, RejectReason: reason, SendTime: time.Now()}
+}
@@ -0,0 +1,150 @@
+package route
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hrygo/log"
+
+	"github.com/hrygo/gosms/event_manager"
+)
+
+// Table 从文本文件加载的路由表，文件修改后可自动重新加载。
+// 文件每行一条记录，格式为 "号码(或号段) 账号"，以空白或逗号分隔，# 开头为注释。
+// 号段表（prefix=true）按最长前缀匹配，携号转网表（prefix=false）按号码精确匹配。
+type Table struct {
+	sync.RWMutex
+	path    string
+	prefix  bool
+	modTime time.Time
+	trie    *Trie
+	exact   map[string]string
+}
+
+// NewSegmentTable 创建号段表，按最长前缀匹配
+func NewSegmentTable(path string) *Table {
+	return &Table{path: path, prefix: true, trie: NewTrie()}
+}
+
+// NewPortabilityTable 创建携号转网表，按号码精确匹配
+func NewPortabilityTable(path string) *Table {
+	return &Table{path: path, exact: make(map[string]string)}
+}
+
+// Load 加载（或重新加载）路由表文件，加载失败时保留原有数据
+func (t *Table) Load() error {
+	fi, err := os.Stat(t.path)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(t.path)
+	if err != nil {
+		return err
+	}
+	defer func(f *os.File) {
+		_ = f.Close()
+	}(f)
+
+	trie := NewTrie()
+	exact := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.FieldsFunc(text, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t'
+		})
+		if len(fields) < 2 {
+			return fmt.Errorf("%s:%d: expect \"<number> <account>\", got %q", t.path, line, text)
+		}
+		key := Normalize(fields[0])
+		if t.prefix {
+			trie.Insert(key, fields[1])
+		} else {
+			exact[key] = fields[1]
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return fmt.Errorf("%s: %v", t.path, err)
+	}
+
+	t.Lock()
+	t.trie, t.exact = trie, exact
+	t.modTime = fi.ModTime()
+	t.Unlock()
+	log.Infof("[Route] Load %s success, %d records.", t.path, t.Len())
+	return nil
+}
+
+// Lookup 查找号码对应的账号，未匹配时返回空字符串
+func (t *Table) Lookup(phone string) string {
+	t.RLock()
+	defer t.RUnlock()
+	if t.prefix {
+		return t.trie.Lookup(phone)
+	}
+	return t.exact[Normalize(phone)]
+}
+
+// Len 记录数
+func (t *Table) Len() int {
+	t.RLock()
+	defer t.RUnlock()
+	if t.prefix {
+		return t.trie.Len()
+	}
+	return len(t.exact)
+}
+
+// Watch 按 d 指定的间隔检查文件修改时间，文件变化时重新加载
+func (t *Table) Watch(d time.Duration) {
+	if d <= 0 {
+		d = 30 * time.Second
+	}
+	go func() {
+		ticker := time.NewTicker(d)
+		defer ticker.Stop()
+
+		cancel := event_manager.RegisterShutdownHookerAddChan(fmt.Sprintf("Stop_RouteTableWatcher_%p", t),
+			func(args ...any) { ticker.Stop() },
+		)
+		// 文件持续不可访问或内容有误时只输出一次错误日志
+		missing := false
+		var failed time.Time
+		for {
+			select {
+			case <-cancel:
+				return
+			case <-ticker.C:
+				fi, err := os.Stat(t.path)
+				if err != nil {
+					if !missing {
+						log.Errorf("[Route] Stat %s error: %v", t.path, err)
+					}
+					missing = true
+					continue
+				}
+				missing = false
+				t.RLock()
+				changed := !fi.ModTime().Equal(t.modTime)
+				t.RUnlock()
+				if !changed || fi.ModTime().Equal(failed) {
+					continue
+				}
+				log.Warnf("[Route] %s changed, reload!", t.path)
+				if err = t.Load(); err != nil {
+					log.Errorf("[Route] Reload error: %v", err)
+					failed = fi.ModTime()
+				}
+			}
+		}
+	}()
+}
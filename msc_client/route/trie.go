@@ -0,0 +1,100 @@
+package route
+
+import (
+	"strings"
+	"sync"
+)
+
+// Trie 号段前缀树，按最长前缀匹配手机号码所属的账号（或运营商）
+type Trie struct {
+	sync.RWMutex
+	root *node
+	size int
+}
+
+type node struct {
+	children map[rune]*node
+	value    string
+}
+
+func NewTrie() *Trie {
+	return &Trie{root: &node{}}
+}
+
+// Insert 插入号段前缀及对应的值，已存在的前缀会被覆盖
+func (t *Trie) Insert(prefix, value string) {
+	t.Lock()
+	defer t.Unlock()
+	n := t.root
+	for _, r := range prefix {
+		if n.children == nil {
+			n.children = make(map[rune]*node)
+		}
+		child, ok := n.children[r]
+		if !ok {
+			child = &node{}
+			n.children[r] = child
+		}
+		n = child
+	}
+	if n.value == "" {
+		t.size++
+	}
+	n.value = value
+}
+
+// Lookup 按最长前缀匹配查找手机号码对应的值，未匹配时返回空字符串
+func (t *Trie) Lookup(phone string) string {
+	t.RLock()
+	defer t.RUnlock()
+	var ret string
+	n := t.root
+	for _, r := range Normalize(phone) {
+		child, ok := n.children[r]
+		if !ok {
+			break
+		}
+		n = child
+		if n.value != "" {
+			ret = n.value
+		}
+	}
+	return ret
+}
+
+// Len 已插入的号段数
+func (t *Trie) Len() int {
+	t.RLock()
+	defer t.RUnlock()
+	return t.size
+}
+
+// Normalize 规范化手机号码：
+// 去除空格及连字符；
+// 国内号码去除 +86、0086、86 前缀，返回11位号码；
+// 国际号码统一采用 + 开头的形式，如 00852xxx 转为 +852xxx。
+func Normalize(phone string) string {
+	phone = strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' {
+			return -1
+		}
+		return r
+	}, phone)
+
+	switch {
+	case strings.HasPrefix(phone, "+86"):
+		return phone[3:]
+	case strings.HasPrefix(phone, "0086"):
+		return phone[4:]
+	case strings.HasPrefix(phone, "00"):
+		return "+" + phone[2:]
+	case strings.HasPrefix(phone, "86") && len(phone) == 13:
+		return phone[2:]
+	}
+	return phone
+}
+
+// IsInternational 是否为国际号码（规范化后以 + 开头）
+func IsInternational(phone string) bool {
+	return strings.HasPrefix(Normalize(phone), "+")
+}
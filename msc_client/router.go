@@ -0,0 +1,185 @@
+package sms
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/hrygo/log"
+
+	"github.com/hrygo/gosms/smc_client/route"
+)
+
+// Router 路由器，为消息中的手机号码选择发送账号。
+// 返回账号名称或分组名称（如运营商名称 cmpp、sgip、smgp 即为分组名），返回空字符串表示无可用路由。
+// 分组内的多个账号按权重负载均衡，见 ResolveAccounts。
+type Router interface {
+	Route(phone string, m *Message) string
+}
+
+// RouterFunc 函数形式的路由器
+type RouterFunc func(phone string, m *Message) string
+
+func (f RouterFunc) Route(phone string, m *Message) string {
+	return f(phone, m)
+}
+
+// Routers 路由链，依次尝试各路由器，返回首个非空的结果
+type Routers []Router
+
+func (rs Routers) Route(phone string, m *Message) string {
+	for _, r := range rs {
+		if r == nil {
+			continue
+		}
+		if name := r.Route(phone, m); name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// Lookup 仅依据手机号码查找账号的路由表，如 route.Table、route.Trie
+type Lookup interface {
+	Lookup(phone string) string
+}
+
+// LookupRouter 将路由表适配为路由器
+func LookupRouter(l Lookup) Router {
+	return RouterFunc(func(phone string, _ *Message) string {
+		return l.Lookup(phone)
+	})
+}
+
+// OverrideRouter 消息指定了发送账号（或分组）时，直接使用该账号
+var OverrideRouter Router = RouterFunc(func(_ string, m *Message) string {
+	if m == nil {
+		return ""
+	}
+	return m.Account
+})
+
+// RouteRule 路由规则，所有非空条件均满足时命中规则
+type RouteRule struct {
+	Tag     string `mapstructure:"tag"`     // 业务标签，与 Message.Tag 相等
+	Sender  string `mapstructure:"sender"`  // 发送方子号码前缀，匹配 codec.MtSpSubNo 设置的值
+	Account string `mapstructure:"account"` // 命中规则时使用的账号或分组
+}
+
+func (r RouteRule) match(m *Message) bool {
+	if r.Account == "" || (r.Tag == "" && r.Sender == "") {
+		return false
+	}
+	if r.Tag != "" && (m == nil || m.Tag != r.Tag) {
+		return false
+	}
+	if r.Sender != "" && !strings.HasPrefix(m.Sender(), r.Sender) {
+		return false
+	}
+	return true
+}
+
+// RuleRouter 按照业务标签、发送方子号码等规则路由
+type RuleRouter []RouteRule
+
+func (rr RuleRouter) Route(_ string, m *Message) string {
+	for _, r := range rr {
+		if r.match(m) {
+			return r.Account
+		}
+	}
+	return ""
+}
+
+// RegexRouter 兼容旧版配置，按各运营商配置的 segment 正则表达式路由
+type RegexRouter map[string]*regexp.Regexp
+
+func (rr RegexRouter) Route(phone string, _ *Message) string {
+	phone = route.Normalize(phone)
+	for _, isp := range ISPS {
+		if re, ok := rr[isp]; ok && re.MatchString(phone) {
+			return isp
+		}
+	}
+	return ""
+}
+
+var (
+	router     Router
+	routerLock sync.RWMutex
+)
+
+// SetRouter 替换默认路由器
+func SetRouter(r Router) {
+	routerLock.Lock()
+	defer routerLock.Unlock()
+	router = r
+}
+
+// CurrentRouter 获取当前使用的路由器，未设置时按配置文件创建默认路由器
+func CurrentRouter() Router {
+	routerLock.RLock()
+	r := router
+	routerLock.RUnlock()
+	if r != nil {
+		return r
+	}
+
+	routerLock.Lock()
+	defer routerLock.Unlock()
+	if router == nil {
+		router = NewDefaultRouter()
+	}
+	return router
+}
+
+// NewDefaultRouter 按配置文件创建默认路由链：
+// 消息指定账号 > 路由规则 > 携号转网表 > 号段表 > 各运营商 segment 正则表达式
+func NewDefaultRouter() Router {
+	rs := Routers{OverrideRouter}
+
+	var rules RuleRouter
+	if err := ConfigYml.Viper().UnmarshalKey("Route.rules", &rules); err != nil {
+		log.Errorf("[Route] Route.rules config error: %v", err)
+	}
+	if len(rules) > 0 {
+		rs = append(rs, rules)
+	}
+
+	d := ConfigYml.GetDuration("Route.reload-duration")
+	if path := ConfigYml.GetString("Route.portability-file"); path != "" {
+		mnp := route.NewPortabilityTable(BasePath + path)
+		// 首次加载失败时不启动文件监视，避免每次检查都输出错误日志
+		if err := mnp.Load(); err != nil {
+			log.Errorf("[Route] Load portability table error: %v", err)
+		} else {
+			mnp.Watch(d)
+		}
+		rs = append(rs, LookupRouter(mnp))
+	}
+	if path := ConfigYml.GetString("Route.segment-file"); path != "" {
+		seg := route.NewSegmentTable(BasePath + path)
+		// 首次加载失败时不启动文件监视，避免每次检查都输出错误日志
+		if err := seg.Load(); err != nil {
+			log.Errorf("[Route] Load segment table error: %v", err)
+		} else {
+			seg.Watch(d)
+		}
+		rs = append(rs, LookupRouter(seg))
+	}
+
+	regex := make(RegexRouter)
+	for _, isp := range ISPS {
+		segment := ConfigYml.GetString(isp + ".segment")
+		if segment == "" {
+			continue
+		}
+		re, err := regexp.Compile(segment)
+		if err != nil {
+			log.Error(err.Error())
+			continue
+		}
+		regex[isp] = re
+	}
+	return append(rs, regex)
+}
@@ -0,0 +1,118 @@
+package rpc
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/hrygo/log"
+
+	"github.com/hrygo/gosms/smc_client"
+	"github.com/hrygo/gosms/smc_client/rpc/smspb"
+	"github.com/hrygo/gosms/smc_client/session"
+)
+
+// subscription 一个 Subscribe 调用的订阅条件及事件缓冲
+type subscription struct {
+	accounts map[string]bool
+	subNo    string
+	types    map[smspb.EventType]bool
+	events   chan *smspb.Event
+	dropped  uint64
+}
+
+func newSubscription(req *smspb.SubscribeRequest, size int) *subscription {
+	s := &subscription{subNo: req.SubNo, events: make(chan *smspb.Event, size)}
+	if len(req.Accounts) > 0 {
+		s.accounts = make(map[string]bool, len(req.Accounts))
+		for _, a := range req.Accounts {
+			s.accounts[strings.ToLower(a)] = true
+		}
+	}
+	if len(req.Types) > 0 {
+		s.types = make(map[smspb.EventType]bool, len(req.Types))
+		for _, t := range req.Types {
+			s.types[t] = true
+		}
+	}
+	return s
+}
+
+// Match 事件是否满足订阅条件，未设置的条件不限
+func (s *subscription) Match(t smspb.EventType, account, subNo string) bool {
+	if s.types != nil && !s.types[t] {
+		return false
+	}
+	if s.accounts != nil && !s.accounts[strings.ToLower(account)] {
+		return false
+	}
+	return strings.HasPrefix(subNo, s.subNo)
+}
+
+// broker 将发送结果及上行短信分发给订阅者，订阅者缓冲已满时丢弃事件
+type broker struct {
+	mu   sync.RWMutex
+	subs map[*subscription]struct{}
+}
+
+func newBroker() *broker {
+	return &broker{subs: make(map[*subscription]struct{})}
+}
+
+func (b *broker) add(s *subscription) {
+	b.mu.Lock()
+	b.subs[s] = struct{}{}
+	b.mu.Unlock()
+}
+
+func (b *broker) remove(s *subscription) {
+	b.mu.Lock()
+	delete(b.subs, s)
+	b.mu.Unlock()
+}
+
+func (b *broker) publish(t smspb.EventType, account, subNo string, build func() *smspb.Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	var e *smspb.Event
+	for s := range b.subs {
+		if !s.Match(t, account, subNo) {
+			continue
+		}
+		if e == nil {
+			e = build()
+		}
+		select {
+		case s.events <- e:
+		default:
+			// 按 2 的幂次记录日志，避免持续丢弃时刷屏
+			if n := atomic.AddUint64(&s.dropped, 1); n&(n-1) == 0 {
+				log.Warnf("[Rpc] Subscriber too slow, %d events dropped.", n)
+			}
+		}
+	}
+}
+
+func (b *broker) onResult(event session.Event, r *session.Result) {
+	var t smspb.EventType
+	switch event {
+	case session.EventResponse:
+		t = smspb.EventType_EVENT_TYPE_RESPONSE
+	case session.EventReport:
+		t = smspb.EventType_EVENT_TYPE_REPORT
+	case session.EventLateReport:
+		t = smspb.EventType_EVENT_TYPE_LATE_REPORT
+	default:
+		return
+	}
+	b.publish(t, r.Account, r.SpSubNo, func() *smspb.Event {
+		return &smspb.Event{Type: t, Payload: &smspb.Event_Result{Result: toResult(r)}}
+	})
+}
+
+func (b *broker) onInbound(m *sms.InboundMessage) {
+	t := smspb.EventType_EVENT_TYPE_INBOUND
+	b.publish(t, m.Account, m.SubNo, func() *smspb.Event {
+		return &smspb.Event{Type: t, Payload: &smspb.Event_Inbound{Inbound: toInbound(m)}}
+	})
+}
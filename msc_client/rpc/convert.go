@@ -0,0 +1,125 @@
+package rpc
+
+import (
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/hrygo/gosms/smc_client"
+	"github.com/hrygo/gosms/smc_client/gateway"
+	"github.com/hrygo/gosms/smc_client/rpc/smspb"
+	"github.com/hrygo/gosms/smc_client/session"
+)
+
+// toMessage 校验请求并转换为 sms.Message，校验规则与 HTTP 网关相同
+func toMessage(req *smspb.SendRequest) (*sms.Message, error) {
+	r := &gateway.SendRequest{
+		ClientMsgId: req.ClientMsgId,
+		Content:     req.Content,
+		TemplateId:  req.TemplateId,
+		Phones:      req.Phones,
+		Account:     req.Account,
+		Tag:         req.Tag,
+	}
+	if len(req.Vars) > 0 {
+		r.Vars = make(map[string]any, len(req.Vars))
+		for k, v := range req.Vars {
+			r.Vars[k] = v
+		}
+	}
+	if o := req.Options; o != nil {
+		r.Options = &gateway.Options{
+			NeedReport:      uint8Ptr(o.NeedReport),
+			MsgLevel:        uint8Ptr(o.MsgLevel),
+			AtTime:          o.AtTime,
+			ValidTime:       o.ValidTime,
+			SpSubNo:         o.SpSubNo,
+			ServiceId:       o.ServiceId,
+			LinkID:          o.LinkId,
+			FeeUsertype:     uint8Ptr(o.FeeUsertype),
+			FeeTerminalType: uint8Ptr(o.FeeTerminalType),
+			FeeTerminalId:   o.FeeTerminalId,
+			FeeType:         o.FeeType,
+			FeeCode:         o.FeeCode,
+		}
+	}
+	return r.Message()
+}
+
+// uint8Ptr 转换可选字段，超出 uint8 范围时取 0xff 以便校验失败
+func uint8Ptr(v *uint32) *uint8 {
+	if v == nil {
+		return nil
+	}
+	u := uint8(0xff)
+	if *v <= 0xff {
+		u = uint8(*v)
+	}
+	return &u
+}
+
+func toSendResponse(resp *gateway.SendResponse) *smspb.SendResponse {
+	ret := &smspb.SendResponse{QueryId: resp.QueryId, ClientMsgId: resp.ClientMsgId, Duplicate: resp.Duplicate}
+	for _, p := range resp.Phones {
+		pr := &smspb.PhoneResult{Phone: p.Phone, Status: p.Status, RejectReason: p.RejectReason}
+		for _, s := range p.Segments {
+			pr.Segments = append(pr.Segments, &smspb.Segment{
+				SequenceId:   s.SequenceId,
+				Result:       s.Result,
+				MsgId:        s.MsgId,
+				Report:       s.Report,
+				SendTime:     timestampPtr(s.SendTime),
+				ResponseTime: timestampPtr(s.ResponseTime),
+				ReportTime:   timestampPtr(s.ReportTime),
+			})
+		}
+		ret.Phones = append(ret.Phones, pr)
+	}
+	return ret
+}
+
+func toResult(r *session.Result) *smspb.Result {
+	return &smspb.Result{
+		QueryId:      r.QueryId,
+		ClientMsgId:  r.ClientMsgId,
+		Phone:        r.Phone,
+		Account:      r.Account,
+		SpSubNo:      r.SpSubNo,
+		SequenceId:   r.SequenceId,
+		Result:       r.Result,
+		MsgId:        r.MsgId,
+		Report:       r.Report,
+		SendTime:     timestamp(r.SendTime),
+		ResponseTime: timestamp(r.ResponseTime),
+		ReportTime:   timestamp(r.ReportTime),
+	}
+}
+
+func toInbound(m *sms.InboundMessage) *smspb.InboundMessage {
+	return &smspb.InboundMessage{
+		Id:          m.Id,
+		Isp:         m.ISP,
+		Account:     m.Account,
+		Phone:       m.Phone,
+		DestId:      m.DestId,
+		SubNo:       m.SubNo,
+		Content:     m.Content,
+		ServiceId:   m.ServiceId,
+		LinkId:      m.LinkID,
+		ReceiveTime: timestamp(m.ReceiveTime),
+	}
+}
+
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func timestampPtr(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
@@ -0,0 +1,208 @@
+package rpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+
+	"github.com/hrygo/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/hrygo/gosms/smc_client"
+	"github.com/hrygo/gosms/smc_client/gateway"
+	"github.com/hrygo/gosms/smc_client/rpc/smspb"
+	"github.com/hrygo/gosms/smc_client/session"
+)
+
+// 每个订阅者的事件缓冲大小
+const subscribeBufferSize = 1024
+
+// Server smc_client 的 gRPC 服务，接口定义见 smspb/sms.proto
+type Server struct {
+	smspb.UnimplementedSmsServiceServer
+
+	token  string
+	broker *broker
+	grpc   *grpc.Server
+}
+
+// NewServer 创建 gRPC 服务并监听发送结果及上行短信，token 非空时请求须携带 authorization: Bearer <token> 元数据
+func NewServer(token string) *Server {
+	s := &Server{token: token, broker: newBroker()}
+	s.grpc = grpc.NewServer(
+		grpc.UnaryInterceptor(s.unaryAuth),
+		grpc.StreamInterceptor(s.streamAuth),
+	)
+	smspb.RegisterSmsServiceServer(s.grpc, s)
+	session.AddResultListener(s.broker.onResult)
+	sms.AddInboundListener(s.broker.onInbound)
+	return s
+}
+
+// Serve 在指定地址上提供服务，GracefulStop 后返回 nil
+func (s *Server) Serve(lis net.Listener) error {
+	log.Infof("[Rpc] Listening on %s.", lis.Addr())
+	return s.grpc.Serve(lis)
+}
+
+// GracefulStop 停止接收新请求并等待进行中的请求结束，订阅流会被中断
+func (s *Server) GracefulStop() {
+	s.grpc.GracefulStop()
+}
+
+// Stop 立即停止服务
+func (s *Server) Stop() {
+	s.grpc.Stop()
+}
+
+func (s *Server) Send(_ context.Context, req *smspb.SendRequest) (*smspb.SendResponse, error) {
+	m, err := toMessage(req)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if req.IdempotencyKey == "" {
+		return toSendResponse(currentResponse(m, sms.SendMessage(m))), nil
+	}
+	queryId, dup, err := sms.SendMessageKey(req.IdempotencyKey, m)
+	if err != nil {
+		return nil, idempotencyError(err)
+	}
+	resp := currentResponse(m, queryId)
+	resp.Duplicate = dup
+	return toSendResponse(resp), nil
+}
+
+// idempotencyError 幂等发送的错误对应的状态码
+func idempotencyError(err error) error {
+	if errors.Is(err, sms.ErrIdempotencyDisabled) {
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return status.Error(codes.Unavailable, err.Error())
+}
+
+func (s *Server) BulkSend(stream smspb.SmsService_BulkSendServer) error {
+	resp := &smspb.BulkSendResponse{}
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return stream.SendAndClose(resp)
+		}
+		if err != nil {
+			return err
+		}
+		m, err := toMessage(req)
+		if err != nil {
+			resp.Failed++
+			resp.Results = append(resp.Results, &smspb.BulkResult{ClientMsgId: req.ClientMsgId, Error: err.Error()})
+			continue
+		}
+		// 提交网关后才读取下一条消息
+		var queryId int64
+		var dup bool
+		if req.IdempotencyKey == "" {
+			queryId, err = sms.SendMessageWait(stream.Context(), m)
+		} else {
+			queryId, dup, err = sms.SendMessageKeyWait(stream.Context(), req.IdempotencyKey, m)
+		}
+		if err != nil && stream.Context().Err() != nil {
+			return status.FromContextError(err).Err()
+		}
+		if err != nil {
+			resp.Failed++
+			resp.Results = append(resp.Results, &smspb.BulkResult{ClientMsgId: req.ClientMsgId, Error: err.Error()})
+			continue
+		}
+		if reason := rejectReason(m, queryId); !dup && reason != "" {
+			// 全部号码被拒绝发送（如没有路由）
+			resp.Failed++
+			resp.Results = append(resp.Results, &smspb.BulkResult{QueryId: queryId, ClientMsgId: clientMsgId(m, queryId), Error: "rejected: " + reason})
+			continue
+		}
+		resp.Accepted++
+		resp.Results = append(resp.Results, &smspb.BulkResult{QueryId: queryId, ClientMsgId: clientMsgId(m, queryId), Duplicate: dup})
+	}
+}
+
+// rejectReason 消息的全部号码均被拒绝发送时返回首个号码的拒绝原因，否则返回空
+func rejectReason(m *sms.Message, queryId int64) string {
+	phones := currentResponse(m, queryId).Phones
+	if len(phones) == 0 {
+		return ""
+	}
+	for _, p := range phones {
+		if p.RejectReason == "" {
+			return ""
+		}
+	}
+	return phones[0].RejectReason
+}
+
+func (s *Server) Query(_ context.Context, req *smspb.QueryRequest) (*smspb.SendResponse, error) {
+	results := sms.Query(req.QueryId)
+	if len(results) == 0 {
+		return nil, status.Error(codes.NotFound, "not found")
+	}
+	return toSendResponse(gateway.NewSendResponse(req.QueryId, "", nil, results)), nil
+}
+
+func (s *Server) Subscribe(req *smspb.SubscribeRequest, stream smspb.SmsService_SubscribeServer) error {
+	sub := newSubscription(req, subscribeBufferSize)
+	s.broker.add(sub)
+	defer s.broker.remove(sub)
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case e := <-sub.events:
+			if err := stream.Send(e); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// currentResponse 按查询编号获取当前的发送结果
+func currentResponse(m *sms.Message, queryId int64) *gateway.SendResponse {
+	return gateway.NewSendResponse(queryId, clientMsgId(m, queryId), m.Phones, sms.Query(queryId))
+}
+
+// clientMsgId 未指定客户端消息ID时，sms.SendMessage 使用查询编号
+func clientMsgId(m *sms.Message, queryId int64) string {
+	if m.ClientMsgId != "" {
+		return m.ClientMsgId
+	}
+	return strconv.FormatInt(queryId, 10)
+}
+
+func (s *Server) authorized(ctx context.Context) error {
+	if s.token == "" {
+		return nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, v := range md.Get("authorization") {
+		if subtle.ConstantTimeCompare([]byte(v), []byte("Bearer "+s.token)) == 1 {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "unauthorized")
+}
+
+func (s *Server) unaryAuth(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if err := s.authorized(ctx); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (s *Server) streamAuth(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := s.authorized(ss.Context()); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
@@ -0,0 +1,285 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             v3.21.5
+// source: sms.proto
+
+package smspb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// SmsServiceClient is the client API for SmsService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type SmsServiceClient interface {
+	// Send 发送短信，返回提交时的发送结果
+	Send(ctx context.Context, in *SendRequest, opts ...grpc.CallOption) (*SendResponse, error)
+	// BulkSend 批量发送，每条消息提交网关后才读取下一条：
+	// 发送窗口已满或被限速时服务端暂停读取，由 gRPC 流控将背压传递给客户端
+	BulkSend(ctx context.Context, opts ...grpc.CallOption) (SmsService_BulkSendClient, error)
+	// Query 按查询编号查询发送结果
+	Query(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (*SendResponse, error)
+	// Subscribe 订阅网关响应、状态报告及上行短信，消费过慢时丢弃事件
+	Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (SmsService_SubscribeClient, error)
+}
+
+type smsServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSmsServiceClient(cc grpc.ClientConnInterface) SmsServiceClient {
+	return &smsServiceClient{cc}
+}
+
+func (c *smsServiceClient) Send(ctx context.Context, in *SendRequest, opts ...grpc.CallOption) (*SendResponse, error) {
+	out := new(SendResponse)
+	err := c.cc.Invoke(ctx, "/gosms.sms.v1.SmsService/Send", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *smsServiceClient) BulkSend(ctx context.Context, opts ...grpc.CallOption) (SmsService_BulkSendClient, error) {
+	stream, err := c.cc.NewStream(ctx, &SmsService_ServiceDesc.Streams[0], "/gosms.sms.v1.SmsService/BulkSend", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &smsServiceBulkSendClient{stream}
+	return x, nil
+}
+
+type SmsService_BulkSendClient interface {
+	Send(*SendRequest) error
+	CloseAndRecv() (*BulkSendResponse, error)
+	grpc.ClientStream
+}
+
+type smsServiceBulkSendClient struct {
+	grpc.ClientStream
+}
+
+func (x *smsServiceBulkSendClient) Send(m *SendRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *smsServiceBulkSendClient) CloseAndRecv() (*BulkSendResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(BulkSendResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *smsServiceClient) Query(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (*SendResponse, error) {
+	out := new(SendResponse)
+	err := c.cc.Invoke(ctx, "/gosms.sms.v1.SmsService/Query", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *smsServiceClient) Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (SmsService_SubscribeClient, error) {
+	stream, err := c.cc.NewStream(ctx, &SmsService_ServiceDesc.Streams[1], "/gosms.sms.v1.SmsService/Subscribe", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &smsServiceSubscribeClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type SmsService_SubscribeClient interface {
+	Recv() (*Event, error)
+	grpc.ClientStream
+}
+
+type smsServiceSubscribeClient struct {
+	grpc.ClientStream
+}
+
+func (x *smsServiceSubscribeClient) Recv() (*Event, error) {
+	m := new(Event)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// SmsServiceServer is the server API for SmsService service.
+// All implementations must embed UnimplementedSmsServiceServer
+// for forward compatibility
+type SmsServiceServer interface {
+	// Send 发送短信，返回提交时的发送结果
+	Send(context.Context, *SendRequest) (*SendResponse, error)
+	// BulkSend 批量发送，每条消息提交网关后才读取下一条：
+	// 发送窗口已满或被限速时服务端暂停读取，由 gRPC 流控将背压传递给客户端
+	BulkSend(SmsService_BulkSendServer) error
+	// Query 按查询编号查询发送结果
+	Query(context.Context, *QueryRequest) (*SendResponse, error)
+	// Subscribe 订阅网关响应、状态报告及上行短信，消费过慢时丢弃事件
+	Subscribe(*SubscribeRequest, SmsService_SubscribeServer) error
+	mustEmbedUnimplementedSmsServiceServer()
+}
+
+// UnimplementedSmsServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedSmsServiceServer struct {
+}
+
+func (UnimplementedSmsServiceServer) Send(context.Context, *SendRequest) (*SendResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Send not implemented")
+}
+func (UnimplementedSmsServiceServer) BulkSend(SmsService_BulkSendServer) error {
+	return status.Errorf(codes.Unimplemented, "method BulkSend not implemented")
+}
+func (UnimplementedSmsServiceServer) Query(context.Context, *QueryRequest) (*SendResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Query not implemented")
+}
+func (UnimplementedSmsServiceServer) Subscribe(*SubscribeRequest, SmsService_SubscribeServer) error {
+	return status.Errorf(codes.Unimplemented, "method Subscribe not implemented")
+}
+func (UnimplementedSmsServiceServer) mustEmbedUnimplementedSmsServiceServer() {}
+
+// UnsafeSmsServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SmsServiceServer will
+// result in compilation errors.
+type UnsafeSmsServiceServer interface {
+	mustEmbedUnimplementedSmsServiceServer()
+}
+
+func RegisterSmsServiceServer(s grpc.ServiceRegistrar, srv SmsServiceServer) {
+	s.RegisterService(&SmsService_ServiceDesc, srv)
+}
+
+func _SmsService_Send_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SendRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SmsServiceServer).Send(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gosms.sms.v1.SmsService/Send",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SmsServiceServer).Send(ctx, req.(*SendRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SmsService_BulkSend_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(SmsServiceServer).BulkSend(&smsServiceBulkSendServer{stream})
+}
+
+type SmsService_BulkSendServer interface {
+	SendAndClose(*BulkSendResponse) error
+	Recv() (*SendRequest, error)
+	grpc.ServerStream
+}
+
+type smsServiceBulkSendServer struct {
+	grpc.ServerStream
+}
+
+func (x *smsServiceBulkSendServer) SendAndClose(m *BulkSendResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *smsServiceBulkSendServer) Recv() (*SendRequest, error) {
+	m := new(SendRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _SmsService_Query_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QueryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SmsServiceServer).Query(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gosms.sms.v1.SmsService/Query",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SmsServiceServer).Query(ctx, req.(*QueryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SmsService_Subscribe_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SmsServiceServer).Subscribe(m, &smsServiceSubscribeServer{stream})
+}
+
+type SmsService_SubscribeServer interface {
+	Send(*Event) error
+	grpc.ServerStream
+}
+
+type smsServiceSubscribeServer struct {
+	grpc.ServerStream
+}
+
+func (x *smsServiceSubscribeServer) Send(m *Event) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// SmsService_ServiceDesc is the grpc.ServiceDesc for SmsService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var SmsService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "gosms.sms.v1.SmsService",
+	HandlerType: (*SmsServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Send",
+			Handler:    _SmsService_Send_Handler,
+		},
+		{
+			MethodName: "Query",
+			Handler:    _SmsService_Query_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "BulkSend",
+			Handler:       _SmsService_BulkSend_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Subscribe",
+			Handler:       _SmsService_Subscribe_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "sms.proto",
+}
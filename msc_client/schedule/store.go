@@ -0,0 +1,211 @@
+package schedule
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/hrygo/gosms/codec"
+)
+
+var (
+	ErrNotFound = errors.New("schedule: job not found")      // 任务不存在（或已发送、已取消）
+	ErrExists   = errors.New("schedule: job already exists") // 相同ID的任务已存在
+)
+
+// Job 定时发送任务
+type Job struct {
+	Id          string           `json:"id"`
+	QueryId     int64            `json:"queryId"`     // 发送结果的查询编号
+	FireTime    time.Time        `json:"fireTime"`    // 发送时间
+	ClientMsgId string           `json:"clientMsgId"` // 客户端消息ID
+	Content     string           `json:"content"`
+	Phones      []string         `json:"phones"`
+	Account     string           `json:"account"`
+	Tag         string           `json:"tag"`
+	Options     *codec.MtOptions `json:"options"`
+	Reason      string           `json:"reason"` // 暂存的原因，如 QUIET_HOURS；为空表示 sms.SendAt 创建的定时发送任务
+	CreateTime  time.Time        `json:"createTime"`
+}
+
+var (
+	jobBucket  = []byte("jobs")
+	fireBucket = []byte("fire") // 发送时间索引，键为 8 字节的 UnixNano + 任务ID
+)
+
+// Store 基于 bbolt 嵌入式数据库的定时任务存储
+type Store struct {
+	db *bolt.DB
+}
+
+// Open 打开（或创建）定时任务数据库文件
+func Open(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 3 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(jobBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(fireBucket)
+		return err
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+func fireKey(t time.Time, id string) []byte {
+	key := make([]byte, 8, 8+len(id))
+	binary.BigEndian.PutUint64(key, uint64(t.UnixNano()))
+	return append(key, id...)
+}
+
+// Put 写入任务，相同ID的任务被覆盖
+func (s *Store) Put(j *Job) error {
+	if j.CreateTime.IsZero() {
+		j.CreateTime = time.Now()
+	}
+	data, err := json.Marshal(j)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		jobs, fire := tx.Bucket(jobBucket), tx.Bucket(fireBucket)
+		if err := deleteJob(jobs, fire, j.Id); err != nil && !errors.Is(err, ErrNotFound) {
+			return err
+		}
+		if err := jobs.Put([]byte(j.Id), data); err != nil {
+			return err
+		}
+		return fire.Put(fireKey(j.FireTime, j.Id), nil)
+	})
+}
+
+// Add 写入新任务，相同ID的任务已存在时返回 ErrExists
+func (s *Store) Add(j *Job) error {
+	if j.CreateTime.IsZero() {
+		j.CreateTime = time.Now()
+	}
+	data, err := json.Marshal(j)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		jobs := tx.Bucket(jobBucket)
+		if jobs.Get([]byte(j.Id)) != nil {
+			return ErrExists
+		}
+		if err := jobs.Put([]byte(j.Id), data); err != nil {
+			return err
+		}
+		return tx.Bucket(fireBucket).Put(fireKey(j.FireTime, j.Id), nil)
+	})
+}
+
+// Update 在事务中修改任务（如发送时间），任务不存在时返回 ErrNotFound
+func (s *Store) Update(id string, fn func(j *Job)) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		jobs, fire := tx.Bucket(jobBucket), tx.Bucket(fireBucket)
+		data := jobs.Get([]byte(id))
+		if data == nil {
+			return ErrNotFound
+		}
+		j := &Job{}
+		if err := json.Unmarshal(data, j); err != nil {
+			return err
+		}
+		if err := fire.Delete(fireKey(j.FireTime, id)); err != nil {
+			return err
+		}
+		fn(j)
+		j.Id = id
+		data, err := json.Marshal(j)
+		if err != nil {
+			return err
+		}
+		if err = jobs.Put([]byte(id), data); err != nil {
+			return err
+		}
+		return fire.Put(fireKey(j.FireTime, id), nil)
+	})
+}
+
+// Get 获取任务
+func (s *Store) Get(id string) (*Job, error) {
+	var j *Job
+	err := s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(jobBucket).Get([]byte(id))
+		if data == nil {
+			return ErrNotFound
+		}
+		j = &Job{}
+		return json.Unmarshal(data, j)
+	})
+	return j, err
+}
+
+// Delete 删除任务
+func (s *Store) Delete(id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return deleteJob(tx.Bucket(jobBucket), tx.Bucket(fireBucket), id)
+	})
+}
+
+func deleteJob(jobs, fire *bolt.Bucket, id string) error {
+	data := jobs.Get([]byte(id))
+	if data == nil {
+		return ErrNotFound
+	}
+	old := &Job{}
+	if err := json.Unmarshal(data, old); err != nil {
+		return err
+	}
+	if err := fire.Delete(fireKey(old.FireTime, id)); err != nil {
+		return err
+	}
+	return jobs.Delete([]byte(id))
+}
+
+// Due 按发送时间顺序返回发送时间不晚于 t 的任务
+func (s *Store) Due(t time.Time) ([]*Job, error) {
+	var ret []*Job
+	end := uint64(t.UnixNano())
+	err := s.db.View(func(tx *bolt.Tx) error {
+		jobs := tx.Bucket(jobBucket)
+		c := tx.Bucket(fireBucket).Cursor()
+		for k, _ := c.First(); k != nil && binary.BigEndian.Uint64(k[:8]) <= end; k, _ = c.Next() {
+			data := jobs.Get(k[8:])
+			if data == nil {
+				continue
+			}
+			j := &Job{}
+			if err := json.Unmarshal(data, j); err != nil {
+				return err
+			}
+			ret = append(ret, j)
+		}
+		return nil
+	})
+	return ret, err
+}
+
+// List 按发送时间顺序返回全部任务
+func (s *Store) List() ([]*Job, error) {
+	return s.Due(time.Unix(0, 1<<63-1))
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,239 @@
+package sms
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hrygo/log"
+
+	"github.com/hrygo/gosms/codec"
+	"github.com/hrygo/gosms/event_manager"
+	"github.com/hrygo/gosms/smc_client/outbox"
+	"github.com/hrygo/gosms/smc_client/quiet"
+	"github.com/hrygo/gosms/smc_client/schedule"
+)
+
+var (
+	jobs      *schedule.Store // 定时任务存储，未启用时为nil
+	jobsOnce  sync.Once
+	jobsError error
+)
+
+// ErrSchedulerDisabled 未启用定时发送
+var ErrSchedulerDisabled = errors.New("scheduler is not enabled")
+
+// StartScheduler 按配置文件启用定时发送（Schedule.enable），通过 SendAt 创建的任务保存在本地数据库中，
+// 到期后按路由规则发送，进程重启后未发送的任务仍会发送（错过的任务在启动后立即发送）。
+func StartScheduler() error {
+	if !ConfigYml.GetBool("Schedule.enable") {
+		return nil
+	}
+	return startScheduler()
+}
+
+// SendAt 创建定时发送任务，at 时按路由规则发送消息，返回任务ID及发送结果的查询编号。
+// 任务ID为消息的 ClientMsgId（为空时使用查询编号），相同ID的任务已存在时返回 schedule.ErrExists。
+func SendAt(at time.Time, m *Message) (id string, queryId int64, err error) {
+	if jobs == nil {
+		return "", 0, ErrSchedulerDisabled
+	}
+	if m == nil || len(m.Phones) < 1 || m.Content == "" {
+		return "", 0, errors.New("content and phones are required")
+	}
+	queryId = codec.B64Seq.NextVal()
+	id = m.ClientMsgId
+	if id == "" {
+		id = strconv.FormatInt(queryId, 10)
+	}
+	j := &schedule.Job{
+		Id:          id,
+		QueryId:     queryId,
+		FireTime:    at,
+		ClientMsgId: id,
+		Content:     m.Content,
+		Phones:      m.Phones,
+		Account:     m.Account,
+		Tag:         m.Tag,
+		Options:     codec.LoadMtOptions(m.Options...),
+	}
+	if err = jobs.Add(j); err != nil {
+		return "", 0, err
+	}
+	log.Infof("[Schedule] Job %s scheduled at %s.", id, at.Format(time.RFC3339))
+	return id, queryId, nil
+}
+
+// CancelScheduled 取消尚未发送的定时发送任务，任务不存在或已发送时返回 schedule.ErrNotFound
+func CancelScheduled(id string) error {
+	if jobs == nil {
+		return ErrSchedulerDisabled
+	}
+	return jobs.Delete(id)
+}
+
+// Reschedule 修改尚未发送的定时发送任务的发送时间，任务不存在或已发送时返回 schedule.ErrNotFound
+func Reschedule(id string, at time.Time) error {
+	if jobs == nil {
+		return ErrSchedulerDisabled
+	}
+	return jobs.Update(id, func(j *schedule.Job) { j.FireTime = at })
+}
+
+// ScheduledJob 获取尚未发送的定时发送任务
+func ScheduledJob(id string) (*schedule.Job, error) {
+	if jobs == nil {
+		return nil, ErrSchedulerDisabled
+	}
+	return jobs.Get(id)
+}
+
+// ScheduledJobs 按发送时间顺序返回尚未发送的全部任务，含发送时段限制暂存的消息
+func ScheduledJobs() ([]*schedule.Job, error) {
+	if jobs == nil {
+		return nil, ErrSchedulerDisabled
+	}
+	return jobs.List()
+}
+
+// startScheduler 打开定时任务存储（Schedule.path），并按 Schedule.check-duration 间隔发送到期的任务，
+// 多次调用只启动一次
+func startScheduler() error {
+	jobsOnce.Do(func() {
+		path := ConfigYml.GetString("Schedule.path")
+		if path == "" {
+			path = "data/schedule.db"
+		}
+		st, err := schedule.Open(BasePath + path)
+		if err != nil {
+			jobsError = err
+			return
+		}
+		jobs = st
+		startSchedulerTicker(st)
+		log.Infof("[Schedule] Started with %s.", path)
+	})
+	return jobsError
+}
+
+func startSchedulerTicker(st *schedule.Store) {
+	d := ConfigYml.GetDuration("Schedule.check-duration")
+	if d <= 0 {
+		d = time.Second
+	}
+	ctx, stop := context.WithCancel(context.Background())
+	go func() {
+		ticker := time.NewTicker(d)
+		defer ticker.Stop()
+
+		cancel := event_manager.RegisterShutdownHookerAddChan("Stop_Scheduler",
+			func(args ...any) {
+				stop()
+				ticker.Stop()
+			},
+		)
+		defer func() { _ = st.Close() }()
+		for {
+			select {
+			case <-cancel:
+				return
+			case <-ticker.C:
+				fireDueJobs(ctx, st)
+			}
+		}
+	}()
+}
+
+// fireDueJobs 依次发送到期的任务，号码暂无可用会话时阻塞等待。
+// 任务在全部号码发送（或写入发件箱）后删除，中途停止时已发送的号码从任务中移除，其余号码下次（含重启后）继续发送
+func fireDueJobs(ctx context.Context, st *schedule.Store) {
+	due, err := st.Due(time.Now())
+	if err != nil {
+		log.Errorf("[Schedule] Load jobs error: %v", err)
+		return
+	}
+	for _, j := range due {
+		if ctx.Err() != nil {
+			return
+		}
+		// 任务已被取消时跳过
+		if _, err = st.Get(j.Id); err != nil {
+			if !errors.Is(err, schedule.ErrNotFound) {
+				log.Errorf("[Schedule] Load job %s error: %v", j.Id, err)
+			}
+			continue
+		}
+		if j.Reason == "" {
+			log.Infof("[Schedule] Fire job %s.", j.Id)
+		}
+		held := false
+		for i, phone := range j.Phones {
+			m := &Message{
+				ClientMsgId: j.ClientMsgId,
+				Content:     j.Content,
+				Phones:      []string{phone},
+				Account:     j.Account,
+				Tag:         j.Tag,
+				Options:     []codec.OptionFunc{codec.WithMtOptions(j.Options)},
+			}
+			if j.Reason == "" {
+				_, err = sendQuery(ctx, j.QueryId, m, true)
+			} else {
+				held, err = release(ctx, j.QueryId, phone, m)
+			}
+			if err != nil {
+				rest := j.Phones[i:]
+				if err = st.Update(j.Id, func(j *schedule.Job) { j.Phones = rest }); err != nil && !errors.Is(err, schedule.ErrNotFound) {
+					log.Errorf("[Schedule] Update job %s error: %v", j.Id, err)
+				}
+				return
+			}
+		}
+		if held {
+			// 消息继续暂存，任务已按相同的编号更新为新的发送时间
+			continue
+		}
+		if err = st.Delete(j.Id); err != nil && !errors.Is(err, schedule.ErrNotFound) {
+			log.Errorf("[Schedule] Delete job %s error: %v", j.Id, err)
+		}
+	}
+}
+
+// release 发送已入队的暂存消息，发送前重新检查拒绝条件及发送时段，结果追加到原查询编号下。
+// 仍不在允许发送的时段内时继续暂存，返回 held 为 true
+func release(ctx context.Context, queryId int64, phone string, m *Message) (held bool, err error) {
+	reason := rejectCheck(phone, m)
+	if p, open := quietCheck(phone, m); reason == "" && p != nil {
+		// 仍不在允许发送的时段内（如进程停止期间错过了时段），继续暂存
+		if p.Action == quiet.ActionHold && !open.IsZero() && hold(queryId, phone, m, open) == nil {
+			return true, nil
+		}
+		reason = RejectQuietHours
+	}
+	if reason == "" {
+		reason = limitCheck(phone, m)
+	}
+	if box != nil {
+		err := box.Update(m.ClientMsgId, phone, func(r *outbox.Record) {
+			r.Reset()
+			if reason != "" {
+				r.Rejected(reason)
+			}
+		})
+		if err != nil && !errors.Is(err, outbox.ErrNotFound) {
+			log.Errorf("[Schedule] Update %s error: %v", outbox.Key(m.ClientMsgId, phone), err)
+		}
+	}
+	if reason != "" {
+		log.Warnf("[Schedule] Message %s to %s rejected: %s.", m.ClientMsgId, phone, reason)
+		saveQueryCache(queryId, []any{rejected(phone, m, reason)})
+		return false, nil
+	}
+	results, err := sendWait(ctx, phone, m)
+	if len(results) > 0 {
+		saveQueryCache(queryId, results)
+	}
+	return false, err
+}
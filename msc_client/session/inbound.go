@@ -0,0 +1,54 @@
+package session
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// InboundMessage 上行短信（MO）
+type InboundMessage struct {
+	Id          string    `json:"id"`          // 网关的消息标识
+	ISP         string    `json:"isp"`         // 协议（运营商）cmpp、smgp
+	Account     string    `json:"account"`     // 接收上行短信的账号
+	Phone       string    `json:"phone"`       // 发送方手机号码
+	DestId      string    `json:"destId"`      // 完整的目的号码
+	SubNo       string    `json:"subNo"`       // 扩展子号码，即目的号码去掉接入号后的部分
+	Content     string    `json:"content"`     // 已解码的短信内容
+	ServiceId   string    `json:"serviceId"`   // 业务代码
+	LinkID      string    `json:"linkId"`      // 点播业务使用的LinkID
+	ReceiveTime time.Time `json:"receiveTime"` // 接收时间
+}
+
+// InboundHook 上行短信接收钩子，返回错误时会话将以失败结果应答网关，由网关重新推送
+type InboundHook func(m *InboundMessage) error
+
+var (
+	inboundHook InboundHook
+	inboundMu   sync.RWMutex
+)
+
+// SetInboundHook 设置上行短信接收钩子，未设置时上行短信应答后丢弃
+func SetInboundHook(h InboundHook) {
+	inboundMu.Lock()
+	defer inboundMu.Unlock()
+	inboundHook = h
+}
+
+// onInbound 处理上行短信，返回是否已成功接收
+func (s *Session) onInbound(m *InboundMessage) bool {
+	inboundMu.RLock()
+	h := inboundHook
+	inboundMu.RUnlock()
+	if h == nil {
+		return true
+	}
+	m.ISP = s.serverName
+	m.Account = s.account
+	m.SubNo = m.DestId
+	if ac := s.authConf; ac != nil {
+		m.SubNo = strings.TrimPrefix(m.DestId, ac.SmsDisplayNo)
+	}
+	m.ReceiveTime = time.Now()
+	return h(m) == nil
+}
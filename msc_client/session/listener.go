@@ -0,0 +1,63 @@
+package session
+
+import (
+	"sync"
+
+	"github.com/hrygo/log"
+)
+
+// Event 发送结果事件类型
+type Event byte
+
+const (
+	EventResponse   Event = iota + 1 // 收到网关对下行短信的响应
+	EventReport                      // 收到状态报告
+	EventLateReport                  // 收到状态报告，但发送结果已从内存缓存中清除，Result 仅包含 MsgId、Report 及 ReportTime
+)
+
+func (e Event) String() string {
+	switch e {
+	case EventResponse:
+		return "response"
+	case EventReport:
+		return "report"
+	case EventLateReport:
+		return "late-report"
+	}
+	return "unknown"
+}
+
+// ResultListener 发送结果监听器，网关响应或状态报告更新 Result 后回调。
+// 回调在会话的接收协程中同步执行，耗时操作需自行异步处理。
+type ResultListener func(event Event, r *Result)
+
+var (
+	listeners   []ResultListener
+	listenersMu sync.RWMutex
+)
+
+// AddResultListener 注册发送结果监听器
+func AddResultListener(l ResultListener) {
+	if l == nil {
+		return
+	}
+	listenersMu.Lock()
+	defer listenersMu.Unlock()
+	listeners = append(listeners, l)
+}
+
+func notify(event Event, r *Result) {
+	listenersMu.RLock()
+	ls := listeners
+	listenersMu.RUnlock()
+	for _, l := range ls {
+		func() {
+			defer func() {
+				if e := recover(); e != nil {
+					log.Errorf("[Listener] %s listener panic: %v", event, e)
+				}
+			}()
+			l(event, r)
+		}()
+	}
+}
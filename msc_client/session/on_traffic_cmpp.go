@@ -11,24 +11,27 @@ import (
 	"github.com/hrygo/gosms/utils"
 )
 
-func (s *Session) sendByCmpp(phone string, message string, options ...codec.OptionFunc) (results []any) {
+func (s *Session) sendByCmpp(clientMsgId, phone string, message string, options ...codec.OptionFunc) (results []any) {
 	var send = fmt.Sprintf("[%s] OnTraffic >>>", s.serverName)
+	subNo := codec.LoadMtOptions(options...).SpSubNo
 	mts := cmpp.NewSubmit(s.authConf, []string{phone}, message, uint32(codec.B32Seq.NextVal()), options...)
 	for _, mt := range mts {
+		mtt := mt.(*cmpp.Submit)
+		// 先缓存发送结果再写出报文，避免网关响应先于缓存到达
+		r := Result{ClientMsgId: clientMsgId, Account: s.account, SpSubNo: subNo, SendTime: time.Now()}
+		r.SequenceId = uint64(mtt.SequenceId)
+		r.Phone = phone
+		SequenceIdResultCacheMap.Store(r.SequenceId, &r)
+
 		_, err := s.con.Write(mt.Encode())
 		if err != nil {
+			SequenceIdResultCacheMap.Delete(r.SequenceId)
 			log.Error(err.Error())
 			s.Close()
 			return nil
 		}
-		mtt := mt.(*cmpp.Submit)
 		log.Debug(send, mtt.Log()...)
-
-		r := Result{SendTime: time.Now()}
-		r.SequenceId = uint64(mtt.SequenceId)
-		r.Phone = phone
 		results = append(results, &r)
-		SequenceIdResultCacheMap.Store(r.SequenceId, &r)
 	}
 	return
 }
@@ -83,7 +86,7 @@ func (s *Session) onTrafficCmpp(cmd, seq uint32, buff []byte) {
 			log.Error(err.Error())
 			s.Close()
 		}
-		result, ok := SequenceIdResultCacheMap.Load(sub.SequenceId)
+		result, ok := SequenceIdResultCacheMap.Load(uint64(sub.SequenceId))
 		if ok {
 			mtr := result.(*Result)
 			mtr.Result = sub.Result()
@@ -91,6 +94,7 @@ func (s *Session) onTrafficCmpp(cmd, seq uint32, buff []byte) {
 			mtr.ResponseTime = time.Now()
 			// 已msgId为Key存储到内存缓存
 			MsgIdResultCacheMap.Store(mtr.MsgId, mtr)
+			notify(EventResponse, mtr)
 		}
 	case cmpp.CMPP_DELIVER:
 		dly := &cmpp.Delivery{Version: cmpp.Version(s.authConf.Version)}
@@ -101,7 +105,23 @@ func (s *Session) onTrafficCmpp(cmd, seq uint32, buff []byte) {
 			s.Close()
 			return
 		}
-		resp := dly.ToResponse(0)
+		// 上行短信先交由接收钩子持久化，失败时应答错误码，由网关重新推送
+		var code uint32
+		if !dly.IsReport() {
+			mo := &InboundMessage{
+				Id:        utils.Uint64HexString(dly.MsgId()),
+				Phone:     dly.SrcTerminalId(),
+				DestId:    dly.DestId(),
+				Content:   dly.MsgContent(),
+				ServiceId: dly.ServiceId(),
+				LinkID:    dly.LinkID(),
+			}
+			if !s.onInbound(mo) {
+				log.Warnf("[%s] Inbound message %s not accepted, ask gateway to retry.", s.serverName, mo.Id)
+				code = 9 // 未知错误
+			}
+		}
+		resp := dly.ToResponse(code)
 		_, err = s.con.Write(resp.Encode())
 		log.Debug(send, resp.Log()...)
 		if err != nil {
@@ -120,6 +140,9 @@ func (s *Session) onTrafficCmpp(cmd, seq uint32, buff []byte) {
 				mtr := val.(*Result)
 				mtr.Report = rpt.Stat()
 				mtr.ReportTime = time.Now()
+				notify(EventReport, mtr)
+			} else {
+				notify(EventLateReport, &Result{MsgId: key, Report: rpt.Stat(), ReportTime: time.Now()})
 			}
 		}
 	}
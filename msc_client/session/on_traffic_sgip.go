@@ -10,24 +10,27 @@ import (
 	"github.com/hrygo/gosms/codec/sgip"
 )
 
-func (s *Session) sendBySgip(phone string, message string, options ...codec.OptionFunc) (results []any) {
+func (s *Session) sendBySgip(clientMsgId, phone string, message string, options ...codec.OptionFunc) (results []any) {
 	var send = fmt.Sprintf("[%s] OnTraffic >>>", s.serverName)
+	subNo := codec.LoadMtOptions(options...).SpSubNo
 	mts := sgip.NewSubmit(s.authConf, []string{phone}, message, options...)
 	for _, mt := range mts {
+		mtt := mt.(*sgip.Submit)
+		// 先缓存发送结果再写出报文，避免网关响应先于缓存到达
+		r := Result{ClientMsgId: clientMsgId, Account: s.account, SpSubNo: subNo, SendTime: time.Now()}
+		r.SequenceId = mtt.Sequence2Uint64()
+		r.Phone = phone
+		SequenceIdResultCacheMap.Store(r.SequenceId, &r)
+
 		_, err := s.con.Write(mt.Encode())
 		if err != nil {
+			SequenceIdResultCacheMap.Delete(r.SequenceId)
 			log.Error(err.Error())
 			s.Close()
 			return nil
 		}
-		mtt := mt.(*sgip.Submit)
 		log.Debug(send, mtt.Log()...)
-
-		r := Result{SendTime: time.Now()}
-		r.SequenceId = mtt.Sequence2Uint64()
-		r.Phone = phone
 		results = append(results, &r)
-		SequenceIdResultCacheMap.Store(r.SequenceId, &r)
 	}
 	return
 }
@@ -76,6 +79,7 @@ func (s *Session) onTrafficSgip(cmd, seq uint32, buff []byte) {
 			mtr.ResponseTime = time.Now()
 			// 以msgId为Key存储到内存缓存
 			MsgIdResultCacheMap.Store(mtr.MsgId, mtr)
+			notify(EventResponse, mtr)
 		}
 	}
 }
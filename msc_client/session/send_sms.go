@@ -9,7 +9,10 @@ import (
 
 type Result struct {
 	QueryId      int64     `json:"QueryId"`      // 给客户端用的查询编号
+	ClientMsgId  string    `json:"clientMsgId"`  // 客户端消息ID
 	Phone        string    `json:"phone"`        // 手机号
+	Account      string    `json:"account"`      // 发送账号
+	SpSubNo      string    `json:"spSubNo"`      // 发送时的扩展子号码
 	SequenceId   uint64    `json:"sequenceId"`   // 消息发送的标识
 	Result       uint32    `json:"result"`       // 消息发送的网关响应码
 	MsgId        string    `json:"msgId"`        // 消息的msgId用于关联状态报告
@@ -17,6 +20,7 @@ type Result struct {
 	SendTime     time.Time `json:"sendTime"`     // 发送时间
 	ResponseTime time.Time `json:"responseTime"` // 网关响应时间
 	ReportTime   time.Time `json:"reportTime"`   // 状态报告时间
+	RejectReason string    `json:"rejectReason"` // 客户端拒绝发送的原因，如 SUPPRESSED，为空表示已提交网关
 }
 
 // SequenceIdResultCacheMap 临时存储短信发送的返回结果数据，Key为requestId,value为*Result，后续采用数据库存储
@@ -27,13 +31,18 @@ var MsgIdResultCacheMap sync.Map
 
 // Send 发送短信
 func (s *Session) Send(phone string, message string, options ...codec.OptionFunc) []any {
+	return s.SendWithId("", phone, message, options...)
+}
+
+// SendWithId 发送短信，clientMsgId 记录到发送结果中，用于关联客户端的消息
+func (s *Session) SendWithId(clientMsgId, phone string, message string, options ...codec.OptionFunc) []any {
 	switch s.serverName {
 	case CMPP:
-		return s.sendByCmpp(phone, message, options...)
+		return s.sendByCmpp(clientMsgId, phone, message, options...)
 	case SMGP:
-		return s.sendBySmgp(phone, message, options...)
+		return s.sendBySmgp(clientMsgId, phone, message, options...)
 	case SGIP:
-		return s.sendBySgip(phone, message, options...)
+		return s.sendBySgip(clientMsgId, phone, message, options...)
 	}
 	return nil
 }
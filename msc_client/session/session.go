@@ -22,6 +22,7 @@ type Session struct {
 	con           net.Conn
 	authConf      *codec.AuthConf
 	serverName    string
+	account       string // 会话所属的发送账号
 	stat          stat
 	counter       uint64
 	periodCounter uint64 // 短周期内的计数器，用于LRU排序算法
@@ -45,7 +46,12 @@ const (
 
 // NewSession 创建一个新会话并登录，且启动定时器和接收服务
 func NewSession(isp string, ac *codec.AuthConf, con net.Conn) *Session {
-	sc := &Session{con: con, authConf: ac, serverName: isp, stat: StatConnect}
+	return NewAccountSession(isp, isp, ac, con)
+}
+
+// NewAccountSession 为指定的发送账号创建一个新会话并登录，且启动定时器和接收服务
+func NewAccountSession(account, isp string, ac *codec.AuthConf, con net.Conn) *Session {
+	sc := &Session{con: con, authConf: ac, serverName: isp, account: account, stat: StatConnect}
 	err := sc.login()
 	if err != nil {
 		log.Error("create session error: " + err.Error())
@@ -58,6 +64,11 @@ func NewSession(isp string, ac *codec.AuthConf, con net.Conn) *Session {
 	return sc
 }
 
+// Account 会话所属的发送账号
+func (s *Session) Account() string {
+	return s.account
+}
+
 func (s *Session) ResetCounter() {
 	s.periodCounter = 0
 }
@@ -3,10 +3,10 @@ package sms
 import (
 	"fmt"
 	"net"
-	"regexp"
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/hrygo/log"
@@ -21,7 +21,11 @@ import (
 	"github.com/hrygo/gosms/utils"
 )
 
-var factories [3]*SessionFactory
+// factories 账号名 => 会话工厂
+var factories = make(map[string]*SessionFactory)
+
+// factoryIndex 账号名 => 会话工厂，供接收协程无锁查找
+var factoryIndex sync.Map
 
 // resultQueryCacheMap 临时存储短信发送的返回结果数据，Key为queryId,value为[]*Status，后续采用数据库存储
 var resultQueryCacheMap sync.Map
@@ -35,71 +39,102 @@ func AsyncPool() *goroutine.Pool {
 type SessionFactory struct {
 	sync.Mutex
 	srvName    string
+	account    *Account
 	serverAddr string
 	authConf   *codec.AuthConf
 	sessions   []*session.Session
 	window     chan struct{}
 	limiter    *rate.Limiter
-	regex      *regexp.Regexp
+	inflight   int32 // 已提交但未收到网关响应的分段数，达到发送窗口大小时暂停选择该账号
+	counter    factoryCounter
+}
+
+// factoryCounter 会话工厂的累计计数，原子操作
+type factoryCounter struct {
+	connects      uint64
+	connectErrors uint64
+	disconnects   uint64
+	windowFull    uint64
+	throttled     uint64
+}
+
+// FactoryStat 账号的连接及发送窗口统计，计数均为进程启动以来的累计值
+type FactoryStat struct {
+	Account       string `json:"account"`
+	ISP           string `json:"isp"`
+	Sessions      int    `json:"sessions"`      // 当前健康会话数
+	Inflight      int32  `json:"inflight"`      // 已提交但未收到网关响应的分段数
+	Connects      uint64 `json:"connects"`      // 登录成功的连接数，含重连
+	ConnectErrors uint64 `json:"connectErrors"` // 连接或登录失败次数
+	Disconnects   uint64 `json:"disconnects"`   // 因异常被移除的连接数
+	WindowFull    uint64 `json:"windowFull"`    // 因发送窗口已满未能选择该账号的次数
+	Throttled     uint64 `json:"throttled"`     // 因吞吐量限制未能选择该账号的次数
 }
 
 // SelectSession 根据手机号码选择一个会话
 func SelectSession(phone string) *session.Session {
-	mu.Lock()
-	var fa *SessionFactory
-	for i, factory := range factories {
-		if factory == nil {
-			factory = CreateSessionFactory(ISPS[i])
-			if factory == nil {
-				continue
-			}
-		}
-		if factory.regex.MatchString(phone) {
-			fa = factory
-			break
-		}
-	}
-	mu.Unlock()
+	return selectSession(phone, &Message{Phones: []string{phone}})
+}
 
-	if fa == nil {
+// selectSession 由路由器为手机号码选择账号（或分组），
+// 在候选账号中按权重负载均衡，当前账号不可用（无健康会话或被限速）时转移到下一个账号。
+func selectSession(phone string, m *Message) *session.Session {
+	target := CurrentRouter().Route(phone, m)
+	if target == "" {
+		log.Warnf("[Route] No route for phone %s.", phone)
+		return nil
+	}
+	candidates := ResolveAccounts(target)
+	if len(candidates) == 0 {
+		log.Errorf("[Route] Phone %s routed to unknown account \"%s\".", phone, target)
 		return nil
 	}
-	return fa.PeekSession()
+
+	for _, a := range candidates {
+		mu.Lock()
+		fa := CreateSessionFactory(a.Name)
+		mu.Unlock()
+		if fa == nil {
+			continue
+		}
+		if sc := fa.TryPeekSession(); sc != nil {
+			return sc
+		}
+		log.Debugf("[Route] Account %s unavailable, try next.", a.Name)
+	}
+	return nil
 }
 
-// CreateSessionFactory 创建或获取由isp指定的factory，isp需与sms.yml配置文件对应，否则会引起程序崩溃
-func CreateSessionFactory(isp string) *SessionFactory {
-	isp = strings.ToLower(isp)
-	saved := factories[ISP(isp).Int()]
+// CreateSessionFactory 创建或获取由账号名称指定的factory，账号不存在或配置错误时返回nil
+func CreateSessionFactory(name string) *SessionFactory {
+	name = strings.ToLower(name)
+	saved := factories[name]
 	if saved != nil {
 		return saved
 	}
 
-	ac := FindAuthConf(isp, ConfigYml.GetString(isp+".client-id"))
+	acc := FindAccount(name)
+	if acc == nil {
+		log.Errorf("Account \"%s\" not found!", name)
+		return nil
+	}
+	isp := acc.ISP
+
+	ac := FindAuthConf(isp, acc.ClientId)
 	if ac == nil {
-		log.Errorf("isp=%s, clientId=%s not found!", isp, ConfigYml.GetString(isp+".client-id"))
+		log.Errorf("account=%s, isp=%s, clientId=%s not found!", name, isp, acc.ClientId)
 		return nil
 	}
 
-	factory := &SessionFactory{srvName: isp, authConf: ac}
+	factory := &SessionFactory{srvName: isp, account: acc, authConf: ac}
 
-	address := ConfigYml.GetString(isp + ".address")
+	address := acc.Address
 	if address == "" {
-		log.Error(isp + ".address can't be empty")
+		log.Error(name + ".address can't be empty")
 	}
 	factory.serverAddr = address
 
-	segment := ConfigYml.GetString(isp + ".segment")
-	if segment == "" {
-		log.Error(isp + ".segment can't be empty")
-	}
-	var err error
-	factory.regex, err = regexp.Compile(segment)
-	if err != nil {
-		log.Error(err.Error())
-	}
-
-	maxConns := ConfigYml.GetInt(isp + ".max-conns")
+	maxConns := acc.MaxConns
 	if maxConns > 0 {
 		factory.sessions = make([]*session.Session, 0, maxConns)
 	} else {
@@ -109,16 +144,20 @@ func CreateSessionFactory(isp string) *SessionFactory {
 	// 立即初始化一个连接
 	c, err := net.Dial("tcp", address)
 	if err == nil {
-		sc := session.NewSession(isp, ac, c)
+		sc := session.NewAccountSession(acc.Name, isp, ac, c)
 		if sc != nil {
 			factory.sessions = append(factory.sessions, sc)
+			factory.counter.connects++
+		} else {
+			factory.counter.connectErrors++
 		}
 	} else {
+		factory.counter.connectErrors++
 		log.Error(err.Error())
 	}
 
-	winSize := ConfigYml.GetInt(isp + ".mt-window-size")
-	if maxConns > 0 {
+	winSize := acc.MtWindowSize
+	if winSize > 0 {
 		factory.window = make(chan struct{}, winSize)
 	} else {
 		factory.window = make(chan struct{}, 16)
@@ -126,7 +165,7 @@ func CreateSessionFactory(isp string) *SessionFactory {
 
 	// 默认1W微妙即10毫秒生成一个token，也即tps最大200
 	ev := 10 * time.Millisecond
-	throughput := ConfigYml.GetInt(isp + ".throughput")
+	throughput := acc.Throughput
 	if throughput > 0 {
 		// 1s = 1000*1000 microsecond = 1000000 microsecond, Throughput 单位时TPS
 		ev = time.Duration(1000000/throughput) * time.Microsecond
@@ -136,17 +175,24 @@ func CreateSessionFactory(isp string) *SessionFactory {
 	factory.startLruSortTicker()
 	factory.RegCloseSessionsHooker()
 
-	factories[ISP(isp).Int()] = factory
+	factories[name] = factory
+	factoryIndex.Store(name, factory)
+	windowOnce.Do(func() { session.AddResultListener(onWindowResult) })
 	return factory
 }
 
+// Account 工厂对应的账号配置
+func (f *SessionFactory) Account() *Account {
+	return f.account
+}
+
 // PeekSession 获取排序后在头部的会话（最近最少使用的会话）
 func (f *SessionFactory) PeekSession() *session.Session {
 	if !f.limiter.Allow() {
 		return nil
 	}
 
-	if len(f.sessions) > 0 && f.sessions[0] == nil && f.sessions[0].HealthCheck() {
+	if len(f.sessions) > 0 && f.sessions[0] != nil && f.sessions[0].HealthCheck() {
 		return f.sessions[0]
 	}
 
@@ -160,6 +206,94 @@ func (f *SessionFactory) PeekSession() *session.Session {
 	return ret
 }
 
+// TryPeekSession 非阻塞地获取一个健康会话，无健康会话或被限速时返回nil
+func (f *SessionFactory) TryPeekSession() *session.Session {
+	f.Lock()
+	var ret *session.Session
+	for _, sc := range f.sessions {
+		if sc != nil && sc.HealthCheck() {
+			ret = sc
+			break
+		}
+	}
+	f.Unlock()
+
+	if ret == nil {
+		return nil
+	}
+	if f.WindowFull() {
+		atomic.AddUint64(&f.counter.windowFull, 1)
+		return nil
+	}
+	if !f.limiter.Allow() {
+		atomic.AddUint64(&f.counter.throttled, 1)
+		return nil
+	}
+	return ret
+}
+
+// Stat 获取账号的连接及发送窗口统计
+func (f *SessionFactory) Stat() FactoryStat {
+	f.Lock()
+	healthy := 0
+	for _, sc := range f.sessions {
+		if sc != nil && sc.HealthCheck() {
+			healthy++
+		}
+	}
+	f.Unlock()
+	return FactoryStat{
+		Account:       f.account.Name,
+		ISP:           f.account.ISP,
+		Sessions:      healthy,
+		Inflight:      atomic.LoadInt32(&f.inflight),
+		Connects:      atomic.LoadUint64(&f.counter.connects),
+		ConnectErrors: atomic.LoadUint64(&f.counter.connectErrors),
+		Disconnects:   atomic.LoadUint64(&f.counter.disconnects),
+		WindowFull:    atomic.LoadUint64(&f.counter.windowFull),
+		Throttled:     atomic.LoadUint64(&f.counter.throttled),
+	}
+}
+
+// FactoryStats 获取已创建会话工厂的所有账号的统计，按账号名排序
+func FactoryStats() []FactoryStat {
+	var ret []FactoryStat
+	factoryIndex.Range(func(_, v any) bool {
+		ret = append(ret, v.(*SessionFactory).Stat())
+		return true
+	})
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Account < ret[j].Account })
+	return ret
+}
+
+var windowOnce sync.Once
+
+// WindowFull 发送窗口是否已满
+func (f *SessionFactory) WindowFull() bool {
+	return atomic.LoadInt32(&f.inflight) >= int32(cap(f.window))
+}
+
+// acquireWindow 占用账号的发送窗口
+func acquireWindow(account string, n int) {
+	if v, ok := factoryIndex.Load(account); ok && n > 0 {
+		atomic.AddInt32(&v.(*SessionFactory).inflight, int32(n))
+	}
+}
+
+// releaseWindow 释放账号的一个发送窗口，网关响应可能先于 acquireWindow 到达，计数短暂为负是正常的
+func releaseWindow(account string) {
+	if v, ok := factoryIndex.Load(account); ok {
+		atomic.AddInt32(&v.(*SessionFactory).inflight, -1)
+	}
+}
+
+// onWindowResult 收到网关响应时释放发送窗口
+func onWindowResult(event session.Event, r *session.Result) {
+	if event == session.EventResponse {
+		releaseWindow(r.Account)
+	}
+}
+
 // StartCacheExpireTicker 过期数据定期检查器
 func StartCacheExpireTicker(asyncHandler func([]any)) {
 	go func() {
@@ -231,6 +365,10 @@ func cleanRequestIdCacheMap() {
 		result := value.(*session.Result)
 		if result.SendTime.Add(d).Before(time.Now()) {
 			expiredKeys = append(expiredKeys, result.SequenceId)
+			if result.ResponseTime.IsZero() {
+				// 超时未收到响应，释放发送窗口
+				releaseWindow(result.Account)
+			}
 		}
 		return true
 	})
@@ -261,7 +399,7 @@ func cleanMsgIdCacheMap() {
 
 func (f *SessionFactory) startLruSortTicker() {
 	go func() {
-		d := ConfigYml.GetDuration(f.srvName + ".tick-duration")
+		d := f.account.TickDuration
 		if d == 0 {
 			d = time.Second
 		}
@@ -279,7 +417,7 @@ func (f *SessionFactory) startLruSortTicker() {
 }
 
 func (f *SessionFactory) lruSort(cancel <-chan bool) {
-	maxConns := ConfigYml.GetInt(f.srvName + ".max-conns")
+	maxConns := f.account.MaxConns
 	var newSlice []*session.Session
 	if maxConns <= 0 {
 		maxConns = 2
@@ -294,6 +432,7 @@ func (f *SessionFactory) lruSort(cancel <-chan bool) {
 			newSlice = append(newSlice, sc) // 加入新会话列表
 		} else { // 去除关闭无效会话
 			sc.Close()
+			atomic.AddUint64(&f.counter.disconnects, 1)
 		}
 	}
 	// 使用新切片替换原列表
@@ -335,13 +474,17 @@ func (f *SessionFactory) newConnect() {
 	// 立即初始化一个连接
 	c, err := net.Dial("tcp", f.serverAddr)
 	if err == nil {
-		sc := session.NewSession(f.srvName, f.authConf, c)
+		sc := session.NewAccountSession(f.account.Name, f.srvName, f.authConf, c)
 		if sc != nil {
 			f.Lock()
 			f.sessions = append(f.sessions, sc)
 			f.Unlock()
+			atomic.AddUint64(&f.counter.connects, 1)
+		} else {
+			atomic.AddUint64(&f.counter.connectErrors, 1)
 		}
 	} else {
+		atomic.AddUint64(&f.counter.connectErrors, 1)
 		log.Error(err.Error())
 	}
 }
@@ -373,7 +516,20 @@ func (i ISP) Int() int {
 	return -1
 }
 
+func validISP(isp string) bool {
+	for _, s := range ISPS {
+		if s == strings.ToLower(isp) {
+			return true
+		}
+	}
+	return false
+}
+
+// FindAuthConf 获取客户端的认证信息，认证信息未加载或不存在时返回nil
 func FindAuthConf(isp, clientId string) (ac *codec.AuthConf) {
+	if auth.Cache == nil {
+		return nil
+	}
 	c := auth.Cache.FindByCid(isp, clientId)
 	if c == nil {
 		return nil
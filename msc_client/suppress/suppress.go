@@ -0,0 +1,123 @@
+package suppress
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Entry 退订（禁发）名单中的一条记录
+type Entry struct {
+	Phone      string    `json:"phone"`      // 手机号码
+	Account    string    `json:"account"`    // 收到退订短信的账号，手工添加时为空
+	Reason     string    `json:"reason"`     // 原因，如退订短信内容或手工备注
+	CreateTime time.Time `json:"createTime"` // 加入时间
+}
+
+// Store 退订名单存储
+type Store interface {
+	// Add 加入名单，已存在时覆盖
+	Add(e *Entry) error
+	// Remove 移出名单
+	Remove(phone string) error
+	// Get 查询号码，不在名单中时返回nil
+	Get(phone string) (*Entry, error)
+	// List 列出名单中的所有记录
+	List() ([]*Entry, error)
+	Close() error
+}
+
+// IsUnsubscribe 短信内容（忽略首尾空白、标点及大小写）是否与任一退订关键字相同
+func IsUnsubscribe(content string, keywords []string) bool {
+	content = strings.TrimFunc(content, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r)
+	})
+	for _, kw := range keywords {
+		if kw != "" && strings.EqualFold(content, kw) {
+			return true
+		}
+	}
+	return false
+}
+
+var bucket = []byte("suppression")
+
+// BoltStore 基于 bbolt 本地文件的退订名单
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// Open 打开（或创建）退订名单数据库文件
+func Open(path string) (*BoltStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 3 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucket)
+		return err
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return &BoltStore{db: db}, nil
+}
+
+func (s *BoltStore) Add(e *Entry) error {
+	if e.CreateTime.IsZero() {
+		e.CreateTime = time.Now()
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Put([]byte(e.Phone), data)
+	})
+}
+
+func (s *BoltStore) Remove(phone string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Delete([]byte(phone))
+	})
+}
+
+func (s *BoltStore) Get(phone string) (e *Entry, err error) {
+	err = s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(bucket).Get([]byte(phone))
+		if data == nil {
+			return nil
+		}
+		e = &Entry{}
+		return json.Unmarshal(data, e)
+	})
+	return e, err
+}
+
+func (s *BoltStore) List() ([]*Entry, error) {
+	var ret []*Entry
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).ForEach(func(_, data []byte) error {
+			e := &Entry{}
+			if err := json.Unmarshal(data, e); err != nil {
+				return err
+			}
+			ret = append(ret, e)
+			return nil
+		})
+	})
+	return ret, err
+}
+
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,108 @@
+package sms
+
+import (
+	"github.com/hrygo/log"
+
+	"github.com/hrygo/gosms/event_manager"
+	"github.com/hrygo/gosms/smc_client/route"
+	"github.com/hrygo/gosms/smc_client/session"
+	"github.com/hrygo/gosms/smc_client/suppress"
+)
+
+// suppression 退订名单，未启用时为nil
+var suppression suppress.Store
+
+// StartUnsubscribe 按配置文件启用退订处理（Unsubscribe.enable）：
+// 上行短信内容与账号的退订关键字相同时，发送方号码加入退订名单（Unsubscribe.path），
+// 此后发送给该号码的短信将被拒绝，发送结果的 RejectReason 为 SUPPRESSED。
+func StartUnsubscribe() error {
+	if !ConfigYml.GetBool("Unsubscribe.enable") {
+		return nil
+	}
+	path := ConfigYml.GetString("Unsubscribe.path")
+	if path == "" {
+		path = "data/suppression.db"
+	}
+	st, err := suppress.Open(BasePath + path)
+	if err != nil {
+		return err
+	}
+	suppression = st
+	session.SetInboundHook(acceptInbound)
+	event_manager.RegisterShutdownHooker("Close_Suppression", func(args ...any) {
+		_ = st.Close()
+	})
+	log.Infof("[Unsubscribe] Started with %s.", path)
+	return nil
+}
+
+// unsubscribeKeywords 账号的退订关键字
+func unsubscribeKeywords(account string) []string {
+	if a := FindAccount(account); a != nil && len(a.UnsubscribeKeywords) > 0 {
+		return a.UnsubscribeKeywords
+	}
+	kws := ConfigYml.GetStringSlice("Unsubscribe.keywords")
+	if len(kws) == 0 {
+		kws = []string{"TD", "退订"}
+	}
+	return kws
+}
+
+// onUnsubscribe 检查上行短信是否为退订短信，是则加入退订名单
+func onUnsubscribe(m *InboundMessage) error {
+	if suppression == nil || !suppress.IsUnsubscribe(m.Content, unsubscribeKeywords(m.Account)) {
+		return nil
+	}
+	e := &suppress.Entry{Phone: route.Normalize(m.Phone), Account: m.Account, Reason: m.Content}
+	if err := suppression.Add(e); err != nil {
+		log.Errorf("[Unsubscribe] Add %s error: %v", e.Phone, err)
+		return err
+	}
+	log.Warnf("[Unsubscribe] %s unsubscribed from %s with \"%s\".", e.Phone, m.Account, m.Content)
+	return nil
+}
+
+// Suppress 将号码加入退订名单
+func Suppress(phone, reason string) error {
+	if suppression == nil {
+		return ErrUnsubscribeDisabled
+	}
+	return suppression.Add(&suppress.Entry{Phone: route.Normalize(phone), Reason: reason})
+}
+
+// Unsuppress 将号码移出退订名单
+func Unsuppress(phone string) error {
+	if suppression == nil {
+		return ErrUnsubscribeDisabled
+	}
+	return suppression.Remove(route.Normalize(phone))
+}
+
+// SuppressionEntry 查询号码的退订记录，不在名单中时返回nil
+func SuppressionEntry(phone string) (*suppress.Entry, error) {
+	if suppression == nil {
+		return nil, ErrUnsubscribeDisabled
+	}
+	return suppression.Get(route.Normalize(phone))
+}
+
+// SuppressionList 列出退订名单
+func SuppressionList() ([]*suppress.Entry, error) {
+	if suppression == nil {
+		return nil, ErrUnsubscribeDisabled
+	}
+	return suppression.List()
+}
+
+// IsSuppressed 号码是否在退订名单中，查询出错时视为不在名单中
+func IsSuppressed(phone string) bool {
+	if suppression == nil {
+		return false
+	}
+	e, err := suppression.Get(route.Normalize(phone))
+	if err != nil {
+		log.Errorf("[Unsubscribe] Query %s error: %v", phone, err)
+		return false
+	}
+	return e != nil
+}
@@ -0,0 +1,64 @@
+package template
+
+import (
+	"sort"
+	"sync"
+)
+
+// Registry 模板注册表，并发安全
+type Registry struct {
+	sync.RWMutex
+	templates map[string]*Template
+}
+
+func NewRegistry() *Registry {
+	return &Registry{templates: make(map[string]*Template)}
+}
+
+// Add 校验并注册模板，相同编号的模板将被替换
+func (r *Registry) Add(t *Template) error {
+	if err := t.Compile(); err != nil {
+		return err
+	}
+	r.Lock()
+	r.templates[t.Id] = t
+	r.Unlock()
+	return nil
+}
+
+// Get 根据编号获取模板，不存在时返回nil
+func (r *Registry) Get(id string) *Template {
+	r.RLock()
+	defer r.RUnlock()
+	return r.templates[id]
+}
+
+// Remove 移除模板，返回模板是否存在
+func (r *Registry) Remove(id string) bool {
+	r.Lock()
+	defer r.Unlock()
+	_, ok := r.templates[id]
+	delete(r.templates, id)
+	return ok
+}
+
+// List 按编号排序列出所有模板
+func (r *Registry) List() []*Template {
+	r.RLock()
+	ret := make([]*Template, 0, len(r.templates))
+	for _, t := range r.templates {
+		ret = append(ret, t)
+	}
+	r.RUnlock()
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Id < ret[j].Id })
+	return ret
+}
+
+// Render 使用指定模板渲染短信内容
+func (r *Registry) Render(id string, vars map[string]any) (string, error) {
+	t := r.Get(id)
+	if t == nil {
+		return "", ErrNotFound
+	}
+	return t.Render(vars)
+}
@@ -0,0 +1,261 @@
+package template
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hrygo/gosms/utils"
+)
+
+// Type 变量类型
+type Type string
+
+const (
+	TypeString Type = "string" // 任意文本
+	TypeInt    Type = "int"    // 整数
+	TypeCode   Type = "code"   // 验证码等，仅数字及字母
+	TypeAmount Type = "amount" // 金额，最多两位小数
+	TypeDate   Type = "date"   // 日期时间，按 Format 格式化
+)
+
+// 各类型变量的默认最大长度（字符数）
+var defaultMaxLen = map[Type]int{
+	TypeString: 20,
+	TypeInt:    10,
+	TypeCode:   8,
+	TypeAmount: 12,
+}
+
+const defaultDateFormat = "2006-01-02"
+
+var (
+	ErrNotFound    = errors.New("template not found")
+	placeholderReg = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)}`)
+	codeReg        = regexp.MustCompile(`^[0-9A-Za-z]+$`)
+	intReg         = regexp.MustCompile(`^-?[0-9]+$`)
+	amountReg      = regexp.MustCompile(`^-?[0-9]+(\.[0-9]{1,2})?$`)
+)
+
+// Var 模板变量
+type Var struct {
+	Name     string `mapstructure:"name" json:"name"`
+	Type     Type   `mapstructure:"type" json:"type"`         // 变量类型，默认 string
+	MaxLen   int    `mapstructure:"max-len" json:"maxLen"`    // 最大长度（字符数），0 时使用类型的默认值
+	Optional bool   `mapstructure:"optional" json:"optional"` // 可选变量，未提供时替换为空
+	Format   string `mapstructure:"format" json:"format"`     // date 类型的格式，默认 2006-01-02
+}
+
+// Template 短信模板，内容中以 ${name} 表示变量，不含签名
+type Template struct {
+	Id      string `mapstructure:"id" json:"id"`
+	Content string `mapstructure:"content" json:"content"`
+	Vars    []Var  `mapstructure:"vars" json:"vars"`
+	Tag     string `mapstructure:"tag" json:"tag"` // 短信类别，使用模板发送时作为业务标签，用于路由及发送时段策略
+
+	parts    []string // 按变量拆分的固定文本，len(parts) == len(refs)+1
+	refs     []int    // 各占位符对应的变量下标
+	mu       sync.Mutex
+	segments map[string]int // 签名 => 最大分段数
+}
+
+// Compile 校验模板并解析占位符，模板内容中的变量须全部声明，声明的变量须被使用
+func (t *Template) Compile() error {
+	if t.Id == "" {
+		return errors.New("template id is required")
+	}
+	if strings.TrimSpace(t.Content) == "" {
+		return fmt.Errorf("template %s: content is required", t.Id)
+	}
+	index := make(map[string]int, len(t.Vars))
+	for i := range t.Vars {
+		v := &t.Vars[i]
+		if v.Type == "" {
+			v.Type = TypeString
+		}
+		if v.Type == TypeDate {
+			if v.Format == "" {
+				v.Format = defaultDateFormat
+			}
+			if v.MaxLen == 0 {
+				v.MaxLen = utf8.RuneCountInString(v.Format)
+			}
+		} else if _, ok := defaultMaxLen[v.Type]; !ok {
+			return fmt.Errorf("template %s: var %s: unknown type %s", t.Id, v.Name, v.Type)
+		}
+		if v.MaxLen == 0 {
+			v.MaxLen = defaultMaxLen[v.Type]
+		}
+		if v.MaxLen < 0 {
+			return fmt.Errorf("template %s: var %s: invalid max-len %d", t.Id, v.Name, v.MaxLen)
+		}
+		if _, ok := index[v.Name]; ok {
+			return fmt.Errorf("template %s: duplicate var %s", t.Id, v.Name)
+		}
+		index[v.Name] = i
+	}
+
+	var parts []string
+	var refs []int
+	used := make(map[string]bool, len(index))
+	last := 0
+	for _, loc := range placeholderReg.FindAllStringSubmatchIndex(t.Content, -1) {
+		name := t.Content[loc[2]:loc[3]]
+		i, ok := index[name]
+		if !ok {
+			return fmt.Errorf("template %s: undeclared var %s", t.Id, name)
+		}
+		used[name] = true
+		parts = append(parts, t.Content[last:loc[0]])
+		refs = append(refs, i)
+		last = loc[1]
+	}
+	parts = append(parts, t.Content[last:])
+	for _, v := range t.Vars {
+		if !used[v.Name] {
+			return fmt.Errorf("template %s: unused var %s", t.Id, v.Name)
+		}
+	}
+
+	t.mu.Lock()
+	t.parts, t.refs, t.segments = parts, refs, make(map[string]int)
+	t.mu.Unlock()
+	return nil
+}
+
+// Render 校验变量并替换占位符，返回不含签名的短信内容
+func (t *Template) Render(vars map[string]any) (string, error) {
+	values := make([]string, len(t.Vars))
+	for i, v := range t.Vars {
+		a, ok := vars[v.Name]
+		if !ok || a == nil {
+			if !v.Optional {
+				return "", fmt.Errorf("template %s: var %s is required", t.Id, v.Name)
+			}
+			continue
+		}
+		s, err := v.format(a)
+		if err != nil {
+			return "", fmt.Errorf("template %s: var %s: %v", t.Id, v.Name, err)
+		}
+		if utf8.RuneCountInString(s) > v.MaxLen {
+			return "", fmt.Errorf("template %s: var %s exceeds %d characters", t.Id, v.Name, v.MaxLen)
+		}
+		values[i] = s
+	}
+	for name := range vars {
+		if !t.declared(name) {
+			return "", fmt.Errorf("template %s: unknown var %s", t.Id, name)
+		}
+	}
+	return t.fill(func(i int) string { return values[i] }), nil
+}
+
+// Segments 添加签名后，变量取最大长度时的分段（计费）条数，结果按签名缓存
+func (t *Template) Segments(signature string) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if n, ok := t.segments[signature]; ok {
+		return n
+	}
+	// 文本变量可能含中文，按 UCS2 编码估算
+	content := signature + t.fill(func(i int) string {
+		v := t.Vars[i]
+		if v.Type == TypeString {
+			return strings.Repeat("中", v.MaxLen)
+		}
+		return strings.Repeat("0", v.MaxLen)
+	})
+	n := SegmentCount(content)
+	t.segments[signature] = n
+	return n
+}
+
+// SegmentCount 短信内容按协议编码拆分后的条数
+func SegmentCount(content string) int {
+	return len(utils.MsgSlices(utils.MsgFmt(content), content))
+}
+
+func (t *Template) fill(value func(i int) string) string {
+	var sb strings.Builder
+	for i, p := range t.parts {
+		sb.WriteString(p)
+		if i < len(t.refs) {
+			sb.WriteString(value(t.refs[i]))
+		}
+	}
+	return sb.String()
+}
+
+func (t *Template) declared(name string) bool {
+	for _, v := range t.Vars {
+		if v.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// format 按变量类型校验并格式化变量值，数值类型也接受字符串形式
+func (v *Var) format(a any) (string, error) {
+	switch v.Type {
+	case TypeInt:
+		switch n := a.(type) {
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+			return fmt.Sprint(n), nil
+		case float64:
+			if n != math.Trunc(n) {
+				return "", fmt.Errorf("%v is not an integer", n)
+			}
+			return strconv.FormatFloat(n, 'f', 0, 64), nil
+		case string:
+			if intReg.MatchString(n) {
+				return n, nil
+			}
+		}
+		return "", fmt.Errorf("%v is not an integer", a)
+	case TypeAmount:
+		switch n := a.(type) {
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+			return fmt.Sprint(n), nil
+		case float32:
+			return strconv.FormatFloat(float64(n), 'f', 2, 32), nil
+		case float64:
+			return strconv.FormatFloat(n, 'f', 2, 64), nil
+		case string:
+			if amountReg.MatchString(n) {
+				return n, nil
+			}
+		}
+		return "", fmt.Errorf("%v is not an amount", a)
+	case TypeDate:
+		switch d := a.(type) {
+		case time.Time:
+			return d.Format(v.Format), nil
+		case string:
+			if _, err := time.ParseInLocation(v.Format, d, time.Local); err == nil {
+				return d, nil
+			}
+		}
+		return "", fmt.Errorf("%v does not match format %s", a, v.Format)
+	case TypeCode:
+		s := fmt.Sprint(a)
+		if !codeReg.MatchString(s) {
+			return "", fmt.Errorf("%v is not a code", a)
+		}
+		return s, nil
+	}
+	switch s := a.(type) {
+	case string:
+		return s, nil
+	case fmt.Stringer:
+		return s.String(), nil
+	}
+	return "", fmt.Errorf("%v is not a string", a)
+}
@@ -0,0 +1,85 @@
+package sms
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/hrygo/log"
+
+	"github.com/hrygo/gosms/codec"
+	"github.com/hrygo/gosms/smc_client/template"
+)
+
+var (
+	templates    *template.Registry
+	templateOnce sync.Once
+)
+
+// Templates 短信模板注册表，首次调用时加载配置文件中的 Templates，也可在运行时添加模板
+func Templates() *template.Registry {
+	templateOnce.Do(loadTemplates)
+	return templates
+}
+
+// loadTemplates 加载配置文件中的模板，并预先计算各账号签名下的最大分段数
+func loadTemplates() {
+	templates = template.NewRegistry()
+	var list []*template.Template
+	if err := ConfigYml.Viper().UnmarshalKey("Templates", &list); err != nil {
+		log.Errorf("[Template] Templates config error: %v", err)
+		return
+	}
+	for _, t := range list {
+		if err := templates.Add(t); err != nil {
+			log.Errorf("[Template] %v", err)
+			continue
+		}
+		for _, a := range Accounts() {
+			log.Debugf("[Template] %s: %d segment(s) with account %s.", t.Id, t.Segments(a.Signature), a.Name)
+		}
+	}
+}
+
+// RenderTemplate 使用模板及变量生成短信内容（不含签名）
+func RenderTemplate(templateId string, vars map[string]any) (string, error) {
+	return Templates().Render(templateId, vars)
+}
+
+// TemplateSegments 模板经指定账号发送时的最大分段（计费）条数
+func TemplateSegments(templateId, account string) (int, error) {
+	t := Templates().Get(templateId)
+	if t == nil {
+		return 0, template.ErrNotFound
+	}
+	return t.Segments(signature(account)), nil
+}
+
+// SendTemplate 使用模板发送短信，变量校验失败时返回错误，签名按实际发送账号添加，业务标签为模板的 Tag
+func SendTemplate(templateId string, vars map[string]any, phones []string, options ...codec.OptionFunc) (queryId int64, err error) {
+	t := Templates().Get(templateId)
+	if t == nil {
+		return 0, template.ErrNotFound
+	}
+	content, err := t.Render(vars)
+	if err != nil {
+		return 0, err
+	}
+	return SendMessage(&Message{Content: content, Phones: phones, Tag: t.Tag, Options: options}), nil
+}
+
+// signature 账号配置的短信签名
+func signature(account string) string {
+	if a := FindAccount(account); a != nil {
+		return a.Signature
+	}
+	return ""
+}
+
+// signContent 为短信内容添加账号签名，内容已以【开头（已有签名）时不再添加
+func signContent(account, content string) string {
+	sign := signature(account)
+	if sign == "" || strings.HasPrefix(content, "【") {
+		return content
+	}
+	return sign + content
+}
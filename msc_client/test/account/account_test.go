@@ -0,0 +1,70 @@
+package account_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	sms "github.com/hrygo/gosms/smc_client"
+)
+
+// 须在 SetAccounts 之前执行，使用配置文件中的账号
+func TestLoadAccounts(t *testing.T) {
+	assert.Len(t, sms.Accounts(), 3)
+	for _, isp := range sms.ISPS {
+		a := sms.FindAccount(isp)
+		if assert.NotNil(t, a, isp) {
+			assert.Equal(t, isp, a.ISP)
+			assert.Equal(t, 1, a.Weight)
+			assert.Equal(t, []*sms.Account{a}, sms.ResolveAccounts(isp))
+		}
+	}
+	assert.Equal(t, "123456", sms.FindAccount("CMPP").ClientId)
+}
+
+func TestResolveAccounts(t *testing.T) {
+	sms.SetAccounts([]*sms.Account{
+		{Name: "cmpp", ISP: "cmpp", ClientId: "123456"},
+		{Name: "CMPP-A", ISP: "CMPP", ClientId: "123457", Groups: []string{"marketing", "cmpp"}},
+		{Name: "cmpp-b", ISP: "cmpp", ClientId: "123458", Weight: 3, Groups: []string{"Marketing"}},
+		{Name: "cmpp-a", ISP: "cmpp", ClientId: "123459"},
+		{Name: "intl", ISP: "http", ClientId: "1"},
+		{Name: "", ISP: "smgp", ClientId: "1"},
+	})
+
+	assert.Len(t, sms.Accounts(), 3)
+	assert.Nil(t, sms.FindAccount("intl"))
+	assert.Equal(t, "123457", sms.FindAccount("cmpp-a").ClientId)
+
+	// 分组优先于同名账号，旧版的 cmpp 账号与 cmpp 协议的其他账号一起负载均衡
+	assert.ElementsMatch(t, []string{"cmpp", "cmpp-a", "cmpp-b"}, names(sms.ResolveAccounts("cmpp")))
+	assert.ElementsMatch(t, []string{"cmpp-a", "cmpp-b"}, names(sms.ResolveAccounts("MARKETING")))
+	assert.Equal(t, []string{"cmpp-b"}, names(sms.ResolveAccounts("cmpp-b")))
+	assert.Empty(t, sms.ResolveAccounts("smgp"))
+	assert.Empty(t, sms.ResolveAccounts("unknown"))
+}
+
+func TestWeightedOrder(t *testing.T) {
+	sms.SetAccounts([]*sms.Account{
+		{Name: "a", ISP: "sgip", Weight: 1},
+		{Name: "b", ISP: "sgip", Weight: 3},
+	})
+
+	first := map[string]int{}
+	for i := 0; i < 4000; i++ {
+		list := names(sms.ResolveAccounts("sgip"))
+		assert.ElementsMatch(t, []string{"a", "b"}, list)
+		first[list[0]]++
+	}
+	// 按权重 1:3 排在首位，期望值 1000:3000
+	assert.InDelta(t, 1000, first["a"], 200)
+	assert.InDelta(t, 3000, first["b"], 200)
+}
+
+func names(list []*sms.Account) []string {
+	ret := make([]string, 0, len(list))
+	for _, a := range list {
+		ret = append(ret, a.Name)
+	}
+	return ret
+}
@@ -0,0 +1,93 @@
+package bench_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hrygo/gosms/smc_client"
+	"github.com/hrygo/gosms/smc_client/bench"
+	"github.com/hrygo/gosms/smc_client/session"
+)
+
+func TestLatency_Summary(t *testing.T) {
+	var l bench.Latency
+	assert.Equal(t, 0, l.Summary().Count)
+	for i := 100; i > 0; i-- {
+		l.Add(time.Duration(i) * time.Millisecond)
+	}
+	s := l.Summary()
+	assert.Equal(t, 100, s.Count)
+	assert.Equal(t, 1.0, s.Min)
+	assert.Equal(t, 50.5, s.Mean)
+	assert.Equal(t, 50.0, s.P50)
+	assert.Equal(t, 90.0, s.P90)
+	assert.Equal(t, 99.0, s.P99)
+	assert.Equal(t, 100.0, s.P999)
+	assert.Equal(t, 100.0, s.Max)
+}
+
+func TestRunner(t *testing.T) {
+	snapshots := [][]sms.FactoryStat{
+		{{Account: "cmpp", Connects: 1}},
+		{{Account: "cmpp", Connects: 3, Disconnects: 1}},
+	}
+	var r *bench.Runner
+	r = bench.New(bench.Config{
+		Accounts:    []string{"cmpp"},
+		TPS:         200,
+		Concurrency: 2,
+		Duration:    time.Minute,
+		Count:       40,
+		Drain:       time.Second,
+		Content:     "hello",
+		// 每 10 条中的 1 条被拒绝，其余拆分为 2 条提交
+		Send: func(_ context.Context, m *sms.Message) (int64, error) {
+			if strings.HasSuffix(m.ClientMsgId, "0") {
+				return 0, nil
+			}
+			for i := 0; i < 2; i++ {
+				now := time.Now()
+				res := &session.Result{ClientMsgId: m.ClientMsgId, Account: m.Account, Phone: m.Phones[0], SendTime: now}
+				res.ResponseTime = now.Add(time.Millisecond)
+				r.OnResult(session.EventResponse, res)
+				res.ReportTime, res.Report = now.Add(2*time.Millisecond), "DELIVRD"
+				r.OnResult(session.EventReport, res)
+			}
+			// 其他消息的结果不计入
+			r.OnResult(session.EventResponse, &session.Result{ClientMsgId: "other"})
+			return 1, nil
+		},
+		Query: func(queryId int64) []any {
+			if queryId == 0 {
+				return []any{&session.Result{RejectReason: sms.RejectNoRoute}}
+			}
+			return []any{&session.Result{}, &session.Result{}}
+		},
+		Stats: func() []sms.FactoryStat {
+			s := snapshots[0]
+			snapshots = snapshots[1:]
+			return s
+		},
+	})
+
+	rp := r.Run(context.Background())
+	assert.Equal(t, uint64(36), rp.Submitted)
+	assert.Equal(t, uint64(72), rp.Segments)
+	assert.Equal(t, uint64(4), rp.Rejected)
+	assert.Equal(t, rp.Segments, rp.Responses)
+	assert.Equal(t, rp.Segments, rp.Reports)
+	assert.Equal(t, rp.Segments, rp.ResponseCodes["cmpp:0"])
+	assert.Equal(t, rp.Segments, rp.ReportStats["DELIVRD"])
+	assert.Equal(t, 1.0, rp.Response.P50)
+	assert.Equal(t, uint64(2), rp.Sessions[0].Connects)
+	assert.Equal(t, uint64(1), rp.Sessions[0].Disconnects)
+
+	var buf bytes.Buffer
+	rp.Print(&buf)
+	assert.Contains(t, buf.String(), "cmpp:0=")
+}
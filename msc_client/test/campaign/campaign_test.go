@@ -0,0 +1,123 @@
+package campaign_test
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hrygo/gosms/smc_client"
+	"github.com/hrygo/gosms/smc_client/campaign"
+	"github.com/hrygo/gosms/smc_client/gateway"
+	"github.com/hrygo/gosms/smc_client/session"
+)
+
+const recipients = `phone,name,code
+13800001111,张三,1234
++86 138-0000-2222,李四,5678
+13800001111,张三,1234
+12345,王五,0000
+18600001111,赵六,
+`
+
+func TestReadCSV_Check(t *testing.T) {
+	rs, err := campaign.ReadCSV(strings.NewReader(recipients))
+	assert.NoError(t, err)
+	assert.Len(t, rs, 5)
+	assert.Equal(t, "张三", rs[0].Vars["name"])
+	assert.Equal(t, 2, rs[0].Line)
+
+	valid, skipped := campaign.Check(rs)
+	assert.Len(t, valid, 3)
+	assert.Equal(t, "13800002222", valid[1].Phone)
+	assert.Len(t, skipped, 2)
+	assert.Equal(t, campaign.StatusDuplicate, skipped[0].Status)
+	assert.Equal(t, campaign.StatusInvalid, skipped[1].Status)
+
+	_, err = campaign.ReadCSV(strings.NewReader("mobile,name\n13800001111,a\n"))
+	assert.Error(t, err)
+}
+
+func TestReadJSON(t *testing.T) {
+	rs, err := campaign.ReadJSON(strings.NewReader(`[
+		{"phone": "13800001111", "vars": {"code": 123456}},
+		{"phone": 13800002222, "name": "李四"}
+	]`))
+	assert.NoError(t, err)
+	assert.Len(t, rs, 2)
+	assert.Equal(t, "123456", rs[0].Vars["code"])
+	assert.Equal(t, "13800002222", rs[1].Phone)
+	assert.Equal(t, "李四", rs[1].Vars["name"])
+}
+
+func TestCampaign_Resume(t *testing.T) {
+	dir := t.TempDir()
+	rs, _ := campaign.ReadCSV(strings.NewReader(recipients))
+	valid, skipped := campaign.Check(rs)
+
+	var sent []string
+	results := make(map[int64][]any)
+	ctx, cancel := context.WithCancel(context.Background())
+	conf := campaign.Config{
+		Id: "c1",
+		Content: func(r *campaign.Recipient) (string, error) {
+			if r.Vars["code"] == "" {
+				return "", errors.New("code is required")
+			}
+			return "验证码" + r.Vars["code"], nil
+		},
+		Wait: time.Second,
+		Send: func(_ context.Context, m *sms.Message) (int64, error) {
+			sent = append(sent, m.Phones[0])
+			id := int64(len(sent))
+			now := time.Now()
+			results[id] = []any{&session.Result{Phone: m.Phones[0], ClientMsgId: m.ClientMsgId, SendTime: now, ResponseTime: now, MsgId: "m", Report: "DELIVRD"}}
+			// 提交第一个号码后中断
+			cancel()
+			return id, nil
+		},
+		Query: func(queryId int64) []any { return results[queryId] },
+		Check: func(string, *sms.Message) string { return "" },
+	}
+
+	state, err := campaign.OpenState(filepath.Join(dir, "c1.state"))
+	assert.NoError(t, err)
+	n, err := campaign.New(conf, state).Run(ctx, valid)
+	assert.Equal(t, 1, n)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.NoError(t, state.Close())
+
+	// 重新执行时跳过已提交的号码
+	state, err = campaign.OpenState(filepath.Join(dir, "c1.state"))
+	assert.NoError(t, err)
+	c := campaign.New(conf, state)
+	n, err = c.Run(context.Background(), valid)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []string{"13800001111", "13800002222"}, sent)
+
+	done := c.WaitReports(context.Background())
+	assert.Len(t, done, 3)
+	summary := campaign.Summary(skipped, done)
+	assert.Equal(t, 2, summary[gateway.StatusDelivered])
+	assert.Equal(t, 1, summary[campaign.StatusBadVars])
+
+	out := filepath.Join(dir, "c1.result.csv")
+	assert.NoError(t, campaign.WriteResult(out, skipped, done))
+	assert.NoError(t, state.Close())
+	f, err := os.Open(out)
+	assert.NoError(t, err)
+	defer func() { _ = f.Close() }()
+	rows, err := csv.NewReader(f).ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, rows, 6)
+	assert.Equal(t, []string{"2", "13800001111", gateway.StatusDelivered}, rows[1][:3])
+	assert.Equal(t, "c1-13800001111", rows[1][5])
+	assert.Equal(t, []string{"1", "0", "m", "DELIVRD"}, rows[1][6:])
+}
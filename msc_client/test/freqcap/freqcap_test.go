@@ -0,0 +1,82 @@
+package freqcap_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hrygo/gosms/smc_client/freqcap"
+)
+
+func testLimiter(t *testing.T, counter freqcap.Counter) {
+	ctx := context.Background()
+	now := time.Date(2022, 8, 12, 10, 0, 30, 0, time.Local)
+	l := freqcap.NewLimiter(counter, []freqcap.Rule{
+		{Window: time.Minute, Max: 2},
+		{Window: time.Hour, Max: 3},
+		{Window: 24 * time.Hour, Max: 0}, // 不限制
+	}, time.Minute).WithClock(func() time.Time { return now })
+
+	allow := func(phone, content, want string) {
+		reason, err := l.Allow(ctx, phone, content)
+		assert.NoError(t, err)
+		assert.Equal(t, want, reason, content)
+	}
+	allow("13800001111", "验证码1", "")
+	allow("13800001111", "验证码1", freqcap.Duplicate)
+	allow("13800001111", "验证码2", "")
+	allow("13800001111", "验证码3", freqcap.Frequency)
+	// 被拒绝的发送不计数，也不占用重复检查
+	allow("13800002222", "验证码3", "")
+	allow("13800002222", "验证码3", freqcap.Duplicate)
+	// 窗口按自然分钟对齐，下一分钟重新计数，小时窗口仍累计
+	now = now.Add(30 * time.Second)
+	allow("13800001111", "验证码4", "")
+	allow("13800001111", "验证码5", freqcap.Frequency)
+
+	d := freqcap.NewLimiter(counter, nil, 0).WithClock(func() time.Time { return now })
+	for i := 0; i < 3; i++ {
+		reason, err := d.Allow(ctx, "13800003333", "same")
+		assert.NoError(t, err)
+		assert.Empty(t, reason)
+	}
+}
+
+func TestMemoryCounter(t *testing.T) {
+	c := freqcap.NewMemoryCounter()
+	ctx := context.Background()
+	n, _ := c.Incr(ctx, "k", 20*time.Millisecond)
+	assert.Equal(t, int64(1), n)
+	n, _ = c.Incr(ctx, "k", 20*time.Millisecond)
+	assert.Equal(t, int64(2), n)
+	time.Sleep(30 * time.Millisecond)
+	n, _ = c.Incr(ctx, "k", time.Minute)
+	assert.Equal(t, int64(1), n)
+
+	ok, _ := c.SetNX(ctx, "d", time.Minute)
+	assert.True(t, ok)
+	ok, _ = c.SetNX(ctx, "d", time.Minute)
+	assert.False(t, ok)
+	assert.NoError(t, c.Del(ctx, "d"))
+	ok, _ = c.SetNX(ctx, "d", time.Minute)
+	assert.True(t, ok)
+
+	testLimiter(t, freqcap.NewMemoryCounter())
+}
+
+func TestRedisCounter(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	c := freqcap.NewRedisCounter(client, "gosms:")
+	defer func() { _ = c.Close() }()
+
+	testLimiter(t, c)
+	assert.NotEmpty(t, mr.Keys())
+	for _, k := range mr.Keys() {
+		assert.True(t, mr.TTL(k) > 0, k)
+	}
+}
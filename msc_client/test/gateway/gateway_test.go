@@ -0,0 +1,169 @@
+package gateway_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hrygo/gosms/codec"
+	sms "github.com/hrygo/gosms/smc_client"
+	"github.com/hrygo/gosms/smc_client/gateway"
+	"github.com/hrygo/gosms/smc_client/session"
+)
+
+func u8(v uint8) *uint8 { return &v }
+
+func TestOptions_MtOptions(t *testing.T) {
+	o := &gateway.Options{
+		NeedReport: u8(1),
+		MsgLevel:   u8(9),
+		AtTime:     "220801090000",
+		ValidTime:  "2022-08-02T09:00:00Z",
+		SpSubNo:    "01",
+		FeeType:    "02",
+		FeeCode:    "10",
+	}
+	ops, err := o.MtOptions()
+	assert.NoError(t, err)
+	mt := codec.LoadMtOptions(ops...)
+	assert.Equal(t, uint8(1), mt.NeedReport)
+	assert.Equal(t, uint8(9), mt.MsgLevel)
+	assert.Equal(t, "220801090000032+", mt.AtTime)
+	assert.Len(t, mt.ValidTime, 16)
+	assert.Equal(t, "01", mt.SpSubNo)
+	assert.Equal(t, "02", mt.FeeType)
+	assert.Equal(t, "10", mt.FeeCode)
+
+	for _, bad := range []*gateway.Options{
+		{NeedReport: u8(2)},
+		{MsgLevel: u8(10)},
+		{AtTime: "tomorrow"},
+		{FeeType: "06"},
+		{FeeUsertype: u8(4)},
+		{SpSubNo: "0a"},
+	} {
+		_, err = bad.MtOptions()
+		assert.Error(t, err)
+	}
+}
+
+func TestNewSendResponse(t *testing.T) {
+	now := time.Now()
+	results := []any{
+		&session.Result{Phone: "13800001111", SequenceId: 1, SendTime: now, ResponseTime: now, MsgId: "a", Report: "DELIVRD"},
+		&session.Result{Phone: "13800001111", SequenceId: 2, SendTime: now, ResponseTime: now, MsgId: "b"},
+		&session.Result{Phone: "13300001111", SequenceId: 3, SendTime: now},
+		&session.Result{Phone: "18600001111", RejectReason: "SUPPRESSED", SendTime: now},
+		&session.Result{Phone: "18600002222", RejectReason: sms.RejectDropped, SendTime: now},
+	}
+	resp := gateway.NewSendResponse(1, "c1", []string{"13800001111", "13300001111", "18600001111", "18900001111"}, results)
+	assert.Len(t, resp.Phones, 5)
+	assert.Equal(t, gateway.StatusResponded, resp.Phones[0].Status)
+	assert.Len(t, resp.Phones[0].Segments, 2)
+	assert.Equal(t, gateway.StatusSubmitted, resp.Phones[1].Status)
+	assert.Equal(t, gateway.StatusRejected, resp.Phones[2].Status)
+	assert.Equal(t, gateway.StatusQueued, resp.Phones[3].Status)
+	assert.Equal(t, gateway.StatusDropped, resp.Phones[4].Status)
+
+	results[1].(*session.Result).Report = "UNDELIV"
+	resp = gateway.NewSendResponse(1, "c1", nil, results[:2])
+	assert.Equal(t, gateway.StatusUndelivered, resp.Phones[0].Status)
+
+	data, err := json.Marshal(resp)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"queryId":"1"`)
+}
+
+func TestNotifier(t *testing.T) {
+	var calls int32
+	done := make(chan *http.Request, 1)
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		body, _ = io.ReadAll(r.Body)
+		done <- r
+	}))
+	defer srv.Close()
+
+	n := gateway.NewNotifier(time.Second, 3, 10*time.Millisecond)
+	assert.Error(t, n.Register(gateway.Webhook{URL: "ftp://example.com"}))
+	assert.NoError(t, n.Register(gateway.Webhook{URL: srv.URL, Secret: "s3cret"}))
+	assert.Len(t, n.List(), 1)
+
+	n.OnResult(session.EventResponse, &session.Result{MsgId: "ignored"})
+	n.OnResult(session.EventReport, &session.Result{QueryId: 7, Phone: "13800001111", MsgId: "m1", Report: "DELIVRD"})
+
+	select {
+	case r := <-done:
+		ts, err := strconv.ParseInt(r.Header.Get(gateway.HeaderTimestamp), 10, 64)
+		assert.NoError(t, err)
+		assert.Equal(t, gateway.Sign("s3cret", ts, body), r.Header.Get(gateway.HeaderSignature))
+		var e gateway.ReportEvent
+		assert.NoError(t, json.Unmarshal(body, &e))
+		assert.Equal(t, "report", e.Event)
+		assert.Equal(t, int64(7), e.QueryId)
+		assert.Equal(t, "m1", e.MsgId)
+	case <-time.After(3 * time.Second):
+		t.Fatal("webhook not delivered")
+	}
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	assert.True(t, n.Unregister(srv.URL))
+	assert.False(t, n.Unregister(srv.URL))
+}
+
+func TestServer_Handler(t *testing.T) {
+	gw, err := gateway.New(gateway.Config{Token: "t0ken", MaxBatch: 1})
+	assert.NoError(t, err)
+	h := gw.Handler()
+
+	do := func(method, path, body string, auth bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if auth {
+			req.Header.Set("Authorization", "Bearer t0ken")
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, do(http.MethodGet, "/v1/webhooks", "", false).Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, do(http.MethodGet, "/v1/messages", "", true).Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/v1/messages", `{"content":"hi"}`, true).Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/v1/messages",
+		`{"content":"hi","phones":["13800001111"],"options":{"needReport":3}}`, true).Code)
+	// 未启用幂等发送
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/v1/messages",
+		`{"content":"hi","phones":["13800001111"],"idempotencyKey":"k1"}`, true).Code)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, do(http.MethodPost, "/v1/messages/batch",
+		`{"messages":[{"content":"a"},{"content":"b"}]}`, true).Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/v1/messages/abc", "", true).Code)
+
+	assert.Equal(t, http.StatusNoContent, do(http.MethodPost, "/v1/webhooks", `{"url":"http://127.0.0.1:9/r","secret":"x"}`, true).Code)
+	w := do(http.MethodGet, "/v1/webhooks", "", true)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"secret":"******"`)
+	assert.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/v1/webhooks?url=http://127.0.0.1:9/r", "", true).Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodDelete, "/v1/webhooks?url=http://127.0.0.1:9/r", "", true).Code)
+}
+
+func TestSend_Dropped(t *testing.T) {
+	// 账号的认证信息不存在，号码有路由但无可用会话，未启用发件箱时消息被丢弃
+	sms.SetAccounts([]*sms.Account{{Name: "gateway-test", ISP: "cmpp", ClientId: "000000"}})
+	sms.SetRouter(sms.RouterFunc(func(string, *sms.Message) string { return "gateway-test" }))
+	phones := []string{"13800001111"}
+	id := sms.SendMessage(&sms.Message{Content: "hello", Phones: phones})
+	resp := gateway.NewSendResponse(id, "", phones, sms.Query(id))
+	assert.Equal(t, gateway.StatusDropped, resp.Phones[0].Status)
+	assert.Equal(t, sms.RejectDropped, resp.Phones[0].RejectReason)
+}
@@ -0,0 +1,34 @@
+package idempotency_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	sms "github.com/hrygo/gosms/smc_client"
+	"github.com/hrygo/gosms/smc_client/idempotency"
+)
+
+func TestSendMessageKey_Retry(t *testing.T) {
+	// 账号的认证信息不存在，号码有路由但无可用会话，等待发送直到 ctx 结束
+	sms.SetAccounts([]*sms.Account{{Name: "idem-test", ISP: "cmpp", ClientId: "000000"}})
+	sms.SetRouter(sms.RouterFunc(func(string, *sms.Message) string { return "idem-test" }))
+	sms.SetIdempotencyStore(idempotency.NewMemoryStore(), time.Minute)
+
+	send := func() (int64, bool, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		return sms.SendMessageKeyWait(ctx, "order-1", &sms.Message{Content: "hello", Phones: []string{"13800001111"}})
+	}
+	id1, dup, err := send()
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.False(t, dup)
+
+	// 首次发送失败后释放幂等键，使用相同的幂等键重试时重新发送
+	id2, dup, err := send()
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.False(t, dup)
+	assert.NotEqual(t, id1, id2)
+}
@@ -0,0 +1,79 @@
+package idempotency_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hrygo/gosms/smc_client/idempotency"
+)
+
+func testStore(t *testing.T, st idempotency.Store, expire func(d time.Duration)) {
+	ctx := context.Background()
+	id, ok, err := st.Reserve(ctx, "order-1", 100, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, int64(100), id)
+
+	id, ok, err = st.Reserve(ctx, "order-1", 101, time.Minute)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, int64(100), id)
+
+	// 仅释放仍对应该查询编号的幂等键
+	require.NoError(t, st.Release(ctx, "order-1", 101))
+	_, ok, _ = st.Reserve(ctx, "order-1", 102, time.Minute)
+	assert.False(t, ok)
+	require.NoError(t, st.Release(ctx, "order-1", 100))
+	id, ok, err = st.Reserve(ctx, "order-1", 102, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, int64(102), id)
+
+	// 并发的重复请求只有一个成功
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	winners := 0
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, ok, err := st.Reserve(ctx, "order-2", int64(200+i), time.Minute)
+			assert.NoError(t, err)
+			if ok {
+				mu.Lock()
+				winners++
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, 1, winners)
+
+	// 过期后可再次使用
+	_, _, err = st.Reserve(ctx, "order-3", 300, 50*time.Millisecond)
+	require.NoError(t, err)
+	expire(100 * time.Millisecond)
+	id, ok, err = st.Reserve(ctx, "order-3", 301, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, int64(301), id)
+	assert.NoError(t, st.Close())
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, idempotency.NewMemoryStore(), time.Sleep)
+}
+
+func TestRedisStore(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	testStore(t, idempotency.NewRedisStore(client, "test:"), mr.FastForward)
+	assert.True(t, mr.Exists("test:idem:order-1"))
+}
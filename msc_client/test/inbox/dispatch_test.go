@@ -0,0 +1,47 @@
+package inbox_test
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sms "github.com/hrygo/gosms/smc_client"
+	"github.com/hrygo/gosms/smc_client/inbox"
+	"github.com/hrygo/gosms/smc_client/session"
+)
+
+func TestDispatch_HandleAfterStart(t *testing.T) {
+	dir := t.TempDir()
+	q, err := inbox.Open(filepath.Join(dir, "inbox.db"))
+	require.NoError(t, err)
+	_, err = q.Push(&session.InboundMessage{Id: "1", ISP: "cmpp", Account: "cmpp", Content: "hello", ReceiveTime: time.Now()})
+	require.NoError(t, err)
+	require.NoError(t, q.Close())
+
+	base := sms.BasePath
+	sms.BasePath = dir + "/"
+	sms.ConfigYml.Viper().Set("Inbound.enable", true)
+	sms.ConfigYml.Viper().Set("Inbound.path", "inbox.db")
+	require.NoError(t, sms.StartInbound())
+	sms.BasePath = base
+
+	// 启动时尚未注册处理器，上次退出时未处理的消息保留至注册后分发
+	time.Sleep(50 * time.Millisecond)
+	var mu sync.Mutex
+	var got []string
+	sms.HandleInbound("dispatch-test", sms.InboundFilter{}, func(m *sms.InboundMessage) error {
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, m.Id)
+		return nil
+	})
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(got) == 1 && got[0] == "1"
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,63 @@
+package inbox_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hrygo/gosms/smc_client/inbox"
+	"github.com/hrygo/gosms/smc_client/session"
+)
+
+func TestFilter_Match(t *testing.T) {
+	m := &session.InboundMessage{SubNo: "0011", Content: "  td 退订"}
+	assert.True(t, inbox.Filter{}.Match(m))
+	assert.True(t, inbox.Filter{SubNo: "001"}.Match(m))
+	assert.False(t, inbox.Filter{SubNo: "002"}.Match(m))
+	assert.True(t, inbox.Filter{Keywords: []string{"TD", "退订"}}.Match(m))
+	assert.False(t, inbox.Filter{Keywords: []string{"退订"}}.Match(m))
+	assert.False(t, inbox.Filter{SubNo: "002", Keywords: []string{"TD"}}.Match(m))
+}
+
+func TestQueue(t *testing.T) {
+	q, err := inbox.Open(filepath.Join(t.TempDir(), "inbox.db"))
+	assert.NoError(t, err)
+	defer func() { _ = q.Close() }()
+
+	now := time.Now()
+	m1 := &session.InboundMessage{Id: "1", ISP: "cmpp", Content: "hello", ReceiveTime: now.Add(time.Second)}
+	m2 := &session.InboundMessage{Id: "2", ISP: "cmpp", Content: "TD", ReceiveTime: now}
+	for _, m := range []*session.InboundMessage{m1, m2} {
+		dup, err := q.Push(m)
+		assert.NoError(t, err)
+		assert.False(t, dup)
+	}
+	dup, err := q.Push(m1)
+	assert.NoError(t, err)
+	assert.True(t, dup)
+
+	rs, err := q.List()
+	assert.NoError(t, err)
+	assert.Len(t, rs, 2)
+	assert.Equal(t, "2", rs[0].Message.Id)
+
+	err = q.Update(inbox.Key(m1), func(r *inbox.Record) {
+		r.Attempts++
+		r.Done = append(r.Done, "bot")
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, q.Delete(inbox.Key(m2)))
+
+	rs, _ = q.List()
+	assert.Len(t, rs, 1)
+	assert.True(t, rs[0].IsDone("bot"))
+	assert.False(t, rs[0].IsDone("td"))
+	assert.ErrorIs(t, q.Update("cmpp/none", func(r *inbox.Record) {}), inbox.ErrNotFound)
+
+	// 同一协议的不同账号收到的消息标识可能相同
+	dup, err = q.Push(&session.InboundMessage{Id: "1", ISP: "cmpp", Account: "cmpp-b", Content: "hello"})
+	assert.NoError(t, err)
+	assert.False(t, dup)
+}
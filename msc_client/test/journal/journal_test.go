@@ -0,0 +1,50 @@
+package journal_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hrygo/gosms/smc_client/journal"
+	"github.com/hrygo/gosms/smc_client/session"
+)
+
+func TestBoltStore(t *testing.T) {
+	st, err := journal.OpenBolt(filepath.Join(t.TempDir(), "journal.db"))
+	assert.NoError(t, err)
+	defer func() { _ = st.Close() }()
+
+	old := time.Now().Add(-2 * time.Hour)
+	err = st.Save([]*session.Result{
+		{QueryId: 1, Phone: "13800001111", SequenceId: 1, MsgId: "a1", SendTime: old},
+		{QueryId: 1, Phone: "13800001111", SequenceId: 2, MsgId: "a2", SendTime: old},
+		{QueryId: 2, Phone: "13300001111", SequenceId: 3, MsgId: "b1", SendTime: time.Now()},
+	})
+	assert.NoError(t, err)
+
+	rs, err := st.Query(1)
+	assert.NoError(t, err)
+	assert.Len(t, rs, 2)
+	rs, err = st.Query(3)
+	assert.NoError(t, err)
+	assert.Empty(t, rs)
+
+	found, err := st.UpdateReport("b1", "DELIVRD", time.Now())
+	assert.NoError(t, err)
+	assert.True(t, found)
+	found, err = st.UpdateReport("none", "DELIVRD", time.Now())
+	assert.NoError(t, err)
+	assert.False(t, found)
+	rs, _ = st.Query(2)
+	assert.Equal(t, "DELIVRD", rs[0].Report)
+
+	n, err := st.Purge(time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	rs, _ = st.Query(1)
+	assert.Empty(t, rs)
+	found, _ = st.UpdateReport("a1", "DELIVRD", time.Now())
+	assert.False(t, found)
+}
@@ -0,0 +1,80 @@
+package outbox_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hrygo/gosms/codec"
+	"github.com/hrygo/gosms/smc_client/outbox"
+)
+
+func TestRecord_State(t *testing.T) {
+	r := &outbox.Record{ClientMsgId: "m1", Phone: "13800001111"}
+	assert.Equal(t, outbox.StatePending, r.State)
+
+	// 响应先于提交标记到达
+	r.Responded(0, "id1")
+	assert.Equal(t, outbox.StatePending, r.State)
+	r.Submitted(2)
+	assert.Equal(t, outbox.StateSubmitted, r.State)
+	r.Responded(0, "id2")
+	assert.Equal(t, outbox.StateResponded, r.State)
+	assert.False(t, r.Finished())
+
+	r.Reported("DELIVRD")
+	r.Reported("UNDELIV")
+	assert.Equal(t, outbox.StateReported, r.State)
+	assert.Equal(t, "UNDELIV", r.Report)
+	assert.True(t, r.Finished())
+
+	r.Reset()
+	assert.Equal(t, outbox.StatePending, r.State)
+	assert.Equal(t, 1, r.Attempts)
+	assert.Empty(t, r.MsgIds)
+
+	r.Held(time.Now().Add(time.Hour))
+	assert.Equal(t, outbox.StateHeld, r.State)
+	assert.Equal(t, "held", r.State.String())
+	r.Reset()
+	assert.Equal(t, outbox.StatePending, r.State)
+}
+
+func TestBoltStore(t *testing.T) {
+	st, err := outbox.Open(filepath.Join(t.TempDir(), "outbox.db"))
+	assert.NoError(t, err)
+
+	opts := codec.LoadMtOptions(codec.MtSpSubNo("0001"))
+	r := &outbox.Record{ClientMsgId: "m1", Phone: "13800001111", Content: "hello", Options: opts}
+	dup, err := st.Enqueue(r)
+	assert.NoError(t, err)
+	assert.False(t, dup)
+	dup, err = st.Enqueue(&outbox.Record{ClientMsgId: "m1", Phone: "13800001111", Content: "other"})
+	assert.NoError(t, err)
+	assert.True(t, dup)
+	_, err = st.Enqueue(&outbox.Record{ClientMsgId: "m1", Phone: "13300001111"})
+	assert.NoError(t, err)
+
+	err = st.Update("m1", "13800001111", func(r *outbox.Record) { r.Submitted(1) })
+	assert.NoError(t, err)
+	err = st.Update("m2", "13800001111", func(r *outbox.Record) {})
+	assert.ErrorIs(t, err, outbox.ErrNotFound)
+
+	got, err := st.Get("m1", "13800001111")
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", got.Content)
+	assert.Equal(t, outbox.StateSubmitted, got.State)
+	assert.Equal(t, "0001", codec.LoadMtOptions(codec.WithMtOptions(got.Options)).SpSubNo)
+
+	pending, err := st.Find(func(r *outbox.Record) bool { return r.State == outbox.StatePending })
+	assert.NoError(t, err)
+	assert.Len(t, pending, 1)
+	assert.Equal(t, "13300001111", pending[0].Phone)
+
+	n, err := st.Purge(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.NoError(t, st.Close())
+}
@@ -0,0 +1,114 @@
+package outbox_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sms "github.com/hrygo/gosms/smc_client"
+	"github.com/hrygo/gosms/smc_client/idempotency"
+	"github.com/hrygo/gosms/smc_client/outbox"
+)
+
+var (
+	setupOnce sync.Once
+	box       outbox.Store
+	routed    sync.Map // ClientMsgId -> map[*sms.Message]bool，路由过的消息
+	routedMu  sync.Mutex
+)
+
+// setup 启用发件箱，账号的认证信息不存在，号码有路由但无可用会话
+func setup(t *testing.T) {
+	setupOnce.Do(func() {
+		dir, err := os.MkdirTemp("", "gosms-outbox-")
+		require.NoError(t, err)
+		st, err := outbox.Open(filepath.Join(dir, "outbox.db"))
+		require.NoError(t, err)
+		box = st
+		sms.SetAccounts([]*sms.Account{{Name: "outbox-test", ISP: "cmpp", ClientId: "000000"}})
+		sms.SetRouter(sms.RouterFunc(func(_ string, m *sms.Message) string {
+			routedMu.Lock()
+			defer routedMu.Unlock()
+			v, _ := routed.LoadOrStore(m.ClientMsgId, map[*sms.Message]bool{})
+			v.(map[*sms.Message]bool)[m] = true
+			return "outbox-test"
+		}))
+		sms.SetOutbox(st, 100*time.Millisecond)
+	})
+}
+
+// attempts 消息被发送（首次发送或重试）的次数
+func attempts(clientMsgId string) int {
+	routedMu.Lock()
+	defer routedMu.Unlock()
+	v, ok := routed.Load(clientMsgId)
+	if !ok {
+		return 0
+	}
+	return len(v.(map[*sms.Message]bool))
+}
+
+func TestSendMessage_Dedup(t *testing.T) {
+	setup(t)
+	m := &sms.Message{ClientMsgId: "dedup-1", Content: "hello", Phones: []string{"13800001111"}}
+	id1 := sms.SendMessage(m)
+	r, err := box.Get("dedup-1", "13800001111")
+	require.NoError(t, err)
+	assert.Equal(t, id1, r.QueryId)
+	assert.Equal(t, outbox.StatePending, r.State)
+
+	// 相同 ClientMsgId 与手机号码的消息不再发送，也不等待可用会话
+	id2 := sms.SendMessage(m)
+	assert.NotEqual(t, id1, id2)
+	assert.Empty(t, sms.Query(id2))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	start := time.Now()
+	_, err = sms.SendMessageWait(ctx, m)
+	assert.NoError(t, err)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+
+	r, err = box.Get("dedup-1", "13800001111")
+	require.NoError(t, err)
+	assert.Equal(t, id1, r.QueryId)
+}
+
+func TestReplay(t *testing.T) {
+	setup(t)
+	// 未能提交的消息按重试间隔重新发送
+	sms.SendMessage(&sms.Message{ClientMsgId: "replay-1", Content: "hello", Phones: []string{"13800002222"}})
+	assert.Eventually(t, func() bool { return attempts("replay-1") >= 2 }, 2*time.Second, 10*time.Millisecond)
+
+	// 等待发送的消息超过重试间隔仍未提交时，不会被同时重试
+	ctx, cancel := context.WithTimeout(context.Background(), 600*time.Millisecond)
+	defer cancel()
+	_, err := sms.SendMessageWait(ctx, &sms.Message{ClientMsgId: "wait-1", Content: "hello", Phones: []string{"13800003333"}})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 1, attempts("wait-1"))
+}
+
+func TestSendMessageKey_Partial(t *testing.T) {
+	setup(t)
+	sms.SetIdempotencyStore(idempotency.NewMemoryStore(), time.Minute)
+	send := func() (int64, bool, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		return sms.SendMessageKeyWait(ctx, "order-1", &sms.Message{Content: "hello", Phones: []string{"13800004444", "13800005555"}})
+	}
+	id1, dup, err := send()
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.False(t, dup)
+
+	// 首个号码已写入发件箱等待重试，保留幂等键，重试时返回原查询编号
+	id2, dup, err := send()
+	assert.NoError(t, err)
+	assert.True(t, dup)
+	assert.Equal(t, id1, id2)
+}
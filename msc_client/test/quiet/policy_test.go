@@ -0,0 +1,76 @@
+package quiet_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hrygo/gosms/smc_client/quiet"
+)
+
+var shanghai, _ = time.LoadLocation("Asia/Shanghai")
+
+func at(s string) time.Time {
+	t, _ := time.ParseInLocation("2006-01-02 15:04", s, shanghai)
+	return t
+}
+
+func TestPolicyWindow(t *testing.T) {
+	p := &quiet.Policy{Name: "marketing", Hours: []string{"08:00-12:00", "13:30-21:00"}, Weekdays: []string{"mon-fri"}, TimeZone: "Asia/Shanghai"}
+	require.NoError(t, p.Compile())
+	assert.Equal(t, quiet.ActionReject, p.Action)
+
+	// 2023-03-06 为周一
+	assert.True(t, p.Allowed(at("2023-03-06 08:00")))
+	assert.False(t, p.Allowed(at("2023-03-06 12:30")))
+	assert.True(t, p.Allowed(at("2023-03-06 20:59")))
+	assert.False(t, p.Allowed(at("2023-03-06 21:00")))
+	assert.False(t, p.Allowed(at("2023-03-11 10:00"))) // 周六
+	// 其他时区的同一时刻
+	assert.True(t, p.Allowed(at("2023-03-06 10:00").UTC()))
+
+	assert.Equal(t, at("2023-03-06 13:30"), p.NextOpen(at("2023-03-06 12:30")))
+	assert.Equal(t, at("2023-03-07 08:00"), p.NextOpen(at("2023-03-06 22:00")))
+	assert.Equal(t, at("2023-03-13 08:00"), p.NextOpen(at("2023-03-10 21:00"))) // 周五晚至下周一
+	now := at("2023-03-06 09:15")
+	assert.Equal(t, now, p.NextOpen(now))
+}
+
+func TestPolicyOvernight(t *testing.T) {
+	p := &quiet.Policy{Hours: []string{"22:00-06:00"}, TimeZone: "Asia/Shanghai", Action: quiet.ActionHold}
+	require.NoError(t, p.Compile())
+	assert.True(t, p.Allowed(at("2023-03-06 23:00")))
+	assert.True(t, p.Allowed(at("2023-03-07 05:59")))
+	assert.False(t, p.Allowed(at("2023-03-07 06:00")))
+	assert.Equal(t, at("2023-03-07 22:00"), p.NextOpen(at("2023-03-07 12:00")))
+}
+
+func TestPolicyCompileError(t *testing.T) {
+	for _, p := range []*quiet.Policy{
+		{Hours: []string{"8-21"}},
+		{Hours: []string{"08:00-08:00"}},
+		{Hours: []string{"08:00-25:00"}},
+		{Weekdays: []string{"monday"}},
+		{TimeZone: "Mars/Base"},
+		{Action: "drop"},
+	} {
+		assert.Error(t, p.Compile())
+	}
+}
+
+func TestPoliciesFind(t *testing.T) {
+	ps := quiet.Policies{
+		{Name: "cmpp-marketing", Accounts: []string{"cmpp"}, Tags: []string{"marketing"}},
+		{Name: "marketing", Tags: []string{"marketing"}},
+		{Name: "vip", Accounts: []string{"VIP"}},
+	}
+	for _, p := range ps {
+		require.NoError(t, p.Compile())
+	}
+	assert.Equal(t, "cmpp-marketing", ps.Find([]string{"cmpp-1", "cmpp"}, "marketing").Name)
+	assert.Equal(t, "marketing", ps.Find([]string{"sgip"}, "marketing").Name)
+	assert.Equal(t, "vip", ps.Find([]string{"sgip", "vip"}, "verify").Name)
+	assert.Nil(t, ps.Find([]string{"sgip"}, "verify"))
+}
@@ -0,0 +1,82 @@
+package route_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hrygo/gosms/smc_client/route"
+)
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, "13800001111", route.Normalize("+8613800001111"))
+	assert.Equal(t, "13800001111", route.Normalize("008613800001111"))
+	assert.Equal(t, "13800001111", route.Normalize("8613800001111"))
+	assert.Equal(t, "13800001111", route.Normalize("138-0000 1111"))
+	assert.Equal(t, "+85251234567", route.Normalize("0085251234567"))
+	assert.True(t, route.IsInternational("+85251234567"))
+	assert.False(t, route.IsInternational("13800001111"))
+}
+
+func TestTrie_Lookup(t *testing.T) {
+	trie := route.NewTrie()
+	trie.Insert("134", "cmpp")
+	trie.Insert("1349", "smgp")
+	trie.Insert("170", "smgp")
+	trie.Insert("1705", "cmpp")
+	trie.Insert("+", "intl")
+	trie.Insert("+852", "hk")
+
+	assert.Equal(t, 6, trie.Len())
+	assert.Equal(t, "cmpp", trie.Lookup("13400001111"))
+	assert.Equal(t, "smgp", trie.Lookup("13490001111"))
+	assert.Equal(t, "smgp", trie.Lookup("17000001111"))
+	assert.Equal(t, "cmpp", trie.Lookup("17050001111"))
+	assert.Equal(t, "cmpp", trie.Lookup("+8613400001111"))
+	assert.Equal(t, "hk", trie.Lookup("0085251234567"))
+	assert.Equal(t, "intl", trie.Lookup("+14155550100"))
+	assert.Equal(t, "", trie.Lookup("19900001111"))
+}
+
+func TestTable_LoadAndReload(t *testing.T) {
+	dir := t.TempDir()
+	seg := filepath.Join(dir, "segments.txt")
+	mnp := filepath.Join(dir, "mnp.txt")
+	assert.Nil(t, os.WriteFile(seg, []byte("# comment\n138 cmpp\n133,smgp\n"), 0644))
+	assert.Nil(t, os.WriteFile(mnp, []byte("13800001111 smgp\n"), 0644))
+
+	st := route.NewSegmentTable(seg)
+	assert.Nil(t, st.Load())
+	assert.Equal(t, "cmpp", st.Lookup("13800002222"))
+	assert.Equal(t, "smgp", st.Lookup("13300002222"))
+
+	pt := route.NewPortabilityTable(mnp)
+	assert.Nil(t, pt.Load())
+	assert.Equal(t, "smgp", pt.Lookup("+8613800001111"))
+	assert.Equal(t, "", pt.Lookup("13800002222"))
+
+	pt.Watch(10 * time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	assert.Nil(t, os.WriteFile(mnp, []byte("13800002222 sgip\n"), 0644))
+	future := time.Now().Add(time.Second)
+	assert.Nil(t, os.Chtimes(mnp, future, future))
+	assert.Eventually(t, func() bool {
+		return pt.Lookup("13800002222") == "sgip"
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, "", pt.Lookup("13800001111"))
+}
+
+func TestTable_LoadError(t *testing.T) {
+	dir := t.TempDir()
+	seg := filepath.Join(dir, "segments.txt")
+	assert.Nil(t, os.WriteFile(seg, []byte("138\n"), 0644))
+
+	st := route.NewSegmentTable(seg)
+	err := st.Load()
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "segments.txt:1")
+	assert.NotNil(t, route.NewSegmentTable(filepath.Join(dir, "none.txt")).Load())
+}
@@ -0,0 +1,179 @@
+package rpc_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/hrygo/gosms/codec"
+	"github.com/hrygo/gosms/codec/cmpp"
+	sms "github.com/hrygo/gosms/smc_client"
+	"github.com/hrygo/gosms/smc_client/rpc"
+	"github.com/hrygo/gosms/smc_client/rpc/smspb"
+	"github.com/hrygo/gosms/smc_client/session"
+)
+
+func dial(t *testing.T, token string) smspb.SmsServiceClient {
+	lis := bufconn.Listen(1 << 20)
+	s := rpc.NewServer(token)
+	go func() { _ = s.Serve(lis) }()
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return smspb.NewSmsServiceClient(conn)
+}
+
+func TestAuth(t *testing.T) {
+	c := dial(t, "t0ken")
+	_, err := c.Query(context.Background(), &smspb.QueryRequest{QueryId: 1})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer t0ken")
+	_, err = c.Query(ctx, &smspb.QueryRequest{QueryId: 1})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestSend_InvalidArgument(t *testing.T) {
+	c := dial(t, "")
+	ctx := context.Background()
+	_, err := c.Send(ctx, &smspb.SendRequest{Content: "hi"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	level := uint32(10)
+	_, err = c.Send(ctx, &smspb.SendRequest{Content: "hi", Phones: []string{"13800001111"}, Options: &smspb.MtOptions{MsgLevel: &level}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestBulkSend_Invalid(t *testing.T) {
+	c := dial(t, "")
+	stream, err := c.BulkSend(context.Background())
+	assert.NoError(t, err)
+	assert.NoError(t, stream.Send(&smspb.SendRequest{ClientMsgId: "a", Content: "hi"}))
+	assert.NoError(t, stream.Send(&smspb.SendRequest{ClientMsgId: "b", Phones: []string{"13800001111"}}))
+	resp, err := stream.CloseAndRecv()
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(0), resp.Accepted)
+	assert.Equal(t, uint32(2), resp.Failed)
+	assert.Equal(t, "b", resp.Results[1].ClientMsgId)
+	assert.NotEmpty(t, resp.Results[1].Error)
+}
+
+func TestBulkSend_Backpressure(t *testing.T) {
+	// 账号的认证信息不存在，号码有路由但无可用会话；以 139 开头的号码没有路由
+	sms.SetAccounts([]*sms.Account{{Name: "rpc-test", ISP: "cmpp", ClientId: "000000"}})
+	sms.SetRouter(sms.RouterFunc(func(phone string, _ *sms.Message) string {
+		if phone[:3] == "139" {
+			return ""
+		}
+		return "rpc-test"
+	}))
+	c := dial(t, "")
+
+	// 没有路由的消息不等待，计入失败
+	stream, err := c.BulkSend(context.Background())
+	require.NoError(t, err)
+	assert.NoError(t, stream.Send(&smspb.SendRequest{ClientMsgId: "a", Content: "hi", Phones: []string{"13900001111"}}))
+	resp, err := stream.CloseAndRecv()
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0), resp.Accepted)
+	assert.Equal(t, uint32(1), resp.Failed)
+	assert.NotZero(t, resp.Results[0].QueryId)
+	assert.Contains(t, resp.Results[0].Error, sms.RejectNoRoute)
+
+	// 暂无可用会话时阻塞，直到调用方的 ctx 结束
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	stream, err = c.BulkSend(ctx)
+	require.NoError(t, err)
+	assert.NoError(t, stream.Send(&smspb.SendRequest{ClientMsgId: "b", Content: "hi", Phones: []string{"13800001111"}}))
+	_, err = stream.CloseAndRecv()
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+}
+
+// gateway 模拟网关的一端，连接指定账号的会话
+type gateway struct {
+	net.Conn
+	ac *codec.AuthConf
+}
+
+func newGateway(t *testing.T, account string) *gateway {
+	cli, srv := net.Pipe()
+	gw := &gateway{Conn: srv, ac: &codec.AuthConf{ClientId: "123456", SharedSecret: "secret", Version: 0x30, SmsDisplayNo: "1065"}}
+	go func() {
+		seq, body, err := gw.read()
+		if err != nil {
+			return
+		}
+		c := &cmpp.Connect{}
+		_ = c.Decode(seq, body)
+		_, _ = gw.Write(c.ToResponse(0).Encode())
+	}()
+	sc := session.NewAccountSession(account, session.CMPP, gw.ac, cli)
+	require.NotNil(t, sc)
+	t.Cleanup(sc.Close)
+	return gw
+}
+
+func (gw *gateway) read() (uint32, []byte, error) {
+	head := make([]byte, codec.HeadLen)
+	if _, err := io.ReadFull(gw, head); err != nil {
+		return 0, nil, err
+	}
+	pkl, _, seq := codec.UnpackHead(head)
+	body := make([]byte, pkl-codec.HeadLen)
+	_, err := io.ReadFull(gw, body)
+	return seq, body, err
+}
+
+// deliver 推送上行短信并等待会话应答
+func (gw *gateway) deliver(t *testing.T, subNo, content string) {
+	seq := uint32(codec.B32Seq.NextVal())
+	_, err := gw.Write(cmpp.NewDelivery(gw.ac, "13800001111", content, subNo, "", seq).Encode())
+	require.NoError(t, err)
+	_, _, err = gw.read()
+	require.NoError(t, err)
+}
+
+func TestSubscribe_Filter(t *testing.T) {
+	c := dial(t, "")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := c.Subscribe(ctx, &smspb.SubscribeRequest{
+		Accounts: []string{"RPC-A"},
+		SubNo:    "01",
+		Types:    []smspb.EventType{smspb.EventType_EVENT_TYPE_INBOUND},
+	})
+	require.NoError(t, err)
+	// 等待服务端注册订阅
+	time.Sleep(100 * time.Millisecond)
+
+	a, b := newGateway(t, "rpc-a"), newGateway(t, "rpc-b")
+	b.deliver(t, "0100", "other account")
+	a.deliver(t, "0200", "other sub no")
+	a.deliver(t, "0100", "matched")
+
+	e, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, smspb.EventType_EVENT_TYPE_INBOUND, e.GetType())
+	assert.Equal(t, "rpc-a", e.GetInbound().GetAccount())
+	assert.Equal(t, "0100", e.GetInbound().GetSubNo())
+	assert.Equal(t, "matched", e.GetInbound().GetContent())
+}
@@ -0,0 +1,82 @@
+package schedule_test
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sms "github.com/hrygo/gosms/smc_client"
+	"github.com/hrygo/gosms/smc_client/session"
+)
+
+var setupOnce sync.Once
+
+// setup 启用定时发送及发送时段限制：quiet-test 账号当前不在允许发送的时段内，消息暂存；
+// 以 139 开头的号码没有路由
+func setup(t *testing.T) {
+	setupOnce.Do(func() {
+		dir, err := os.MkdirTemp("", "gosms-schedule-")
+		require.NoError(t, err)
+		base := sms.BasePath
+		defer func() { sms.BasePath = base }()
+		sms.BasePath = dir + "/"
+
+		from := (time.Now().Hour() + 2) % 24
+		v := sms.ConfigYml.Viper()
+		v.Set("Schedule.enable", true)
+		v.Set("Schedule.path", "schedule.db")
+		v.Set("Schedule.check-duration", "20ms")
+		v.Set("QuietHours.enable", true)
+		v.Set("QuietHours.policies", []map[string]any{{
+			"name":     "test",
+			"accounts": []string{"quiet-test"},
+			"hours":    []string{fmt.Sprintf("%02d:00-%02d:00", from, (from+1)%24)},
+			"action":   "hold",
+		}})
+		sms.SetAccounts([]*sms.Account{{Name: "quiet-test", ISP: "cmpp", ClientId: "000000"}})
+		sms.SetRouter(sms.RouterFunc(func(phone string, _ *sms.Message) string {
+			if phone[:3] == "139" {
+				return ""
+			}
+			return "quiet-test"
+		}))
+		require.NoError(t, sms.StartScheduler())
+		require.NoError(t, sms.StartQuietHours())
+	})
+}
+
+func TestFire_StillQuiet(t *testing.T) {
+	setup(t)
+	sms.SendMessage(&sms.Message{ClientMsgId: "held-1", Content: "hello", Phones: []string{"13800001111"}})
+	id := "hold/held-1/13800001111"
+	j, err := sms.ScheduledJob(id)
+	require.NoError(t, err)
+	open := j.FireTime
+	assert.True(t, open.After(time.Now()))
+
+	// 到期时（如进程停止期间错过了时段）仍不在允许发送的时段内，继续暂存
+	require.NoError(t, sms.Reschedule(id, time.Now().Add(-time.Second)))
+	assert.Eventually(t, func() bool {
+		j, err := sms.ScheduledJob(id)
+		return err == nil && j.FireTime.Equal(open)
+	}, time.Second, 10*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	j, err = sms.ScheduledJob(id)
+	require.NoError(t, err)
+	assert.Equal(t, open, j.FireTime)
+}
+
+func TestFire_NoRoute(t *testing.T) {
+	setup(t)
+	_, queryId, err := sms.SendAt(time.Now(), &sms.Message{Content: "hello", Phones: []string{"13900001111"}})
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		rs := sms.Query(queryId)
+		return len(rs) == 1 && rs[0].(*session.Result).RejectReason == sms.RejectNoRoute
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,78 @@
+package schedule_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hrygo/gosms/codec"
+	"github.com/hrygo/gosms/smc_client/schedule"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schedule.db")
+	st, err := schedule.Open(path)
+	require.NoError(t, err)
+
+	now := time.Now()
+	for i, id := range []string{"c", "a", "b"} {
+		err = st.Put(&schedule.Job{Id: id, FireTime: now.Add(time.Duration(i) * time.Minute), Phones: []string{"13800001111"}})
+		require.NoError(t, err)
+	}
+	due, err := st.Due(now.Add(time.Minute))
+	require.NoError(t, err)
+	require.Len(t, due, 2)
+	assert.Equal(t, "c", due[0].Id)
+	assert.Equal(t, "a", due[1].Id)
+
+	// 修改发送时间
+	require.NoError(t, st.Put(&schedule.Job{Id: "c", FireTime: now.Add(time.Hour), Options: codec.LoadMtOptions(codec.MtSpSubNo("01"))}))
+	due, err = st.Due(now.Add(time.Minute))
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, "a", due[0].Id)
+
+	require.NoError(t, st.Delete("a"))
+	assert.ErrorIs(t, st.Delete("a"), schedule.ErrNotFound)
+	_, err = st.Get("a")
+	assert.ErrorIs(t, err, schedule.ErrNotFound)
+	require.NoError(t, st.Close())
+
+	// 重新打开后任务仍在
+	st, err = schedule.Open(path)
+	require.NoError(t, err)
+	defer st.Close()
+	all, err := st.List()
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	assert.Equal(t, "b", all[0].Id)
+	assert.Equal(t, "c", all[1].Id)
+	assert.Equal(t, "01", all[1].Options.SpSubNo)
+}
+
+func TestStore_AddUpdate(t *testing.T) {
+	st, err := schedule.Open(filepath.Join(t.TempDir(), "schedule.db"))
+	require.NoError(t, err)
+	defer st.Close()
+
+	now := time.Now()
+	require.NoError(t, st.Add(&schedule.Job{Id: "m1", FireTime: now.Add(time.Hour)}))
+	assert.ErrorIs(t, st.Add(&schedule.Job{Id: "m1", FireTime: now}), schedule.ErrExists)
+
+	// 提前发送
+	require.NoError(t, st.Update("m1", func(j *schedule.Job) { j.FireTime = now.Add(-time.Second) }))
+	due, err := st.Due(now)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, "m1", due[0].Id)
+	assert.False(t, due[0].CreateTime.IsZero())
+
+	assert.ErrorIs(t, st.Update("m2", func(j *schedule.Job) {}), schedule.ErrNotFound)
+	require.NoError(t, st.Delete("m1"))
+	all, err := st.List()
+	require.NoError(t, err)
+	assert.Empty(t, all)
+}
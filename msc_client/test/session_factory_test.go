@@ -41,15 +41,9 @@ func TestSessionFactory(t *testing.T) {
 	time.Sleep(3600 * time.Second)
 }
 
-func TestPanic(t *testing.T) {
-	defer func() {
-		err := recover()
-		assert.True(t, err != nil)
-	}()
-
+func TestUnknownAccount(t *testing.T) {
 	var fa = sms.CreateSessionFactory("ABCD")
-	assert.True(t, fa != nil)
-
+	assert.True(t, fa == nil)
 }
 
 // 开启pprof，监听请求
@@ -0,0 +1,45 @@
+package suppress_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hrygo/gosms/smc_client/suppress"
+)
+
+func TestIsUnsubscribe(t *testing.T) {
+	kws := []string{"TD", "退订"}
+	assert.True(t, suppress.IsUnsubscribe("TD", kws))
+	assert.True(t, suppress.IsUnsubscribe(" td。", kws))
+	assert.True(t, suppress.IsUnsubscribe("退订！", kws))
+	assert.False(t, suppress.IsUnsubscribe("TD 退订", kws))
+	assert.False(t, suppress.IsUnsubscribe("hello", kws))
+	assert.False(t, suppress.IsUnsubscribe("", []string{""}))
+}
+
+func TestBoltStore(t *testing.T) {
+	st, err := suppress.Open(filepath.Join(t.TempDir(), "suppression.db"))
+	assert.NoError(t, err)
+	defer func() { _ = st.Close() }()
+
+	e, err := st.Get("8613800001111")
+	assert.NoError(t, err)
+	assert.Nil(t, e)
+
+	assert.NoError(t, st.Add(&suppress.Entry{Phone: "8613800001111", Account: "cmpp", Reason: "TD"}))
+	assert.NoError(t, st.Add(&suppress.Entry{Phone: "8618600001111", Reason: "manual"}))
+	e, err = st.Get("8613800001111")
+	assert.NoError(t, err)
+	assert.Equal(t, "cmpp", e.Account)
+	assert.False(t, e.CreateTime.IsZero())
+
+	es, err := st.List()
+	assert.NoError(t, err)
+	assert.Len(t, es, 2)
+
+	assert.NoError(t, st.Remove("8613800001111"))
+	e, _ = st.Get("8613800001111")
+	assert.Nil(t, e)
+}
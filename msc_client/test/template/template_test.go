@@ -0,0 +1,98 @@
+package template_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hrygo/gosms/smc_client/template"
+)
+
+func verifyCode() *template.Template {
+	return &template.Template{
+		Id:      "verify",
+		Content: "您的验证码为${code}，${minutes}分钟内有效，请于${date}前使用。${remark}",
+		Vars: []template.Var{
+			{Name: "code", Type: template.TypeCode, MaxLen: 6},
+			{Name: "minutes", Type: template.TypeInt, MaxLen: 2},
+			{Name: "date", Type: template.TypeDate, Format: "01-02"},
+			{Name: "remark", Optional: true},
+		},
+	}
+}
+
+func TestTemplate_Compile(t *testing.T) {
+	assert.NoError(t, verifyCode().Compile())
+
+	bad := []*template.Template{
+		{Content: "no id"},
+		{Id: "a", Content: "hello ${name}"},
+		{Id: "a", Content: "hello", Vars: []template.Var{{Name: "name"}}},
+		{Id: "a", Content: "${x}", Vars: []template.Var{{Name: "x", Type: "bool"}}},
+		{Id: "a", Content: "${x}${x}", Vars: []template.Var{{Name: "x"}, {Name: "x"}}},
+	}
+	for _, b := range bad {
+		assert.Error(t, b.Compile())
+	}
+}
+
+func TestTemplate_Render(t *testing.T) {
+	tp := verifyCode()
+	assert.NoError(t, tp.Compile())
+
+	date := time.Date(2022, 8, 20, 0, 0, 0, 0, time.Local)
+	s, err := tp.Render(map[string]any{"code": "a1B2", "minutes": float64(5), "date": date})
+	assert.NoError(t, err)
+	assert.Equal(t, "您的验证码为a1B2，5分钟内有效，请于08-20前使用。", s)
+
+	s, err = tp.Render(map[string]any{"code": 123456, "minutes": "10", "date": "12-31", "remark": "勿告知他人"})
+	assert.NoError(t, err)
+	assert.True(t, strings.HasSuffix(s, "勿告知他人"))
+
+	for _, vars := range []map[string]any{
+		{"minutes": 5, "date": date},
+		{"code": "12-34", "minutes": 5, "date": date},
+		{"code": "1234567", "minutes": 5, "date": date},
+		{"code": "1234", "minutes": 1.5, "date": date},
+		{"code": "1234", "minutes": 100, "date": date},
+		{"code": "1234", "minutes": 5, "date": "2022-08-20"},
+		{"code": "1234", "minutes": 5, "date": date, "unknown": "x"},
+	} {
+		_, err = tp.Render(vars)
+		assert.Error(t, err, vars)
+	}
+}
+
+func TestTemplate_Segments(t *testing.T) {
+	tp := &template.Template{Id: "a", Content: "Your code is ${code}", Vars: []template.Var{{Name: "code", Type: template.TypeCode}}}
+	assert.NoError(t, tp.Compile())
+	assert.Equal(t, 1, tp.Segments(""))
+	assert.Equal(t, 1, tp.Segments("【测试】"))
+
+	tp = &template.Template{Id: "b", Content: strings.Repeat("好", 59) + "${name}", Vars: []template.Var{{Name: "name", MaxLen: 10}}}
+	assert.NoError(t, tp.Compile())
+	assert.Equal(t, 1, tp.Segments(""))
+	assert.Equal(t, 2, tp.Segments("【测试】"))
+	assert.Equal(t, 2, template.SegmentCount(strings.Repeat("好", 71)))
+}
+
+func TestRegistry(t *testing.T) {
+	r := template.NewRegistry()
+	assert.Error(t, r.Add(&template.Template{Id: "bad", Content: "${x}"}))
+	assert.NoError(t, r.Add(verifyCode()))
+	assert.NoError(t, r.Add(&template.Template{Id: "notice", Content: "系统维护通知"}))
+	assert.Len(t, r.List(), 2)
+	assert.Equal(t, "notice", r.List()[0].Id)
+
+	_, err := r.Render("none", nil)
+	assert.ErrorIs(t, err, template.ErrNotFound)
+	s, err := r.Render("notice", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "系统维护通知", s)
+
+	assert.True(t, r.Remove("notice"))
+	assert.False(t, r.Remove("notice"))
+	assert.Nil(t, r.Get("notice"))
+}
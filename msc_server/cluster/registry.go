@@ -0,0 +1,166 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// ErrMaxConns 客户端的有效会话数已达上限
+var ErrMaxConns = errors.New("max conns reached")
+
+// SessionRegistry 已登录会话的登记存储，用于统计客户端在集群中的会话数；
+// 会话须在 ttl 内续期，否则视为失效（如节点异常退出）
+type SessionRegistry interface {
+	// Register 登记会话，客户端的有效会话数已达 max 时不登记并返回 ErrMaxConns，成功时返回登记后的会话数
+	Register(ctx context.Context, client, sid string, max int, ttl time.Duration) (int, error)
+	// Refresh 续期会话，会话已失效时重新登记
+	Refresh(ctx context.Context, client, sid string, ttl time.Duration) error
+	// Deregister 注销会话
+	Deregister(ctx context.Context, client, sid string) error
+	// Count 客户端的有效会话数
+	Count(ctx context.Context, client string) (int, error)
+	Close() error
+}
+
+// MemoryRegistry 进程内的会话登记，仅统计本节点的会话
+type MemoryRegistry struct {
+	mu       sync.Mutex
+	sessions map[string]map[string]time.Time
+}
+
+func NewMemoryRegistry() *MemoryRegistry {
+	return &MemoryRegistry{sessions: make(map[string]map[string]time.Time)}
+}
+
+// active 清理失效的会话，返回客户端的有效会话
+func (r *MemoryRegistry) active(client string, now time.Time) map[string]time.Time {
+	ss := r.sessions[client]
+	for sid, expire := range ss {
+		if !now.Before(expire) {
+			delete(ss, sid)
+		}
+	}
+	if len(ss) == 0 {
+		delete(r.sessions, client)
+		return nil
+	}
+	return ss
+}
+
+func (r *MemoryRegistry) Register(_ context.Context, client, sid string, max int, ttl time.Duration) (int, error) {
+	now := time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ss := r.active(client, now)
+	if _, ok := ss[sid]; !ok && len(ss) >= max {
+		return len(ss), ErrMaxConns
+	}
+	if ss == nil {
+		ss = make(map[string]time.Time)
+		r.sessions[client] = ss
+	}
+	ss[sid] = now.Add(ttl)
+	return len(ss), nil
+}
+
+func (r *MemoryRegistry) Refresh(_ context.Context, client, sid string, ttl time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ss := r.sessions[client]
+	if ss == nil {
+		ss = make(map[string]time.Time)
+		r.sessions[client] = ss
+	}
+	ss[sid] = time.Now().Add(ttl)
+	return nil
+}
+
+func (r *MemoryRegistry) Deregister(_ context.Context, client, sid string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if ss := r.sessions[client]; ss != nil {
+		delete(ss, sid)
+		if len(ss) == 0 {
+			delete(r.sessions, client)
+		}
+	}
+	return nil
+}
+
+func (r *MemoryRegistry) Count(_ context.Context, client string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.active(client, time.Now())), nil
+}
+
+func (r *MemoryRegistry) Close() error {
+	return nil
+}
+
+// RedisRegistry 基于 Redis 的会话登记，集群中的节点共享会话数；
+// 每个客户端一个有序集合，成员为会话标识，分值为失效时间（毫秒），键名均加上 prefix
+type RedisRegistry struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+func NewRedisRegistry(client redis.UniversalClient, prefix string) *RedisRegistry {
+	return &RedisRegistry{client: client, prefix: prefix}
+}
+
+// registerScript 清理失效的会话后检查会话数并登记，保证并发登录时不超过上限
+// KEYS[1] 客户端键，ARGV: 当前时间、失效时间、键的过期毫秒数、会话标识、上限
+var registerScript = redis.NewScript(`
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', ARGV[1])
+local n = redis.call('ZCARD', KEYS[1])
+if not redis.call('ZSCORE', KEYS[1], ARGV[4]) then
+	if n >= tonumber(ARGV[5]) then
+		return -n - 1
+	end
+	n = n + 1
+end
+redis.call('ZADD', KEYS[1], ARGV[2], ARGV[4])
+redis.call('PEXPIRE', KEYS[1], ARGV[3])
+return n
+`)
+
+func (r *RedisRegistry) Register(ctx context.Context, client, sid string, max int, ttl time.Duration) (int, error) {
+	now := time.Now()
+	n, err := registerScript.Run(ctx, r.client, []string{r.prefix + client},
+		now.UnixMilli(), now.Add(ttl).UnixMilli(), ttl.Milliseconds(), sid, max).Int()
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 {
+		return -n - 1, ErrMaxConns
+	}
+	return n, nil
+}
+
+func (r *RedisRegistry) Refresh(ctx context.Context, client, sid string, ttl time.Duration) error {
+	key := r.prefix + client
+	_, err := r.client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.ZAdd(ctx, key, &redis.Z{Score: float64(time.Now().Add(ttl).UnixMilli()), Member: sid})
+		p.PExpire(ctx, key, ttl)
+		return nil
+	})
+	return err
+}
+
+func (r *RedisRegistry) Deregister(ctx context.Context, client, sid string) error {
+	return r.client.ZRem(ctx, r.prefix+client, sid).Err()
+}
+
+func (r *RedisRegistry) Count(ctx context.Context, client string) (int, error) {
+	n, err := r.client.ZCount(ctx, r.prefix+client, "("+strconv.FormatInt(time.Now().UnixMilli(), 10), "+inf").Result()
+	return int(n), err
+}
+
+func (r *RedisRegistry) Close() error {
+	return r.client.Close()
+}
@@ -23,6 +23,17 @@ func main() {
 	log.Infof("current pid is %s.", savePid(".gosms.pid"))
 	pprofDebug()
 
+	if err := server.StartQuota(); err != nil {
+		log.Fatalf("Start quota error: %v", err)
+	}
+	if err := server.StartCluster(); err != nil {
+		log.Fatalf("Start cluster error: %v", err)
+	}
+	if err := server.StartLoginGuard(); err != nil {
+		log.Fatalf("Start login guard error: %v", err)
+	}
+	server.StartAdmin()
+
 	server.Start(server.New(server.CMPP))
 	server.Start(server.New(server.SMGP))
 	server.Start(server.New(server.SGIP))
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/hrygo/gosms/auth"
+	bs "github.com/hrygo/gosms/msc_server"
+)
+
+const usage = `Usage: gosmsctl clients|quota|secret <command> [flags]
+
+Clients commands:
+  list     [-json]                     list all clients, secrets are masked
+  get      -isp <isp> -id <client-id>  show a client [-show-secret]
+  add      -f <file|->                 create a client from a yaml, json or toml file
+  update   -f <file|->                 replace an existing client
+  delete   -isp <isp> -id <client-id>  delete a client
+  validate -f <file|->                 validate a client file without saving
+  export   -f <file|->                 export all clients as yaml (bolt store), secret refs are kept
+  import   -f <file|->                 create or replace clients from an exported yaml file (bolt store)
+
+Quota commands (requires Server.Admin.Enable):
+  get      -isp <isp> -id <client-id>  show quota usages of the current period
+  reset    -isp <isp> -id <client-id>  reset quota counters [-period day|month]
+
+Secret commands:
+  keygen                               generate a master key for encrypted secrets
+  encrypt  [-value <secret>]           encrypt a secret with the master key (GOSMS_MASTER_KEY
+                                       or AuthClient.MasterKeyFile), read stdin if no -value
+`
+
+func main() {
+	if len(os.Args) < 3 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	var err error
+	switch os.Args[1] {
+	case "clients":
+		err = runClients(os.Args[2], os.Args[3:])
+	case "quota":
+		err = runQuota(os.Args[2], os.Args[3:])
+	case "secret":
+		err = runSecret(os.Args[2], os.Args[3:])
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+}
+
+func runClients(cmd string, args []string) error {
+	fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+	isp := fs.String("isp", "", "isp of the client: cmpp, sgip or smgp")
+	id := fs.String("id", "", "client id")
+	file := fs.String("f", "", "client file, .yaml/.yml, .json or .toml, - for stdin")
+	asJson := fs.Bool("json", false, "output json")
+	showSecret := fs.Bool("show-secret", false, "show shared secret")
+	_ = fs.Parse(args)
+
+	switch cmd {
+	case "list":
+		return list(*asJson)
+	case "get":
+		if *isp == "" || *id == "" {
+			fs.Usage()
+			os.Exit(2)
+		}
+		c := store().FindByCid(*isp, *id)
+		if c == nil {
+			return auth.ErrNotFound
+		}
+		if !*showSecret {
+			c = masked(c)
+		}
+		return printYaml(c)
+	case "add", "update", "validate":
+		if *file == "" {
+			fs.Usage()
+			os.Exit(2)
+		}
+		c, err := readClient(*file)
+		if err != nil {
+			return err
+		}
+		switch cmd {
+		case "add":
+			err = store().Create(c)
+		case "update":
+			err = store().Update(c)
+		default:
+			if err = c.Validate(); err == nil {
+				// 校验密钥引用能否解析
+				if err = auth.LoadMasterKey(bs.ConfigYml); err == nil {
+					err = c.Resolve()
+				}
+			}
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("client %s %s ok.\n", c.Key(), cmd)
+		return nil
+	case "export", "import":
+		return transfer(cmd, *file)
+	case "delete":
+		if *isp == "" || *id == "" {
+			fs.Usage()
+			os.Exit(2)
+		}
+		if err := store().Delete(*isp, *id); err != nil {
+			return err
+		}
+		fmt.Printf("client %s deleted.\n", auth.Key(*isp, *id))
+		return nil
+	}
+	fmt.Fprint(os.Stderr, usage)
+	os.Exit(2)
+	return nil
+}
+
+// store 按服务端配置文件（AuthClient.StoreType）打开客户端存储
+func store() auth.Store {
+	if auth.Cache == nil {
+		auth.Cache = auth.New(bs.ConfigYml)
+	}
+	return auth.Cache
+}
+
+// transfer 导出或导入全部客户端，仅 bolt 存储支持
+func transfer(cmd, file string) error {
+	bolt, ok := store().(*auth.BoltStore)
+	if !ok {
+		return errors.New(cmd + " is only supported by the bolt store")
+	}
+	if file == "" {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if cmd == "export" {
+		if file == "-" {
+			return bolt.Export(os.Stdout)
+		}
+		f, err := os.Create(file)
+		if err != nil {
+			return err
+		}
+		if err = bolt.Export(f); err != nil {
+			_ = f.Close()
+			return err
+		}
+		return f.Close()
+	}
+
+	var r io.Reader = os.Stdin
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+		r = f
+	}
+	n, err := bolt.Import(r)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d clients imported.\n", n)
+	return nil
+}
+
+func list(asJson bool) error {
+	cs := store().List()
+	for i, c := range cs {
+		cs[i] = masked(c)
+	}
+	if asJson {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(cs)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "ISP\tCLIENT-ID\tLOGIN-NAME\tVERSION\tDISPLAY-NO\tMAX-CONNS\tWINDOW\tTPS\tVALID")
+	for _, c := range cs {
+		valid := "ok"
+		if err := c.Validate(); err != nil {
+			valid = err.Error()
+		} else if c.Disabled {
+			valid = "disabled"
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%#x\t%s\t%d\t%d\t%d\t%s\n", c.ISP, c.ClientId, c.LoginName,
+			c.Version, c.SmsDisplayNo, c.MaxConns, c.MtWindowSize, c.Throughput, valid)
+	}
+	return w.Flush()
+}
+
+func masked(c *auth.Client) *auth.Client {
+	m := *c
+	if ref := c.SecretRef(); ref != "" {
+		m.SharedSecret = ref
+	} else if m.SharedSecret != "" {
+		m.SharedSecret = "******"
+	}
+	return &m
+}
+
+func printYaml(c *auth.Client) error {
+	enc := yaml.NewEncoder(os.Stdout)
+	enc.SetIndent(2)
+	return enc.Encode(c)
+}
+
+// readClient 读取客户端配置文件，按扩展名解析，标准输入中以 { 开头的内容按 json 解析，否则按 yaml 解析
+func readClient(file string) (*auth.Client, error) {
+	var data []byte
+	var err error
+	if file == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return nil, err
+	}
+	c := &auth.Client{}
+	format := auth.FormatOf(file)
+	if format == "" {
+		format = auth.FormatYaml
+		if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+			format = auth.FormatJson
+		}
+	}
+	if err = auth.Decode(format, data, c); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	if c.ISP == "" {
+		return nil, errors.New("isp: required")
+	}
+	return c, nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	bs "github.com/hrygo/gosms/msc_server"
+	"github.com/hrygo/gosms/msc_server/quota"
+)
+
+// runQuota 通过服务端管理接口查询或重置客户端的配额计数
+func runQuota(cmd string, args []string) error {
+	fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+	isp := fs.String("isp", "", "isp of the client: cmpp, sgip or smgp")
+	id := fs.String("id", "", "client id")
+	period := fs.String("period", "", "reset only the day or month counters")
+	addr := fs.String("addr", bs.ConfigYml.GetString("Server.Admin.Addr"), "admin api address of msc_server")
+	_ = fs.Parse(args)
+
+	if *isp == "" || *id == "" || (cmd != "get" && cmd != "reset") {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if *addr == "" {
+		*addr = "127.0.0.1:10089"
+	}
+	q := url.Values{"isp": {*isp}, "id": {*id}}
+	method := http.MethodGet
+	if cmd == "reset" {
+		method = http.MethodDelete
+		if *period != "" {
+			q.Set("period", *period)
+		}
+	}
+	req, err := http.NewRequest(method, "http://"+*addr+"/quotas?"+q.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	c := &http.Client{Timeout: 5 * time.Second}
+	resp, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	// 同 server.QuotaResponse
+	ret := &struct {
+		Client string        `json:"client"`
+		Usages []quota.Usage `json:"usages"`
+		Reset  int           `json:"reset"`
+		Error  string        `json:"error"`
+	}{}
+	if err = json.NewDecoder(resp.Body).Decode(ret); err != nil {
+		return fmt.Errorf("%s: %w", resp.Status, err)
+	}
+	if ret.Error != "" {
+		return errors.New(ret.Error)
+	}
+
+	if cmd == "reset" {
+		fmt.Printf("%d counters of %s reset.\n", ret.Reset, ret.Client)
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "PERIOD\tPREFIX\tLIMIT\tUSED")
+	for _, u := range ret.Usages {
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%d\t%d\n", u.Period, u.Prefix, u.Limit, u.Used)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hrygo/gosms/auth"
+	bs "github.com/hrygo/gosms/msc_server"
+)
+
+// runSecret 生成主密钥或使用主密钥加密客户端密码
+func runSecret(cmd string, args []string) error {
+	fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+	value := fs.String("value", "", "secret to encrypt, read from stdin if empty")
+	_ = fs.Parse(args)
+
+	switch cmd {
+	case "keygen":
+		key, err := auth.GenerateMasterKey()
+		if err != nil {
+			return err
+		}
+		fmt.Println(key)
+		return nil
+	case "encrypt":
+		if err := auth.LoadMasterKey(bs.ConfigYml); err != nil {
+			return err
+		}
+		s := *value
+		if s == "" {
+			line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+			if err != nil && line == "" {
+				return err
+			}
+			s = strings.TrimRight(line, "\r\n")
+		}
+		enc, err := auth.EncryptSecret(s)
+		if err != nil {
+			return err
+		}
+		fmt.Println(enc)
+		return nil
+	}
+	fmt.Fprint(os.Stderr, usage)
+	os.Exit(2)
+	return nil
+}
@@ -0,0 +1,150 @@
+package loginguard
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// 登录请求不能通过检查的原因
+var (
+	ErrClockSkew = errors.New("timestamp is out of the allowed clock skew")
+	ErrReplay    = errors.New("authenticator has been used")
+)
+
+// ErrNoSkewCheck 检查重放时必须检查时间戳，否则记录过期后旧的认证码可再次使用
+var ErrNoSkewCheck = errors.New("replay window requires a max clock skew")
+
+type entry struct {
+	n      int
+	expire time.Time
+}
+
+// Guard 检查 CMPP、SMGP 登录请求的时间戳与服务器时间的偏差，并拒绝重放的认证码。
+// 认证码由客户端识别号、密码及精确到秒的时间戳计算，同一秒内建立的多个连接认证码相同，
+// 因此同一认证码在 window 内最多可使用 limit 次（一般为客户端的 max-conns）。
+// 使用记录至少保留到时间戳超出允许的偏差，之后该认证码因时间戳检查不通过而无法再使用
+type Guard struct {
+	maxSkew time.Duration
+	window  time.Duration
+
+	mu   sync.Mutex
+	seen map[string]*entry
+	ops  int
+}
+
+// New maxSkew 为0时不检查时间戳，window 为0时不检查重放；检查重放时 maxSkew 不能为0
+func New(maxSkew, window time.Duration) (*Guard, error) {
+	if window > 0 && maxSkew <= 0 {
+		return nil, ErrNoSkewCheck
+	}
+	return &Guard{maxSkew: maxSkew, window: window, seen: make(map[string]*entry)}, nil
+}
+
+// Reserve 检查客户端 client 的登录时间戳 ts（MMDDHHMMSS）及认证码的已使用次数，检查通过时在同一步骤中记录本次使用，
+// 并发的重放请求不会同时通过检查。登录因其它原因失败时需调用 Release 撤销本次使用。
+// 记录在 window 后且时间戳超出允许的偏差后过期
+func (g *Guard) Reserve(client string, ts uint32, authenticator []byte, limit int, now time.Time) error {
+	if g.maxSkew > 0 {
+		t, err := Timestamp(ts, now)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrClockSkew, err)
+		}
+		if d := now.Sub(t); d > g.maxSkew || d < -g.maxSkew {
+			return fmt.Errorf("%w: %010d, skew %v", ErrClockSkew, ts, d.Round(time.Second))
+		}
+	}
+	if g.window <= 0 {
+		return nil
+	}
+	if limit < 1 {
+		limit = 1
+	}
+	expire := now.Add(g.window)
+	if t, err := Timestamp(ts, now); err == nil && t.Add(g.maxSkew).After(expire) {
+		expire = t.Add(g.maxSkew)
+	}
+	k := key(client, authenticator)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	e := g.get(k, now)
+	if e != nil && e.n >= limit {
+		return fmt.Errorf("%w %d times in %v", ErrReplay, e.n, g.window)
+	}
+	if e == nil {
+		e = &entry{}
+		g.seen[k] = e
+	}
+	if expire.After(e.expire) {
+		e.expire = expire
+	}
+	e.n++
+	return nil
+}
+
+// Release 撤销 Reserve 记录的一次使用，用于检查通过但登录失败（如会话数已达上限）的请求
+func (g *Guard) Release(client string, authenticator []byte, now time.Time) {
+	if g.window <= 0 {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if e := g.get(key(client, authenticator), now); e != nil && e.n > 0 {
+		e.n--
+	}
+}
+
+func key(client string, authenticator []byte) string {
+	return client + ":" + hex.EncodeToString(authenticator)
+}
+
+// get 获取未过期的记录，每 1024 次操作清理一次过期记录
+func (g *Guard) get(key string, now time.Time) *entry {
+	if g.ops++; g.ops >= 1024 {
+		g.ops = 0
+		for k, e := range g.seen {
+			if !now.Before(e.expire) {
+				delete(g.seen, k)
+			}
+		}
+	}
+	e := g.seen[key]
+	if e != nil && !now.Before(e.expire) {
+		delete(g.seen, key)
+		return nil
+	}
+	return e
+}
+
+// Timestamp 将 MMDDHHMMSS 格式的时间戳转换为 now 所在时区中与 now 最接近的时间（可能为上一年或下一年，如跨年时）
+func Timestamp(ts uint32, now time.Time) (time.Time, error) {
+	mon, day := int(ts/100000000), int(ts/1000000%100)
+	hour, min, sec := int(ts/10000%100), int(ts/100%100), int(ts%100)
+	if mon < 1 || mon > 12 || day < 1 || day > 31 || hour > 23 || min > 59 || sec > 59 {
+		return time.Time{}, fmt.Errorf("invalid timestamp %010d", ts)
+	}
+	var best time.Time
+	for _, y := range []int{now.Year() - 1, now.Year(), now.Year() + 1} {
+		t := time.Date(y, time.Month(mon), day, hour, min, sec, 0, now.Location())
+		// 日期不存在时（如非闰年的0229）time.Date 会顺延，此时跳过
+		if t.Day() != day {
+			continue
+		}
+		if best.IsZero() || abs(now.Sub(t)) < abs(now.Sub(best)) {
+			best = t
+		}
+	}
+	if best.IsZero() {
+		return time.Time{}, fmt.Errorf("invalid timestamp %010d", ts)
+	}
+	return best, nil
+}
+
+func abs(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
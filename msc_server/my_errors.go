@@ -8,4 +8,10 @@ const (
 	ErrorsIllegalPacketLength    = "Illegal packet length %d"
 	ErrorsIllegalCommand         = "Illegal command %0x"
 	ErrorsSubmitFlowControl      = "Submit message flow control"
+	ErrorsClientRemoved          = "Client is %s"
+	ErrorsIPNotAllowed           = "Remote ip %s is not allowed"
+	ErrorsQuotaExceeded          = "Quota exceeded (period: %s, prefix: %q, limit: %d), phones: %s"
+	ErrorsLoginRejected          = "Login rejected: %v"
+	ErrorsMaxConnsReached        = "Reached max conns of the client (%d/%d)"
+	ErrorsSubmitRejected         = "Submit rejected: %v"
 )
@@ -0,0 +1,190 @@
+package quota
+
+import (
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Counter 配额计数存储，重启后需保留计数时使用 BoltCounter
+type Counter interface {
+	// IncrBy 计数增加 n（为负数时用于撤销）并返回当前值，计数在 expire 时过期
+	IncrBy(ctx context.Context, key string, n int64, expire time.Time) (int64, error)
+	// Get 获取当前计数，不存在或已过期时返回0
+	Get(ctx context.Context, key string) (int64, error)
+	// Reset 删除以 prefix 开头的全部计数，返回删除的数量
+	Reset(ctx context.Context, prefix string) (int, error)
+	Close() error
+}
+
+type memoryEntry struct {
+	n      int64
+	expire time.Time
+}
+
+// MemoryCounter 进程内的计数存储
+type MemoryCounter struct {
+	mu      sync.Mutex
+	entries map[string]*memoryEntry
+	ops     int
+}
+
+func NewMemoryCounter() *MemoryCounter {
+	return &MemoryCounter{entries: make(map[string]*memoryEntry)}
+}
+
+// get 获取未过期的计数，每 1024 次操作清理一次过期计数
+func (c *MemoryCounter) get(key string, now time.Time) *memoryEntry {
+	if c.ops++; c.ops >= 1024 {
+		c.ops = 0
+		for k, e := range c.entries {
+			if !now.Before(e.expire) {
+				delete(c.entries, k)
+			}
+		}
+	}
+	e := c.entries[key]
+	if e != nil && !now.Before(e.expire) {
+		delete(c.entries, key)
+		return nil
+	}
+	return e
+}
+
+func (c *MemoryCounter) IncrBy(_ context.Context, key string, n int64, expire time.Time) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e := c.get(key, time.Now())
+	if e == nil {
+		e = &memoryEntry{expire: expire}
+		c.entries[key] = e
+	}
+	e.n += n
+	return e.n, nil
+}
+
+func (c *MemoryCounter) Get(_ context.Context, key string) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e := c.get(key, time.Now()); e != nil {
+		return e.n, nil
+	}
+	return 0, nil
+}
+
+func (c *MemoryCounter) Reset(_ context.Context, prefix string) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for k := range c.entries {
+		if strings.HasPrefix(k, prefix) {
+			delete(c.entries, k)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (c *MemoryCounter) Close() error {
+	return nil
+}
+
+var bucket = []byte("quota")
+
+// BoltCounter 基于 bbolt 嵌入式数据库的计数存储，值为 8 字节计数 + 8 字节过期时间（UnixNano）
+type BoltCounter struct {
+	db *bolt.DB
+}
+
+// OpenBolt 打开（或创建）计数数据库文件
+func OpenBolt(path string) (*BoltCounter, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 3 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucket)
+		return err
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return &BoltCounter{db: db}, nil
+}
+
+// decode 解析计数，已过期时返回0
+func decode(v []byte, now time.Time) (n int64, expire time.Time) {
+	if len(v) != 16 {
+		return 0, time.Time{}
+	}
+	expire = time.Unix(0, int64(binary.BigEndian.Uint64(v[8:])))
+	if !now.Before(expire) {
+		return 0, time.Time{}
+	}
+	return int64(binary.BigEndian.Uint64(v[:8])), expire
+}
+
+func (c *BoltCounter) IncrBy(_ context.Context, key string, n int64, expire time.Time) (ret int64, err error) {
+	err = c.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket)
+		old, exp := decode(b.Get([]byte(key)), time.Now())
+		if exp.IsZero() {
+			exp = expire
+		}
+		ret = old + n
+		v := make([]byte, 16)
+		binary.BigEndian.PutUint64(v[:8], uint64(ret))
+		binary.BigEndian.PutUint64(v[8:], uint64(exp.UnixNano()))
+		return b.Put([]byte(key), v)
+	})
+	return
+}
+
+func (c *BoltCounter) Get(_ context.Context, key string) (n int64, err error) {
+	err = c.db.View(func(tx *bolt.Tx) error {
+		n, _ = decode(tx.Bucket(bucket).Get([]byte(key)), time.Now())
+		return nil
+	})
+	return
+}
+
+// Reset 同时清理已过期的计数
+func (c *BoltCounter) Reset(_ context.Context, prefix string) (n int, err error) {
+	now := time.Now()
+	err = c.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket)
+		var keys [][]byte
+		_ = b.ForEach(func(k, v []byte) error {
+			_, exp := decode(v, now)
+			match := strings.HasPrefix(string(k), prefix)
+			if match || exp.IsZero() {
+				keys = append(keys, append([]byte(nil), k...))
+			}
+			if match && !exp.IsZero() {
+				n++
+			}
+			return nil
+		})
+		for _, k := range keys {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return
+}
+
+func (c *BoltCounter) Close() error {
+	return c.db.Close()
+}
@@ -0,0 +1,103 @@
+package quota
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/hrygo/gosms/auth"
+)
+
+// Usage 配额在当前周期的使用情况
+type Usage struct {
+	auth.Quota
+	Used int64 `json:"used"`
+}
+
+// Key 客户端配额在 t 所在周期的计数键，如 cmpp_123456:day:20221019:138
+func Key(client string, q auth.Quota, t time.Time) string {
+	window := t.Format("20060102")
+	if q.Period == auth.PeriodMonth {
+		window = t.Format("200601")
+	}
+	return client + ":" + q.Period + ":" + window + ":" + q.Prefix
+}
+
+// End t 所在周期的结束时间
+func End(period string, t time.Time) time.Time {
+	y, m, d := t.Date()
+	if period == auth.PeriodMonth {
+		return time.Date(y, m+1, 1, 0, 0, 0, 0, t.Location())
+	}
+	return time.Date(y, m, d+1, 0, 0, 0, 0, t.Location())
+}
+
+// trimCountryCode 去掉号码的国家码，如 SGIP 协议的 86 前缀
+func trimCountryCode(phone string) string {
+	phone = strings.TrimPrefix(phone, "+")
+	if len(phone) == 13 && strings.HasPrefix(phone, "86") {
+		return phone[2:]
+	}
+	return phone
+}
+
+// Take 按接收号码对客户端的各配额计数，任一配额超出时撤销本次计数并返回超出的配额
+func Take(ctx context.Context, c Counter, client *auth.Client, phones []string, t time.Time) (*auth.Quota, error) {
+	type taken struct {
+		key    string
+		n      int64
+		expire time.Time
+	}
+	var done []taken
+	rollback := func() {
+		for _, d := range done {
+			_, _ = c.IncrBy(ctx, d.key, -d.n, d.expire)
+		}
+	}
+	for i := range client.Quotas {
+		q := &client.Quotas[i]
+		var n int64
+		for _, p := range phones {
+			if strings.HasPrefix(trimCountryCode(p), q.Prefix) {
+				n++
+			}
+		}
+		if n == 0 {
+			continue
+		}
+		d := taken{key: Key(client.Key(), *q, t), n: n, expire: End(q.Period, t)}
+		used, err := c.IncrBy(ctx, d.key, d.n, d.expire)
+		if err != nil {
+			rollback()
+			return nil, err
+		}
+		done = append(done, d)
+		if used > q.Limit {
+			rollback()
+			return q, nil
+		}
+	}
+	return nil, nil
+}
+
+// Usages 客户端各配额在 t 所在周期的使用情况
+func Usages(ctx context.Context, c Counter, client *auth.Client, t time.Time) ([]Usage, error) {
+	ret := make([]Usage, 0, len(client.Quotas))
+	for _, q := range client.Quotas {
+		used, err := c.Get(ctx, Key(client.Key(), q, t))
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, Usage{Quota: q, Used: used})
+	}
+	return ret, nil
+}
+
+// Reset 重置客户端的配额计数，period 为空时重置全部周期
+func Reset(ctx context.Context, c Counter, client, period string) (int, error) {
+	prefix := client + ":"
+	if period != "" {
+		prefix += period + ":"
+	}
+	return c.Reset(ctx, prefix)
+}
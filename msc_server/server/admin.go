@@ -0,0 +1,92 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/hrygo/log"
+
+	"github.com/hrygo/gosms/auth"
+	"github.com/hrygo/gosms/event_manager"
+	"github.com/hrygo/gosms/msc_server"
+	"github.com/hrygo/gosms/msc_server/quota"
+)
+
+// QuotaResponse 配额查询及重置接口的响应
+type QuotaResponse struct {
+	Client string        `json:"client"`
+	Usages []quota.Usage `json:"usages,omitempty"` // 当前周期的使用情况
+	Reset  int           `json:"reset,omitempty"`  // 重置的计数数量
+	Error  string        `json:"error,omitempty"`
+}
+
+// StartAdmin 按配置文件启动管理接口（Server.Admin.Enable），监听 Server.Admin.Addr，默认仅本机访问：
+// GET /quotas?isp=cmpp&id=123456 查询客户端配额的使用情况；
+// DELETE /quotas?isp=cmpp&id=123456[&period=day] 重置客户端的配额计数，period 为空时重置全部周期
+func StartAdmin() {
+	if !msc.ConfigYml.GetBool("Server.Admin.Enable") {
+		return
+	}
+	addr := msc.ConfigYml.GetString("Server.Admin.Addr")
+	if addr == "" {
+		addr = "127.0.0.1:10089"
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/quotas", handleQuotas)
+	srv := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	event_manager.RegisterShutdownHooker("Stop_Admin", func(args ...any) {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(ctx)
+	})
+	go func() {
+		log.Warnf("admin api on http://%s/", addr)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Errorf("admin api on %s exits with error: %v", addr, err)
+		}
+	}()
+}
+
+func handleQuotas(w http.ResponseWriter, r *http.Request) {
+	isp, id := r.URL.Query().Get("isp"), r.URL.Query().Get("id")
+	resp := &QuotaResponse{Client: auth.Key(isp, id)}
+	status := http.StatusOK
+	switch {
+	case isp == "" || id == "":
+		status, resp.Error = http.StatusBadRequest, "isp and id are required"
+	case quotaCounter == nil:
+		status, resp.Error = http.StatusServiceUnavailable, "quota is not enabled"
+	case r.Method == http.MethodGet:
+		cli := auth.Cache.FindByCid(isp, id)
+		if cli == nil {
+			status, resp.Error = http.StatusNotFound, auth.ErrNotFound.Error()
+			break
+		}
+		usages, err := quota.Usages(r.Context(), quotaCounter, cli, time.Now())
+		if err != nil {
+			status, resp.Error = http.StatusInternalServerError, err.Error()
+			break
+		}
+		resp.Usages = usages
+	case r.Method == http.MethodDelete:
+		period := r.URL.Query().Get("period")
+		if period != "" && period != auth.PeriodDay && period != auth.PeriodMonth {
+			status, resp.Error = http.StatusBadRequest, "period must be day or month"
+			break
+		}
+		n, err := quota.Reset(r.Context(), quotaCounter, resp.Client, period)
+		if err != nil {
+			status, resp.Error = http.StatusInternalServerError, err.Error()
+			break
+		}
+		resp.Reset = n
+		log.Warnf("[Quota] Reset %d counters of %s %s.", n, resp.Client, period)
+	default:
+		status, resp.Error = http.StatusMethodNotAllowed, "method not allowed"
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -29,7 +29,7 @@ var cmppConnect TrafficHandler = func(cmd, seq uint32, buff []byte, c gnet.Conn,
 	})
 	if err != nil {
 		log.Error(fmt.Sprintf("[%s] OnTraffic %s", sc.ServerName(), RC),
-			FlatMapLog(sc.LogSession(), []log.Field{OpConnectionClose.Field(), ErrorField(err), Packet2HexLogStr(buff)})...)
+			FlatMapLog(sc.LogSession(), []log.Field{OpConnectionClose.Field(), ErrorField(err), SecretPacketLogStr(buff)})...)
 		return false, gnet.Close
 	}
 
@@ -46,14 +46,20 @@ func handleCmppConnect(s *Server, sc *session, login *cmpp.Connect) {
 	cli := msc.FindAuthConf(s.name, login.SourceAddr())
 	code := login.Check(cli)
 
-	// 检查当前已登录会话数是否已达上限
-	if code == cmpp.ConnStatusOK {
-		// 注意这里仅按照单节点计算某个client的session数，实际上应该计算集群中的某个client的session数。
-		// TODO 要支持集群，连接会话的计数，应该采用数据库或者redis等存储。
-		activeSession := s.CountSessionByClientId(cli.ClientId)
-		if activeSession >= cli.MaxConns {
-			code = cmpp.ConnStatusOthers
-		}
+	// 检查来源地址是否在客户端的IP白名单内
+	if code == cmpp.ConnStatusOK && !ipAllowed(s, sc, login.SourceAddr()) {
+		code = cmpp.ConnStatusInvalidSrcAddr
+	}
+
+	// 检查时间戳与服务器时间的偏差，并拒绝重放的登录请求
+	if code == cmpp.ConnStatusOK && !loginFresh(s, sc, cli.ClientId, login.Timestamp(), login.AuthenticatorSource(), cli.MaxConns) {
+		code = cmpp.ConnStatusAuthFailed
+	}
+
+	// 检查客户端在集群中已登录的会话数是否已达上限，未达上限时登记本会话
+	if code == cmpp.ConnStatusOK && !registerSession(s, sc, cli.ClientId, cli.MaxConns) {
+		code = cmpp.ConnStatusOthers
+		releaseLogin(s, cli.ClientId, login.AuthenticatorSource())
 	}
 
 	resp := login.ToResponse(uint32(code))
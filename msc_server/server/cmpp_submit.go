@@ -2,13 +2,11 @@ package server
 
 import (
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/hrygo/log"
 	"github.com/panjf2000/gnet/v2"
 
-	"github.com/hrygo/gosms/auth"
 	"github.com/hrygo/gosms/codec/cmpp"
 	"github.com/hrygo/gosms/msc_server"
 	"github.com/hrygo/gosms/utils"
@@ -53,8 +51,8 @@ var cmppSubmit TrafficHandler = func(cmd, seq uint32, buff []byte, c gnet.Conn,
 
 func handleCmppSubmit(s *Server, sc *session, mt *cmpp.Submit) {
 	// 【会话级别流控】采用通道控制消息收发窗口,向通道发送信号
-	sc.window <- struct{}{}
-	defer func() { <-sc.window }()
+	release := sc.acquireWindow()
+	defer release()
 
 	var msg = fmt.Sprintf("[%s] OnTraffic %s", s.name, RC)
 
@@ -69,9 +67,15 @@ func handleCmppSubmit(s *Server, sc *session, mt *cmpp.Submit) {
 	// 4. 模拟网关整体的处理耗时
 	mockRandPrecessTime()
 	// 5. 按比例模拟失败情况
-	if utils.DiceCheck(msc.ConfigYml.GetFloat64("Server.Mock.SuccessRate")) {
+	if result == 0 && utils.DiceCheck(msc.ConfigYml.GetFloat64("Server.Mock.SuccessRate")) {
 		result = uint32(cmpp.MtFlowCtrl)
 	}
+	// 6. 发送量配额检查，仅统计检查通过的号码
+	if result == 0 {
+		if code, exceeded := quotaCheck(sc, cmppDestIds(mt)); exceeded {
+			result = code
+		}
+	}
 	// ...
 	// n. 发送响应
 	resp, err := sendSubmitResponse(sc, mt, result)
@@ -88,17 +92,28 @@ func handleCmppSubmit(s *Server, sc *session, mt *cmpp.Submit) {
 	}
 }
 
-// 协议包检查，并根据检查情况给result赋值
+// 协议包检查，发送号码、业务代码、资费类型及优先级不符合客户端配置时给result赋值
 func cmppSubmitPacketCheck(s *session, mt *cmpp.Submit) (result uint32, err error) {
-	// 获取客户端信息
-	cli := auth.Cache.FindByCid(s.serverName, s.clientId)
-	check := strings.HasPrefix(mt.SrcId(), cli.SmsDisplayNo)
-	if check {
-		check = mt.MsgLevel() < 10
+	return submitCheck(s, &submitFields{
+		srcId:     mt.SrcId(),
+		serviceId: mt.ServiceId(),
+		feeType:   mt.FeeType(),
+		msgLevel:  int(mt.MsgLevel()),
+	})
+}
+
+// cmppDestIds 接收号码列表，3.0版每个号码32字节，2.0版21字节
+func cmppDestIds(mt *cmpp.Submit) []string {
+	l := 21
+	if cmpp.V30.MajorMatchV(mt.Version) {
+		l = 32
 	}
-	// do more check
-	// ...
-	return 0, nil
+	ids := mt.TermIds()
+	ret := make([]string, 0, mt.DestUsrTl())
+	for i := 0; i+l <= len(ids); i += l {
+		ret = append(ret, utils.TrimStr(ids[i:i+l]))
+	}
+	return ret
 }
 
 func mockSendCmppReport(sc *session, sub *cmpp.Submit, msgId uint64) {
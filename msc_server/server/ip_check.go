@@ -0,0 +1,34 @@
+package server
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/hrygo/log"
+	"github.com/panjf2000/gnet/v2"
+
+	"github.com/hrygo/gosms/auth"
+	"github.com/hrygo/gosms/msc_server"
+)
+
+// ipAllowed 检查客户端是否允许从会话的来源地址登录
+func ipAllowed(s *Server, sc *session, clientId string) bool {
+	var addr net.Addr
+	if sc.conn != nil {
+		addr = sc.conn.RemoteAddr()
+	}
+	if msc.AllowIP(s.name, clientId, addr) {
+		return true
+	}
+	log.Warn(fmt.Sprintf("[%s] OnTraffic %s", s.name, RC),
+		FlatMapLog(sc.LogSession(), []log.Field{SErrField(fmt.Sprintf(msc.ErrorsIPNotAllowed, msc.AddrIP(addr)))})...)
+	return false
+}
+
+// unknownIP 开启 Server.DropUnknownIP 后，来源地址不被任何客户端允许时，连接建立时即关闭，不占用会话
+func unknownIP(s *Server, c gnet.Conn) bool {
+	if !msc.ConfigYml.GetBool("Server.DropUnknownIP") {
+		return false
+	}
+	return !auth.AllowIP(s.name, msc.AddrIP(c.RemoteAddr()))
+}
@@ -2,6 +2,7 @@ package server
 
 import (
 	"encoding/hex"
+	"fmt"
 
 	"github.com/hrygo/log"
 )
@@ -48,6 +49,11 @@ func Packet2HexLogStr(pack []byte) log.Field {
 	return log.String(LogKeyPacket, hex.EncodeToString(pack))
 }
 
+// SecretPacketLogStr 登录请求的数据包含密码或认证串，日志中只记录其长度
+func SecretPacketLogStr(pack []byte) log.Field {
+	return log.String(LogKeyPacket, fmt.Sprintf("****** (%d bytes)", len(pack)))
+}
+
 // Operation 操作类型定义
 type Operation byte
 
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hrygo/log"
+
+	"github.com/hrygo/gosms/auth"
+	"github.com/hrygo/gosms/msc_server"
+	"github.com/hrygo/gosms/msc_server/loginguard"
+)
+
+// loginGuard CMPP、SMGP 登录时间戳及重放检查，未启用时为nil
+var loginGuard *loginguard.Guard
+
+// StartLoginGuard 按配置文件启用登录检查：时间戳与服务器时间的偏差不能超过 Server.Login.MaxClockSkew，
+// 同一认证码在 Server.Login.ReplayWindow 内的使用次数不能超过客户端的 max-conns；均为0时不检查，
+// 仅配置 ReplayWindow 时返回错误
+func StartLoginGuard() error {
+	skew := msc.ConfigYml.GetDuration("Server.Login.MaxClockSkew")
+	window := msc.ConfigYml.GetDuration("Server.Login.ReplayWindow")
+	if skew <= 0 && window <= 0 {
+		return nil
+	}
+	g, err := loginguard.New(skew, window)
+	if err != nil {
+		return err
+	}
+	loginGuard = g
+	log.Infof("[LoginGuard] Started with max clock skew %v, replay window %v.", skew, window)
+	return nil
+}
+
+// loginFresh 检查认证通过的登录请求的时间戳及认证码，不是重放的请求时返回 true 并记录认证码的本次使用，
+// 之后登录失败时需调用 releaseLogin
+func loginFresh(s *Server, sc *session, clientId string, ts uint32, authenticator []byte, maxConns int) bool {
+	if loginGuard == nil {
+		return true
+	}
+	err := loginGuard.Reserve(auth.Key(s.name, clientId), ts, authenticator, maxConns, time.Now())
+	if err == nil {
+		return true
+	}
+	log.Warn(fmt.Sprintf("[%s] OnTraffic %s", s.name, RC),
+		FlatMapLog(sc.LogSession(), []log.Field{SErrField(fmt.Sprintf(msc.ErrorsLoginRejected, err))})...)
+	return false
+}
+
+// releaseLogin 登录失败时撤销 loginFresh 记录的认证码使用
+func releaseLogin(s *Server, clientId string, authenticator []byte) {
+	if loginGuard != nil {
+		loginGuard.Release(auth.Key(s.name, clientId), authenticator, time.Now())
+	}
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/hrygo/log"
+
+	"github.com/hrygo/gosms/auth"
+	"github.com/hrygo/gosms/codec"
+	"github.com/hrygo/gosms/msc_server"
+	"github.com/hrygo/gosms/utils"
+)
+
+// onAuthChange 客户端被删除或停用时断开其已登录的会话，配置修改时调整会话的窗口大小及限速
+func (s *Server) onAuthChange(e *auth.Event) {
+	if e.Type == auth.EventCreate || e.Client.ISP != s.name {
+		return
+	}
+	msg := fmt.Sprintf("[%s] OnAuthChange ===", s.name)
+	ac := &codec.AuthConf{}
+	utils.StructCopy(e.Client, ac)
+	reason := ""
+	if e.Type == auth.EventDelete {
+		reason = "deleted"
+	} else if e.Client.Disabled {
+		reason = "disabled"
+	}
+
+	s.sessionPool.Range(func(key, value any) bool {
+		sc, ok := value.(*session)
+		if !ok {
+			return true
+		}
+		// 被删除或停用的客户端的会话置为关闭中，不再处理其请求，也避免重复断开
+		sc.Lock()
+		match := sc.stat == StatLogin && sc.clientId == e.Client.ClientId
+		if match && reason != "" {
+			sc.stat = StatClosing
+		}
+		sc.Unlock()
+		if !match {
+			return true
+		}
+		if reason != "" {
+			log.Warn(msg, FlatMapLog(sc.LogSession(),
+				[]log.Field{OpConnectionClose.Field(), SErrField(fmt.Sprintf(msc.ErrorsClientRemoved, reason))})...)
+			sendTerminate(s, sc)
+		} else {
+			sc.applyAuthConf(ac)
+			log.Info(msg, FlatMapLog(sc.LogSession(), sc.LogCounter())...)
+		}
+		return true
+	})
+}
@@ -25,6 +25,7 @@ func (s *Server) OnClose(c gnet.Conn, e error) (action gnet.Action) {
 
 func deferFunc(c gnet.Conn, s *Server, sc *session) {
 	sc.closeResource() // 关闭通道，释放线程池
+	deregisterSession(s, sc)
 	s.sessionPool.Delete(sc.Id())
 	s.activeSessions -= 1
 	c.SetContext(nil)
@@ -12,6 +12,11 @@ import (
 func (s *Server) OnOpen(c gnet.Conn) (out []byte, action gnet.Action) {
 	var msg = fmt.Sprintf("[%s] OnOpen ===", s.name)
 
+	if unknownIP(s, c) {
+		log.Warn(msg, FlatMapLog(s.LogCounter(), []log.Field{OpConnectionClose.Field(),
+			SErrField(fmt.Sprintf(msc.ErrorsIPNotAllowed, c.RemoteAddr()))})...)
+		return nil, gnet.Close
+	}
 	if s.ActiveSessions() >= s.sessionPoolCap {
 		// 已达到连接数阈值时，拒绝新的连接
 		log.Warn(msg, FlatMapLog(s.LogCounter(), []log.Field{OpFlowControl.Field(), SErrField(msc.ErrorsSessionThreshReached)})...)
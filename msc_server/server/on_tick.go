@@ -32,9 +32,10 @@ func (s *Server) OnTick() (delay time.Duration, action gnet.Action) {
 			if pass {
 				log.Info(msg, FlatMapLog(session.LogSession(), session.LogCounter())...)
 			}
-			// 发送心跳测试
+			// 发送心跳测试，并续期会话登记
 			if pass && session.stat == StatLogin {
 				activeTest(s, session)
+				refreshSession(s, session)
 			}
 			// 发送模拟上行消息
 			if pass && session.stat == StatLogin {
@@ -164,31 +165,36 @@ func mockDelivery(s *Server, sc *session) {
 	})
 }
 
+// sendTerminate 发送断开连接的请求，登录线程池已满时在当前协程发送（AsyncWrite 可在任意协程中调用）
 func sendTerminate(s *Server, sc *session) {
-	_ = s.goPool.Submit(func() {
-		msg := fmt.Sprintf("[%s] OnTick %s", s.name, SD)
-		var term codec.RequestPdu
-		var seq = uint32(codec.B32Seq.NextVal())
-		switch s.name {
-		case CMPP:
-			term = cmpp.NewTerminate(seq)
-		case SGIP:
-			term = sgip.NewUnbind()
-		case SMGP:
-			term = smgp.NewExit(seq)
-		}
-		pack := term.Encode()
-		err := sc.conn.AsyncWrite(pack, func(c gnet.Conn) error {
-			_ = sc.Conn().Flush()
-			// 给对方预留1秒钟响应连接关闭事件
-			time.Sleep(time.Second)
-			_ = sc.Conn().Close()
-			return nil
-		})
-		if err == nil {
-			log.Info(msg, FlatMapLog(sc.LogSession(), term.Log())...)
-		} else {
-			log.Error(msg, FlatMapLog(sc.LogSession(), []log.Field{SErrField(err.Error())})...)
-		}
+	if err := s.goPool.Submit(func() { terminate(s, sc) }); err != nil {
+		terminate(s, sc)
+	}
+}
+
+func terminate(s *Server, sc *session) {
+	msg := fmt.Sprintf("[%s] OnTick %s", s.name, SD)
+	var term codec.RequestPdu
+	var seq = uint32(codec.B32Seq.NextVal())
+	switch s.name {
+	case CMPP:
+		term = cmpp.NewTerminate(seq)
+	case SGIP:
+		term = sgip.NewUnbind()
+	case SMGP:
+		term = smgp.NewExit(seq)
+	}
+	pack := term.Encode()
+	err := sc.conn.AsyncWrite(pack, func(c gnet.Conn) error {
+		_ = sc.Conn().Flush()
+		// 给对方预留1秒钟响应连接关闭事件
+		time.Sleep(time.Second)
+		_ = sc.Conn().Close()
+		return nil
 	})
+	if err == nil {
+		log.Info(msg, FlatMapLog(sc.LogSession(), term.Log())...)
+	} else {
+		log.Error(msg, FlatMapLog(sc.LogSession(), []log.Field{SErrField(err.Error())})...)
+	}
 }
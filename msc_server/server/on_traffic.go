@@ -100,7 +100,11 @@ func DecodeAndCheckHeader(s *Server, c gnet.Conn) (cmd uint32, seq uint32, buff
 	// If you have to use buf in a new goroutine, then you need to make a copy of buf and pass this copy to that new goroutine.
 	newBuff := make([]byte, len(buff))
 	copy(newBuff, buff)
-	log.Debug(msg, FlatMapLog(sc.LogSession(), []log.Field{op.OpLog(), Packet2HexLogStr(newBuff)})...)
+	packet := Packet2HexLogStr(newBuff)
+	if op == cmpp.CMPP_CONNECT || op == sgip.SGIP_BIND || op == smgp.SMGP_LOGIN {
+		packet = SecretPacketLogStr(newBuff)
+	}
+	log.Debug(msg, FlatMapLog(sc.LogSession(), []log.Field{op.OpLog(), packet})...)
 
 	return cmd, seq, newBuff, gnet.None, true
 }
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hrygo/log"
+
+	"github.com/hrygo/gosms/auth"
+	"github.com/hrygo/gosms/event_manager"
+	"github.com/hrygo/gosms/msc_server"
+	"github.com/hrygo/gosms/msc_server/quota"
+)
+
+// quotaCounter 发送量配额计数存储，未启用时为nil
+var quotaCounter quota.Counter
+
+// 各协议超出配额时默认的响应状态码：CMPP 8 流量控制错，SMGP 75 SP发送超过日流量，SGIP 11 节点忙
+var quotaResults = map[string]uint32{CMPP: 8, SMGP: 75, SGIP: 11}
+
+// StartQuota 按配置文件启用客户端发送量配额（Quota.Enable），计数保存在 Quota.Store 指定的存储中：
+// memory 进程内存储，bolt 保存到本地文件 Quota.Path，重启后保留计数
+func StartQuota() error {
+	if !msc.ConfigYml.GetBool("Quota.Enable") {
+		return nil
+	}
+	var counter quota.Counter = quota.NewMemoryCounter()
+	store := msc.ConfigYml.GetString("Quota.Store")
+	if store == "bolt" {
+		path := msc.ConfigYml.GetString("Quota.Path")
+		if path == "" {
+			path = "data/quota.db"
+		}
+		c, err := quota.OpenBolt(msc.BasePath + path)
+		if err != nil {
+			return err
+		}
+		counter = c
+	}
+	for isp := range quotaResults {
+		if code := msc.ConfigYml.GetInt("Quota.ResultCodes." + isp); code > 0 {
+			quotaResults[isp] = uint32(code)
+		}
+	}
+	event_manager.RegisterShutdownHooker("Close_QuotaCounter", func(args ...any) {
+		_ = counter.Close()
+	})
+	quotaCounter = counter
+	log.Infof("[Quota] Started with %s store.", store)
+	return nil
+}
+
+// quotaCheck 对提交成功的接收号码计数，超出客户端配额时返回协议对应的响应状态码
+func quotaCheck(sc *session, phones []string) (result uint32, exceeded bool) {
+	if quotaCounter == nil {
+		return 0, false
+	}
+	cli := auth.Cache.FindByCid(sc.serverName, sc.clientId)
+	if cli == nil || len(cli.Quotas) == 0 {
+		return 0, false
+	}
+	q, err := quota.Take(context.Background(), quotaCounter, cli, phones, time.Now())
+	msg := fmt.Sprintf("[%s] OnTraffic %s", sc.ServerName(), RC)
+	if err != nil {
+		// 计数存储异常时不拦截提交
+		log.Error(msg, FlatMapLog(sc.LogSession(), []log.Field{SErrField(err.Error())})...)
+		return 0, false
+	}
+	if q == nil {
+		return 0, false
+	}
+	log.Warn(msg, FlatMapLog(sc.LogSession(), []log.Field{
+		SErrField(fmt.Sprintf(msc.ErrorsQuotaExceeded, q.Period, q.Prefix, q.Limit, strings.Join(phones, ",")))})...)
+	return quotaResults[sc.serverName], true
+}
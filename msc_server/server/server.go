@@ -11,6 +11,7 @@ import (
 	"github.com/panjf2000/gnet/v2"
 	"github.com/panjf2000/gnet/v2/pkg/pool/goroutine"
 
+	"github.com/hrygo/gosms/auth"
 	bs "github.com/hrygo/gosms/msc_server"
 )
 
@@ -40,6 +41,7 @@ type Server struct {
 }
 
 func Start(s *Server) {
+	auth.OnChange(s.onAuthChange)
 	go func() {
 		defer s.goPool.Release()
 		addr := fmt.Sprintf("%s://:%d", s.protocol, s.port)
@@ -29,6 +29,7 @@ type session struct {
 	window      chan struct{}   // 流控所需通道，登录成功后需设置此值，否则消息不能正常收发
 	pool        *goroutine.Pool // 会话级别的线程池，登录成功后需设置此值，否则消息不能正常收发
 	limiter     *rate.Limiter   // 限速器
+	regKey      string          // 会话登记（见 sessionRegistry）的客户端键，登记成功后设置
 	counter                     // mt, dly, report 计数器
 }
 
@@ -94,6 +95,37 @@ func (s *session) setupLimiter(ac *codec.AuthConf) {
 	s.limiter = rate.NewLimiter(limit, cap(s.window))
 }
 
+// acquireWindow 占用一个流控窗口，返回释放函数。
+// 窗口大小调整后通道会被替换，已占用的窗口仍在原通道中释放
+func (s *session) acquireWindow() (release func()) {
+	s.Lock()
+	w := s.window
+	s.Unlock()
+	w <- struct{}{}
+	return func() { <-w }
+}
+
+// applyAuthConf 客户端配置变更后，调整已登录会话的窗口大小、线程池容量及限速
+func (s *session) applyAuthConf(ac *codec.AuthConf) {
+	s.Lock()
+	defer s.Unlock()
+	if s.stat != StatLogin || s.window == nil {
+		return
+	}
+	if cap(s.window) != ac.MtWindowSize {
+		s.window = make(chan struct{}, ac.MtWindowSize)
+		s.pool.Tune(ac.MtWindowSize * 2)
+	}
+	old := s.limiter
+	s.setupLimiter(ac)
+	if old != nil {
+		// 保留已有的令牌状态
+		old.SetLimit(s.limiter.Limit())
+		old.SetBurst(s.limiter.Burst())
+		s.limiter = old
+	}
+}
+
 // 关闭通道和线程池
 func (s *session) closeResource() {
 	if s == nil {
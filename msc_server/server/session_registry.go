@@ -0,0 +1,136 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/hrygo/log"
+
+	"github.com/hrygo/gosms/auth"
+	"github.com/hrygo/gosms/event_manager"
+	"github.com/hrygo/gosms/msc_server"
+	"github.com/hrygo/gosms/msc_server/cluster"
+)
+
+// sessionRegistry 已登录会话的登记存储，默认仅统计本节点的会话
+var sessionRegistry cluster.SessionRegistry = cluster.NewMemoryRegistry()
+
+// sessionNode 本节点的名称，用于区分不同节点的会话
+var sessionNode string
+
+// StartCluster 按配置文件选择会话登记存储（Cluster.Store）：memory 仅统计本节点的会话，
+// redis 集群中的节点共享会话数，使客户端的 max-conns 对整个集群生效。
+// Cluster.SessionTTL 小于定时器间隔的2倍时，会话可能在续期前失效，返回错误
+func StartCluster() error {
+	tick := msc.ConfigYml.GetDuration("Server.TickDuration")
+	if ttl := msc.ConfigYml.GetDuration("Cluster.SessionTTL"); ttl > 0 && ttl < 2*tick {
+		return fmt.Errorf("Cluster.SessionTTL %v is less than twice Server.TickDuration %v", ttl, tick)
+	}
+	sessionNode = msc.ConfigYml.GetString("Cluster.Node")
+	if sessionNode == "" {
+		host, _ := os.Hostname()
+		sessionNode = fmt.Sprintf("%s:%d", host, os.Getpid())
+	}
+	store := msc.ConfigYml.GetString("Cluster.Store")
+	if store != "redis" {
+		return nil
+	}
+	client := redis.NewClient(&redis.Options{
+		Addr:     msc.ConfigYml.GetString("Cluster.Redis.Addr"),
+		Password: msc.ConfigYml.GetString("Cluster.Redis.Password"),
+		DB:       msc.ConfigYml.GetInt("Cluster.Redis.DB"),
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
+		return err
+	}
+	prefix := msc.ConfigYml.GetString("Cluster.Redis.KeyPrefix")
+	if prefix == "" {
+		prefix = "gosms:sessions:"
+	}
+	registry := cluster.NewRedisRegistry(client, prefix)
+	event_manager.RegisterShutdownHooker("Close_SessionRegistry", func(args ...any) {
+		_ = registry.Close()
+	})
+	sessionRegistry = registry
+	log.Infof("[Cluster] Started with %s store, node %s.", store, sessionNode)
+	return nil
+}
+
+// sessionTTL 会话登记的有效期（Cluster.SessionTTL），由定时器续期，默认为定时器间隔的3倍
+func sessionTTL() time.Duration {
+	ttl := msc.ConfigYml.GetDuration("Cluster.SessionTTL")
+	if ttl <= 0 {
+		ttl = 3 * msc.ConfigYml.GetDuration("Server.TickDuration")
+	}
+	if ttl <= 0 {
+		ttl = 30 * time.Second
+	}
+	return ttl
+}
+
+// sessionMember 会话在登记存储中的标识
+func sessionMember(sc *session) string {
+	return fmt.Sprintf("%s/%d", sessionNode, sc.id)
+}
+
+// registerSession 登记会话，客户端在集群中已登录的会话数已达 max 时返回 false；
+// 登记存储异常时按本节点的会话数检查
+func registerSession(s *Server, sc *session, clientId string, max int) bool {
+	msg := fmt.Sprintf("[%s] OnTraffic %s", s.name, RC)
+	key := auth.Key(s.name, clientId)
+	n, err := sessionRegistry.Register(context.Background(), key, sessionMember(sc), max, sessionTTL())
+	if err == nil {
+		sc.Lock()
+		sc.regKey = key
+		sc.Unlock()
+		return true
+	}
+	if errors.Is(err, cluster.ErrMaxConns) {
+		log.Warn(msg, FlatMapLog(sc.LogSession(), []log.Field{SErrField(fmt.Sprintf(msc.ErrorsMaxConnsReached, n, max))})...)
+		return false
+	}
+	log.Error(msg, FlatMapLog(sc.LogSession(), []log.Field{SErrField(err.Error())})...)
+	return s.CountSessionByClientId(clientId) < max
+}
+
+// refreshSession 续期已登记的会话
+func refreshSession(s *Server, sc *session) {
+	sc.Lock()
+	key := sc.regKey
+	sc.Unlock()
+	if key == "" {
+		return
+	}
+	_ = s.goPool.Submit(func() {
+		if err := sessionRegistry.Refresh(context.Background(), key, sessionMember(sc), sessionTTL()); err != nil {
+			log.Error(fmt.Sprintf("[%s] OnTick ===", s.name), FlatMapLog(sc.LogSession(), []log.Field{SErrField(err.Error())})...)
+		}
+	})
+}
+
+// deregisterSession 注销已登记的会话
+func deregisterSession(s *Server, sc *session) {
+	if sc == nil {
+		return
+	}
+	sc.Lock()
+	key := sc.regKey
+	sc.regKey = ""
+	sc.Unlock()
+	if key == "" {
+		return
+	}
+	member := sessionMember(sc)
+	_ = s.goPool.Submit(func() {
+		if err := sessionRegistry.Deregister(context.Background(), key, member); err != nil {
+			log.Errorf("[%s] OnClose === deregister session %s error: %v", s.name, member, err)
+		}
+	})
+}
@@ -29,7 +29,7 @@ var sgipBind TrafficHandler = func(cmd, seq uint32, buff []byte, c gnet.Conn, s
 	})
 	if err != nil {
 		log.Error(fmt.Sprintf("[%s] OnTraffic %s", sc.ServerName(), RC),
-			FlatMapLog(sc.LogSession(), []log.Field{OpConnectionClose.Field(), ErrorField(err), Packet2HexLogStr(buff)})...)
+			FlatMapLog(sc.LogSession(), []log.Field{OpConnectionClose.Field(), ErrorField(err), SecretPacketLogStr(buff)})...)
 		return false, gnet.Close
 	}
 
@@ -46,14 +46,14 @@ func handleSgipBind(s *Server, sc *session, login *sgip.Bind) {
 	ac := msc.FindAuthConf(s.name, login.LoginName)
 	code := login.Check(ac)
 
-	// 检查当前已登录会话数是否已达上限
-	if code == sgip.Status(0) {
-		// 注意这里仅按照单节点计算某个client的session数，实际上应该计算集群中的某个client的session数。
-		// TODO 要支持集群，连接会话的计数，应该采用数据库或者redis等存储。
-		activeSession := s.CountSessionByClientId(ac.ClientId)
-		if activeSession >= ac.MaxConns {
-			code = sgip.Status(3)
-		}
+	// 检查来源地址是否在客户端的IP白名单内
+	if code == sgip.Status(0) && !ipAllowed(s, sc, login.LoginName) {
+		code = sgip.Status(1)
+	}
+
+	// 检查客户端在集群中已登录的会话数是否已达上限，未达上限时登记本会话
+	if code == sgip.Status(0) && !registerSession(s, sc, ac.ClientId, ac.MaxConns) {
+		code = sgip.Status(3)
 	}
 
 	resp := login.ToResponse(uint32(code))
@@ -2,12 +2,11 @@ package server
 
 import (
 	"fmt"
-	"strings"
+	"strconv"
 
 	"github.com/hrygo/log"
 	"github.com/panjf2000/gnet/v2"
 
-	"github.com/hrygo/gosms/auth"
 	"github.com/hrygo/gosms/codec/sgip"
 	"github.com/hrygo/gosms/msc_server"
 	"github.com/hrygo/gosms/utils"
@@ -52,8 +51,8 @@ var sgipSubmit TrafficHandler = func(cmd, seq uint32, buff []byte, c gnet.Conn,
 
 func handleSgipSubmit(s *Server, sc *session, mt *sgip.Submit) {
 	// 【会话级别流控】采用通道控制消息收发窗口,向通道发送信号
-	sc.window <- struct{}{}
-	defer func() { <-sc.window }()
+	release := sc.acquireWindow()
+	defer release()
 
 	var msg = fmt.Sprintf("[%s] OnTraffic %s", s.name, RC)
 
@@ -68,9 +67,15 @@ func handleSgipSubmit(s *Server, sc *session, mt *sgip.Submit) {
 	// 4. 模拟网关整体的处理耗时
 	mockRandPrecessTime()
 	// 5. 按比例模拟失败情况
-	if utils.DiceCheck(msc.ConfigYml.GetFloat64("Server.Mock.SuccessRate")) {
+	if result == 0 && utils.DiceCheck(msc.ConfigYml.GetFloat64("Server.Mock.SuccessRate")) {
 		result = 33
 	}
+	// 6. 发送量配额检查，仅统计检查通过的号码
+	if result == 0 {
+		if code, exceeded := quotaCheck(sc, mt.UserNumber); exceeded {
+			result = code
+		}
+	}
 	// ...
 	// n. 发送响应
 	_, err := sendSubmitResponse(sc, mt, result)
@@ -84,15 +89,12 @@ func handleSgipSubmit(s *Server, sc *session, mt *sgip.Submit) {
 	// TODO 建立链接发送状态报告
 }
 
-// 协议包检查，并根据检查情况给result赋值
+// 协议包检查，发送号码、业务代码、资费类型及优先级不符合客户端配置时给result赋值
 func sgipSubmitPacketCheck(s *session, mt *sgip.Submit) (result uint32, err error) {
-	// 获取客户端信息
-	cli := auth.Cache.FindByCid(s.serverName, s.clientId)
-	check := strings.HasPrefix(mt.SPNumber, cli.SmsDisplayNo)
-	if check {
-		check = mt.Priority < 10
-	}
-	// do more check
-	// ...
-	return 0, nil
+	return submitCheck(s, &submitFields{
+		srcId:     mt.SPNumber,
+		serviceId: mt.ServiceType,
+		feeType:   strconv.Itoa(int(mt.FeeType)),
+		msgLevel:  int(mt.Priority),
+	})
 }
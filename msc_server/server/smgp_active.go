@@ -42,8 +42,8 @@ var smgpActive TrafficHandler = func(cmd, seq uint32, buff []byte, c gnet.Conn,
 
 func handleSmgpActive(s *Server, sc *session, pdu *smgp.ActiveTest) {
 	// 【会话级别流控】采用通道控制消息收发速度,向通道发送信号
-	sc.window <- struct{}{}
-	defer func() { <-sc.window }()
+	release := sc.acquireWindow()
+	defer release()
 	// 这里采用流量控制目的是防止客户端采用Active进行拒绝服务攻击
 
 	var msg = fmt.Sprintf("[%s] OnTraffic %s", s.name, RC)
@@ -41,8 +41,8 @@ var smgpExitResp TrafficHandler = func(cmd, seq uint32, buff []byte, c gnet.Conn
 
 func handleSmgpExitResp(s *Server, sc *session, term *smgp.ExitRsp) {
 	// 【会话级别流控】采用通道控制消息收发速度,向通道发送信号
-	sc.window <- struct{}{}
-	defer func() { <-sc.window }()
+	release := sc.acquireWindow()
+	defer release()
 	// 这里采用流量控制目的是防止客户端采用此消息进行拒绝服务攻击
 
 	var msg = fmt.Sprintf("[%s] OnTraffic %s", s.name, RC)
@@ -29,7 +29,7 @@ var smgpLogin TrafficHandler = func(cmd, seq uint32, buff []byte, c gnet.Conn, s
 	})
 	if err != nil {
 		log.Error(fmt.Sprintf("[%s] OnTraffic %s", sc.ServerName(), RC),
-			FlatMapLog(sc.LogSession(), []log.Field{OpConnectionClose.Field(), ErrorField(err), Packet2HexLogStr(buff)})...)
+			FlatMapLog(sc.LogSession(), []log.Field{OpConnectionClose.Field(), ErrorField(err), SecretPacketLogStr(buff)})...)
 		return false, gnet.Close
 	}
 
@@ -46,14 +46,20 @@ func handleSmgpLogin(s *Server, sc *session, login *smgp.Login) {
 	cli := msc.FindAuthConf(s.name, login.ClientID())
 	code := login.Check(cli)
 
-	// 检查当前已登录会话数是否已达上限
-	if code == smgp.Status(0) {
-		// 注意这里仅按照单节点计算某个client的session数，实际上应该计算集群中的某个client的session数。
-		// TODO 要支持集群，连接会话的计数，应该采用数据库或者redis等存储。
-		activeSession := s.CountSessionByClientId(cli.ClientId)
-		if activeSession >= cli.MaxConns {
-			code = smgp.Status(2)
-		}
+	// 检查来源地址是否在客户端的IP白名单内
+	if code == smgp.Status(0) && !ipAllowed(s, sc, login.ClientID()) {
+		code = smgp.Status(20)
+	}
+
+	// 检查时间戳与服务器时间的偏差，并拒绝重放的登录请求
+	if code == smgp.Status(0) && !loginFresh(s, sc, cli.ClientId, login.Timestamp(), login.AuthenticatorClient(), cli.MaxConns) {
+		code = smgp.Status(21)
+	}
+
+	// 检查客户端在集群中已登录的会话数是否已达上限，未达上限时登记本会话
+	if code == smgp.Status(0) && !registerSession(s, sc, cli.ClientId, cli.MaxConns) {
+		code = smgp.Status(2)
+		releaseLogin(s, cli.ClientId, login.AuthenticatorClient())
 	}
 
 	resp := login.ToResponse(uint32(code))
@@ -2,13 +2,11 @@ package server
 
 import (
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/hrygo/log"
 	"github.com/panjf2000/gnet/v2"
 
-	"github.com/hrygo/gosms/auth"
 	"github.com/hrygo/gosms/codec"
 	"github.com/hrygo/gosms/codec/smgp"
 	"github.com/hrygo/gosms/msc_server"
@@ -54,8 +52,8 @@ var smgpSubmit TrafficHandler = func(cmd, seq uint32, buff []byte, c gnet.Conn,
 
 func handleSmgpSubmit(s *Server, sc *session, mt *smgp.Submit) {
 	// 【会话级别流控】采用通道控制消息收发窗口,向通道发送信号
-	sc.window <- struct{}{}
-	defer func() { <-sc.window }()
+	release := sc.acquireWindow()
+	defer release()
 
 	var msg = fmt.Sprintf("[%s] OnTraffic %s", s.name, RC)
 
@@ -70,9 +68,15 @@ func handleSmgpSubmit(s *Server, sc *session, mt *smgp.Submit) {
 	// 4. 模拟网关整体的处理耗时
 	mockRandPrecessTime()
 	// 5. 按比例模拟失败情况
-	if utils.DiceCheck(msc.ConfigYml.GetFloat64("Server.Mock.SuccessRate")) {
+	if result == 0 && utils.DiceCheck(msc.ConfigYml.GetFloat64("Server.Mock.SuccessRate")) {
 		result = 75
 	}
+	// 6. 发送量配额检查，仅统计检查通过的号码
+	if result == 0 {
+		if code, exceeded := quotaCheck(sc, mt.DestTermID()); exceeded {
+			result = code
+		}
+	}
 	// ...
 	// n. 发送响应
 	resp, err := sendSubmitResponse(sc, mt, result)
@@ -89,17 +93,14 @@ func handleSmgpSubmit(s *Server, sc *session, mt *smgp.Submit) {
 	}
 }
 
-// 协议包检查，并根据检查情况给result赋值
+// 协议包检查，发送号码、业务代码、资费类型及优先级不符合客户端配置时给result赋值
 func smgpSubmitPacketCheck(s *session, mt *smgp.Submit) (result uint32, err error) {
-	// 获取客户端信息
-	cli := auth.Cache.FindByCid(s.serverName, s.clientId)
-	check := strings.HasPrefix(mt.SrcTermID(), cli.SmsDisplayNo)
-	if check {
-		check = mt.Priority() < 4
-	}
-	// do more check
-	// ...
-	return 0, nil
+	return submitCheck(s, &submitFields{
+		srcId:     mt.SrcTermID(),
+		serviceId: mt.ServiceID(),
+		feeType:   mt.FeeType(),
+		msgLevel:  int(mt.Priority()),
+	})
 }
 
 func mockSendSmgpReport(sc *session, sub *smgp.Submit, msgId []byte) {
@@ -0,0 +1,73 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hrygo/log"
+
+	"github.com/hrygo/gosms/auth"
+	"github.com/hrygo/gosms/codec/cmpp"
+	"github.com/hrygo/gosms/msc_server"
+)
+
+// submitFields 提交请求中按客户端配置检查的字段
+type submitFields struct {
+	srcId     string
+	serviceId string
+	feeType   string
+	msgLevel  int
+}
+
+// 各协议提交检查不通过时的响应状态码：
+// CMPP 10 Src_Id错误（仅3.0版，2.0版使用 9 其他错误）、7 业务代码错、5 资费代码错、1 消息结构错；
+// SMGP 46 非法发送用户号码、43 非法服务代码、32 非法资费类型、31 非法优先级；
+// SGIP 均为 5 参数格式错
+var submitResults = map[string]map[error]uint32{
+	CMPP: {auth.ErrSrcId: 10, auth.ErrSubNumber: 10, auth.ErrServiceId: 7, auth.ErrFeeType: 5, auth.ErrMsgLevel: 1},
+	SMGP: {auth.ErrSrcId: 46, auth.ErrSubNumber: 46, auth.ErrServiceId: 43, auth.ErrFeeType: 32, auth.ErrMsgLevel: 31},
+	SGIP: {auth.ErrSrcId: 5, auth.ErrSubNumber: 5, auth.ErrServiceId: 5, auth.ErrFeeType: 5, auth.ErrMsgLevel: 5},
+}
+
+// 各协议允许的优先级范围，客户端未配置 msg-levels 时使用
+var submitMsgLevels = map[string]auth.Range{CMPP: "0-9", SMGP: "0-3", SGIP: "0-9"}
+
+// checkSubmit 按客户端配置依次检查发送号码、业务代码、资费类型及优先级，返回首个不符合的字段对应的错误
+func checkSubmit(cli *auth.Client, isp string, f *submitFields) error {
+	if err := cli.CheckSrcId(f.srcId); err != nil {
+		return fmt.Errorf("%w: %s", err, f.srcId)
+	}
+	if err := cli.CheckServiceId(f.serviceId); err != nil {
+		return fmt.Errorf("%w: %s", err, f.serviceId)
+	}
+	if err := cli.CheckFeeType(f.feeType); err != nil {
+		return fmt.Errorf("%w: %s", err, f.feeType)
+	}
+	if err := cli.CheckMsgLevel(f.msgLevel, submitMsgLevels[isp]); err != nil {
+		return fmt.Errorf("%w: %d", err, f.msgLevel)
+	}
+	return nil
+}
+
+// submitCheck 检查提交请求是否符合客户端配置，不符合时记录日志并返回协议对应的响应状态码
+func submitCheck(sc *session, f *submitFields) (result uint32, err error) {
+	cli := auth.Cache.FindByCid(sc.serverName, sc.clientId)
+	if cli == nil {
+		// 客户端已删除的会话由认证变更处理关闭，此处不拦截
+		return 0, nil
+	}
+	if err = checkSubmit(cli, sc.serverName, f); err == nil {
+		return 0, nil
+	}
+	log.Warn(fmt.Sprintf("[%s] OnTraffic %s", sc.ServerName(), RC),
+		FlatMapLog(sc.LogSession(), []log.Field{SErrField(fmt.Sprintf(msc.ErrorsSubmitRejected, err))})...)
+	for e, code := range submitResults[sc.serverName] {
+		if errors.Is(err, e) {
+			if sc.serverName == CMPP && code == 10 && !cmpp.V30.MajorMatch(sc.Ver()) {
+				code = 9
+			}
+			return code, err
+		}
+	}
+	return 0, err
+}
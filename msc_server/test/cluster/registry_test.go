@@ -0,0 +1,97 @@
+package cluster_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hrygo/gosms/msc_server/cluster"
+)
+
+func testRegistry(t *testing.T, r cluster.SessionRegistry, expire func(d time.Duration)) {
+	ctx := context.Background()
+	ttl := time.Second
+
+	n, err := r.Register(ctx, "cmpp_123456", "node1/1", 2, ttl)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, n)
+	// 重复登记同一会话不增加会话数
+	n, err = r.Register(ctx, "cmpp_123456", "node1/1", 2, ttl)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, n)
+	n, err = r.Register(ctx, "cmpp_123456", "node2/1", 2, ttl)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, n)
+	n, err = r.Register(ctx, "cmpp_123456", "node2/2", 2, ttl)
+	assert.ErrorIs(t, err, cluster.ErrMaxConns)
+	assert.Equal(t, 2, n)
+	// 不同客户端分别计数
+	_, err = r.Register(ctx, "smgp_123456", "node2/2", 1, ttl)
+	assert.Nil(t, err)
+
+	// 注销后可再次登记
+	assert.Nil(t, r.Deregister(ctx, "cmpp_123456", "node1/1"))
+	n, _ = r.Count(ctx, "cmpp_123456")
+	assert.Equal(t, 1, n)
+	_, err = r.Register(ctx, "cmpp_123456", "node2/2", 2, ttl)
+	assert.Nil(t, err)
+
+	// 未续期的会话超时失效
+	expire(ttl / 2)
+	assert.Nil(t, r.Refresh(ctx, "cmpp_123456", "node2/1", ttl))
+	expire(ttl/2 + 10*time.Millisecond)
+	n, _ = r.Count(ctx, "cmpp_123456")
+	assert.Equal(t, 1, n)
+	n, _ = r.Count(ctx, "smgp_123456")
+	assert.Equal(t, 0, n)
+	// 已失效的会话续期时重新登记
+	assert.Nil(t, r.Refresh(ctx, "cmpp_123456", "node2/2", ttl))
+	n, _ = r.Count(ctx, "cmpp_123456")
+	assert.Equal(t, 2, n)
+}
+
+func testConcurrentRegister(t *testing.T, r cluster.SessionRegistry) {
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	ok := 0
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := r.Register(ctx, "sgip_3037196688", fmt.Sprintf("node%d/%d", i%3, i), 5, time.Minute); err == nil {
+				mu.Lock()
+				ok++
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, 5, ok)
+}
+
+func TestMemoryRegistry(t *testing.T) {
+	testRegistry(t, cluster.NewMemoryRegistry(), time.Sleep)
+	testConcurrentRegister(t, cluster.NewMemoryRegistry())
+}
+
+func TestRedisRegistry(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	r := cluster.NewRedisRegistry(client, "gosms:sessions:")
+	defer func() { _ = r.Close() }()
+
+	// 失效时间按节点时钟计算，键的过期由 miniredis 模拟
+	testRegistry(t, r, func(d time.Duration) {
+		time.Sleep(d)
+		mr.FastForward(d)
+	})
+	testConcurrentRegister(t, r)
+	assert.True(t, mr.Exists("gosms:sessions:cmpp_123456"))
+}
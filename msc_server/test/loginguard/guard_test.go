@@ -0,0 +1,96 @@
+package loginguard_test
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hrygo/gosms/msc_server/loginguard"
+)
+
+func TestTimestamp(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 30, 0, time.Local)
+	ts, err := loginguard.Timestamp(101000010, now)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2023, 1, 1, 0, 0, 10, 0, time.Local), ts)
+	// 跨年时取与当前时间最接近的年份
+	ts, err = loginguard.Timestamp(1231235959, now)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2022, 12, 31, 23, 59, 59, 0, time.Local), ts)
+	ts, err = loginguard.Timestamp(229120000, time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local))
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2024, 2, 29, 12, 0, 0, 0, time.Local), ts)
+
+	for _, v := range []uint32{0, 1301000000, 1032000000, 1012400000, 1010006000, 1010000060} {
+		_, err = loginguard.Timestamp(v, now)
+		assert.Error(t, err, v)
+	}
+}
+
+func TestGuard(t *testing.T) {
+	now := time.Date(2022, 8, 12, 10, 0, 0, 0, time.Local)
+	g, err := loginguard.New(5*time.Minute, 10*time.Minute)
+	assert.Nil(t, err)
+	auth1, auth2 := []byte{1, 2, 3}, []byte{4, 5, 6}
+	assert.ErrorIs(t, g.Reserve("cmpp_123456", 812095459, auth1, 2, now), loginguard.ErrClockSkew)
+	assert.ErrorIs(t, g.Reserve("cmpp_123456", 812100501, auth1, 2, now), loginguard.ErrClockSkew)
+	assert.ErrorIs(t, g.Reserve("cmpp_123456", 0, auth1, 2, now), loginguard.ErrClockSkew)
+
+	// 撤销的使用不占用次数
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, g.Reserve("cmpp_123456", 812095500, auth1, 2, now))
+		g.Release("cmpp_123456", auth1, now)
+	}
+	// 同一认证码最多使用 limit 次
+	assert.Nil(t, g.Reserve("cmpp_123456", 812095500, auth1, 2, now))
+	assert.Nil(t, g.Reserve("cmpp_123456", 812095500, auth1, 2, now))
+	assert.ErrorIs(t, g.Reserve("cmpp_123456", 812095500, auth1, 2, now), loginguard.ErrReplay)
+	assert.Nil(t, g.Reserve("cmpp_123456", 812095500, auth2, 2, now))
+	assert.Nil(t, g.Reserve("smgp_123456", 812095500, auth1, 1, now))
+	assert.ErrorIs(t, g.Reserve("smgp_123456", 812095500, auth1, 1, now), loginguard.ErrReplay)
+
+	// 超过 window 后不再记录，此时时间戳已超出允许的偏差
+	later := now.Add(10 * time.Minute)
+	assert.ErrorIs(t, g.Reserve("cmpp_123456", 812095500, auth1, 2, later), loginguard.ErrClockSkew)
+
+	// window 小于允许的偏差时，记录保留到时间戳超出偏差
+	g, _ = loginguard.New(5*time.Minute, time.Minute)
+	assert.Nil(t, g.Reserve("cmpp_123456", 812100000, auth1, 1, now))
+	assert.ErrorIs(t, g.Reserve("cmpp_123456", 812100000, auth1, 1, now.Add(4*time.Minute)), loginguard.ErrReplay)
+	assert.ErrorIs(t, g.Reserve("cmpp_123456", 812100000, auth1, 1, now.Add(5*time.Minute+time.Second)), loginguard.ErrClockSkew)
+
+	// 检查重放时必须检查时间戳
+	_, err = loginguard.New(0, time.Minute)
+	assert.ErrorIs(t, err, loginguard.ErrNoSkewCheck)
+
+	// window 为0时不检查重放
+	g, err = loginguard.New(time.Minute, 0)
+	assert.Nil(t, err)
+	for i := 0; i < 3; i++ {
+		assert.Nil(t, g.Reserve("cmpp_123456", 812100000, auth1, 1, now))
+	}
+}
+
+func TestGuard_ConcurrentReplay(t *testing.T) {
+	now := time.Date(2022, 8, 12, 10, 0, 0, 0, time.Local)
+	g, _ := loginguard.New(5*time.Minute, 10*time.Minute)
+
+	// 同时发送的多个重放请求最多 limit 个通过检查
+	var passed int32
+	var wg sync.WaitGroup
+	for i := 0; i < 64; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if g.Reserve("cmpp_123456", 812100000, []byte{1, 2, 3}, 2, now) == nil {
+				atomic.AddInt32(&passed, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(2), passed)
+	assert.ErrorIs(t, g.Reserve("cmpp_123456", 812100000, []byte{1, 2, 3}, 2, now), loginguard.ErrReplay)
+}
@@ -0,0 +1,97 @@
+package quota_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hrygo/gosms/auth"
+	"github.com/hrygo/gosms/msc_server/quota"
+)
+
+func client() *auth.Client {
+	return &auth.Client{
+		ISP:      "cmpp",
+		ClientId: "123456",
+		Quotas: []auth.Quota{
+			{Period: auth.PeriodDay, Limit: 3},
+			{Period: auth.PeriodMonth, Limit: 1, Prefix: "133"},
+		},
+	}
+}
+
+func TestKeyAndEnd(t *testing.T) {
+	now := time.Date(2022, 12, 31, 10, 0, 0, 0, time.Local)
+	assert.Equal(t, "cmpp_123456:day:20221231:", quota.Key("cmpp_123456", auth.Quota{Period: auth.PeriodDay}, now))
+	assert.Equal(t, "cmpp_123456:month:202212:133", quota.Key("cmpp_123456", auth.Quota{Period: auth.PeriodMonth, Prefix: "133"}, now))
+	assert.Equal(t, time.Date(2023, 1, 1, 0, 0, 0, 0, time.Local), quota.End(auth.PeriodDay, now))
+	assert.Equal(t, time.Date(2023, 1, 1, 0, 0, 0, 0, time.Local), quota.End(auth.PeriodMonth, now))
+}
+
+func testTake(t *testing.T, c quota.Counter) {
+	ctx, now, cli := context.Background(), time.Now(), client()
+
+	q, err := quota.Take(ctx, c, cli, []string{"13800001111", "8613300001111"}, now)
+	assert.Nil(t, err)
+	assert.Nil(t, q)
+	// 月配额超出，日配额的计数同时撤销
+	q, err = quota.Take(ctx, c, cli, []string{"13300002222"}, now)
+	assert.Nil(t, err)
+	if assert.NotNil(t, q) {
+		assert.Equal(t, auth.PeriodMonth, q.Period)
+	}
+	q, _ = quota.Take(ctx, c, cli, []string{"13800002222"}, now)
+	assert.Nil(t, q)
+	q, _ = quota.Take(ctx, c, cli, []string{"18600002222"}, now)
+	if assert.NotNil(t, q) {
+		assert.Equal(t, auth.PeriodDay, q.Period)
+	}
+
+	us, err := quota.Usages(ctx, c, cli, now)
+	assert.Nil(t, err)
+	if assert.Len(t, us, 2) {
+		assert.Equal(t, int64(3), us[0].Used)
+		assert.Equal(t, int64(1), us[1].Used)
+	}
+
+	n, err := quota.Reset(ctx, c, cli.Key(), auth.PeriodDay)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, n)
+	q, _ = quota.Take(ctx, c, cli, []string{"18600002222"}, now)
+	assert.Nil(t, q)
+	n, _ = quota.Reset(ctx, c, cli.Key(), "")
+	assert.Equal(t, 2, n)
+	us, _ = quota.Usages(ctx, c, cli, now)
+	assert.Equal(t, int64(0), us[0].Used+us[1].Used)
+}
+
+func TestMemoryCounter(t *testing.T) {
+	testTake(t, quota.NewMemoryCounter())
+}
+
+func TestBoltCounter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quota.db")
+	c, err := quota.OpenBolt(path)
+	if !assert.Nil(t, err) {
+		return
+	}
+	testTake(t, c)
+
+	// 重新打开后计数保留，过期的计数不再生效
+	ctx := context.Background()
+	_, _ = c.IncrBy(ctx, "k", 2, time.Now().Add(time.Hour))
+	_, _ = c.IncrBy(ctx, "expired", 2, time.Now().Add(-time.Second))
+	assert.Nil(t, c.Close())
+	c, err = quota.OpenBolt(path)
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer func() { _ = c.Close() }()
+	n, _ := c.Get(ctx, "k")
+	assert.Equal(t, int64(2), n)
+	n, _ = c.Get(ctx, "expired")
+	assert.Equal(t, int64(0), n)
+}
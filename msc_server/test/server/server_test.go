@@ -0,0 +1,226 @@
+package server_test
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hrygo/yaml_config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hrygo/gosms/auth"
+	"github.com/hrygo/gosms/codec"
+	"github.com/hrygo/gosms/codec/cmpp"
+	"github.com/hrygo/gosms/msc_server"
+	"github.com/hrygo/gosms/msc_server/server"
+)
+
+var (
+	clients *auth.FileStore // 测试用的客户端配置，写入临时目录
+	addr    string          // CMPP 服务地址
+)
+
+// dirConfig 将客户端配置文件放在临时目录中
+type dirConfig struct {
+	yaml_config.YmlConfig
+	dir string
+}
+
+func (c dirConfig) BasePath() string {
+	return c.dir + "/"
+}
+
+func (c dirConfig) GetString(key string) string {
+	if key == "AuthClient.FilePath" {
+		return "clients.yaml"
+	}
+	return c.YmlConfig.GetString(key)
+}
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "gosms-server-")
+	if err != nil {
+		panic(err)
+	}
+	if err = os.WriteFile(dir+"/clients.yaml", []byte("clients: []\n"), 0644); err != nil {
+		panic(err)
+	}
+	clients = &auth.FileStore{Config: dirConfig{YmlConfig: msc.ConfigYml, dir: dir}, Cache: make(map[string]*auth.Client)}
+	if err = clients.Load(); err != nil {
+		panic(err)
+	}
+	auth.Cache = clients
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	_ = l.Close()
+	msc.ConfigYml.Viper().Set("Server.CMPP.Port", port)
+	addr = fmt.Sprintf("127.0.0.1:%d", port)
+	server.Start(server.New(server.CMPP))
+	for i := 0; i < 50; i++ {
+		if c, err := net.Dial("tcp", addr); err == nil {
+			_ = c.Close()
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	code := m.Run()
+	_ = os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func newClient(cid string, ver byte) *auth.Client {
+	return &auth.Client{
+		ISP:             "cmpp",
+		ClientId:        cid,
+		SharedSecret:    "shared secret",
+		Version:         ver,
+		NeedReport:      1,
+		SmsDisplayNo:    "95566",
+		MtValidDuration: 2 * time.Hour,
+		MaxConns:        2,
+		MtWindowSize:    16,
+		Throughput:      100,
+	}
+}
+
+// conn CMPP 客户端连接
+type conn struct {
+	net.Conn
+	ac *codec.AuthConf
+}
+
+// login 连接服务端并登录，返回登录响应的状态码。
+// 处理登录的线程池（与CPU核数相同）繁忙时服务端直接关闭连接，此时稍后重试
+func login(t *testing.T, c *auth.Client) (*conn, uint32) {
+	ac := &codec.AuthConf{ClientId: c.ClientId, SharedSecret: c.SharedSecret, Version: c.Version, SmsDisplayNo: c.SmsDisplayNo}
+	for i := 0; ; i++ {
+		nc, err := net.Dial("tcp", addr)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = nc.Close() })
+		cc := &conn{Conn: nc, ac: ac}
+		cc.send(t, cmpp.NewConnect(ac, uint32(codec.B32Seq.NextVal())))
+		body, err := cc.next(cmpp.CMPP_CONNECT_RESP)
+		if errors.Is(err, io.EOF) && i < 10 {
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+		require.NoError(t, err, "waiting for %s", cmpp.CMPP_CONNECT_RESP)
+		if cmpp.V30.MajorMatch(c.Version) {
+			return cc, binary.BigEndian.Uint32(body[0:4])
+		}
+		return cc, uint32(body[0])
+	}
+}
+
+func (c *conn) send(t *testing.T, pdu codec.RequestPdu) {
+	_, err := c.Write(pdu.Encode())
+	require.NoError(t, err)
+}
+
+// read 读取指定命令的报文，返回报文体，跳过其他报文（如心跳、模拟上行短信）
+func (c *conn) read(t *testing.T, cmd cmpp.CommandId) []byte {
+	body, err := c.next(cmd)
+	require.NoError(t, err, "waiting for %s", cmd)
+	return body
+}
+
+func (c *conn) next(cmd cmpp.CommandId) ([]byte, error) {
+	if err := c.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+		return nil, err
+	}
+	for {
+		head := make([]byte, codec.HeadLen)
+		if _, err := io.ReadFull(c, head); err != nil {
+			return nil, err
+		}
+		var h cmpp.MessageHeader
+		_ = h.Decode(head)
+		body := make([]byte, h.TotalLength-codec.HeadLen)
+		if _, err := io.ReadFull(c, body); err != nil {
+			return nil, err
+		}
+		if h.CommandId == cmd {
+			return body, nil
+		}
+	}
+}
+
+// closed 等待服务端关闭连接
+func (c *conn) closed(t *testing.T) {
+	require.NoError(t, c.SetReadDeadline(time.Now().Add(5*time.Second)))
+	_, err := io.Copy(io.Discard, c)
+	require.NoError(t, err)
+}
+
+func TestOnAuthChange(t *testing.T) {
+	cli := newClient("901001", 0x30)
+	require.NoError(t, clients.Create(cli))
+	c1, code := login(t, cli)
+	require.Equal(t, uint32(0), code)
+	c2, code := login(t, cli)
+	require.Equal(t, uint32(0), code)
+
+	// 修改配置不断开会话
+	cli = newClient("901001", 0x30)
+	cli.MtWindowSize, cli.Throughput = 8, 50
+	require.NoError(t, clients.Update(cli))
+	c1.send(t, cmpp.NewActiveTest(uint32(codec.B32Seq.NextVal())))
+	c1.read(t, cmpp.CMPP_ACTIVE_TEST_RESP)
+
+	// 停用或删除客户端时断开其全部会话
+	cli = newClient("901001", 0x30)
+	cli.Disabled = true
+	require.NoError(t, clients.Update(cli))
+	c1.read(t, cmpp.CMPP_TERMINATE)
+	c2.read(t, cmpp.CMPP_TERMINATE)
+	c2.closed(t)
+
+	cli = newClient("901002", 0x30)
+	require.NoError(t, clients.Create(cli))
+	c3, code := login(t, cli)
+	require.Equal(t, uint32(0), code)
+	require.NoError(t, clients.Delete("cmpp", "901002"))
+	c3.read(t, cmpp.CMPP_TERMINATE)
+}
+
+// submit 发送一条短信，返回提交响应的状态码
+func (c *conn) submit(t *testing.T, opts ...codec.OptionFunc) uint32 {
+	pdus := cmpp.NewSubmit(c.ac, []string{"13800001111"}, "hello", uint32(codec.B32Seq.NextVal()), opts...)
+	c.send(t, pdus[0])
+	body := c.read(t, cmpp.CMPP_SUBMIT_RESP)
+	if cmpp.V30.MajorMatch(c.ac.Version) {
+		return binary.BigEndian.Uint32(body[8:12])
+	}
+	return uint32(body[8])
+}
+
+func TestSubmitCheck(t *testing.T) {
+	// 发送号码不符合客户端配置时，3.0版返回 10 Src_Id错误，2.0版返回 9 其他错误
+	for _, v := range []struct {
+		cid  string
+		ver  byte
+		code uint32
+	}{{"902030", 0x30, 10}, {"902020", 0x20, 9}} {
+		cli := newClient(v.cid, v.ver)
+		cli.SrcIdPrefixes = []string{"1065900"}
+		cli.ServiceIds = []string{"MTEST"}
+		require.NoError(t, clients.Create(cli))
+		c, code := login(t, cli)
+		require.Equal(t, uint32(0), code)
+		assert.Equal(t, v.code, c.submit(t), v.ver)
+
+		c.ac.SmsDisplayNo = "1065900"
+		assert.Equal(t, uint32(7), c.submit(t, codec.MtServiceId("OTHER")), v.ver)
+	}
+}
@@ -1,17 +1,36 @@
 package msc
 
 import (
+	"net"
+	"net/netip"
+
 	"github.com/hrygo/gosms/auth"
 	"github.com/hrygo/gosms/codec"
 	"github.com/hrygo/gosms/utils"
 )
 
+// FindAuthConf 查找客户端配置，客户端不存在或已停用时返回nil
 func FindAuthConf(isp, clientId string) (ac *codec.AuthConf) {
 	c := auth.Cache.FindByCid(isp, clientId)
-	if c == nil {
+	if c == nil || c.Disabled {
 		return nil
 	}
 	ac = &codec.AuthConf{}
 	utils.StructCopy(c, ac)
 	return
 }
+
+// AllowIP 客户端是否允许从来源地址 addr 登录
+func AllowIP(isp, clientId string, addr net.Addr) bool {
+	c := auth.Cache.FindByCid(isp, clientId)
+	return c != nil && c.AllowIP(AddrIP(addr))
+}
+
+// AddrIP 连接地址中的IP，无法解析时返回无效地址
+func AddrIP(addr net.Addr) netip.Addr {
+	if tcp, ok := addr.(*net.TCPAddr); ok {
+		ip, _ := netip.AddrFromSlice(tcp.IP)
+		return ip.Unmap()
+	}
+	return netip.Addr{}
+}